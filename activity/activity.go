@@ -0,0 +1,227 @@
+// Package activity scores how suitable each hour of a forecast is for
+// outdoor activities, using threshold rules on the hourly conditions.
+//
+// Activities are declared in JSON:
+//
+//	{"activities": [{
+//		"name": "drone flight",
+//		"minHours": 1,
+//		"rules": [
+//			{"field": "windGust", "idealMax": 10, "max": 20},
+//			{"field": "visibility", "min": 3, "idealMin": 5}
+//		]
+//	}]}
+//
+// An hour is rejected if any value is outside its rule's min and max. An
+// accepted hour scores 1 when every value is within its ideal range, and
+// less the closer the worst value is to its limit. Thresholds are in the
+// units of the forecast, which are US units unless requested otherwise.
+package activity
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+//go:embed activities.json
+var defaults string
+
+// Fields are the hourly fields rules may test.
+var Fields = map[string]func(darksky.HourlyDataPoint) float64{
+	"temperature":         func(p darksky.HourlyDataPoint) float64 { return p.Temperature },
+	"apparentTemperature": func(p darksky.HourlyDataPoint) float64 { return p.ApparentTemperature },
+	"dewPoint":            func(p darksky.HourlyDataPoint) float64 { return p.DewPoint },
+	"humidity":            func(p darksky.HourlyDataPoint) float64 { return p.Humidity },
+	"windSpeed":           func(p darksky.HourlyDataPoint) float64 { return p.WindSpeed },
+	"windGust":            func(p darksky.HourlyDataPoint) float64 { return p.WindGust },
+	"precipProbability":   func(p darksky.HourlyDataPoint) float64 { return p.PrecipProbability },
+	"precipIntensity":     func(p darksky.HourlyDataPoint) float64 { return p.PrecipIntensity },
+	"cloudCover":          func(p darksky.HourlyDataPoint) float64 { return p.CloudCover },
+	"uvIndex":             func(p darksky.HourlyDataPoint) float64 { return float64(p.UvIndex) },
+	"visibility":          func(p darksky.HourlyDataPoint) float64 { return p.Visibility },
+}
+
+// Rule limits one field of the hourly conditions. Any bound may be omitted.
+type Rule struct {
+	Field    string   `json:"field"`
+	Min      *float64 `json:"min,omitempty"`
+	IdealMin *float64 `json:"idealMin,omitempty"`
+	IdealMax *float64 `json:"idealMax,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+}
+
+// Activity is a named set of rules.
+type Activity struct {
+	Name     string `json:"name"`
+	MinHours int    `json:"minHours"` // the shortest window worth recommending
+	Rules    []Rule `json:"rules"`
+}
+
+// Load reads activities in the JSON format described in the package
+// documentation and checks that their rules are consistent.
+func Load(r io.Reader) ([]Activity, error) {
+	var doc struct {
+		Activities []Activity `json:"activities"`
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	for _, a := range doc.Activities {
+		if err := a.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return doc.Activities, nil
+}
+
+// Default returns the built-in activities: running, cycling, drone flight,
+// concrete pouring and crane operation.
+func Default() []Activity {
+	activities, err := Load(strings.NewReader(defaults))
+	if err != nil {
+		panic(fmt.Sprintf("activity: built-in activities are invalid: %v", err))
+	}
+	return activities
+}
+
+// Find returns the activity with the given name.
+func Find(activities []Activity, name string) (Activity, bool) {
+	for _, a := range activities {
+		if strings.EqualFold(a.Name, name) {
+			return a, true
+		}
+	}
+	return Activity{}, false
+}
+
+func (a Activity) validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("activity has no name")
+	}
+	for _, r := range a.Rules {
+		if _, ok := Fields[r.Field]; !ok {
+			return fmt.Errorf("%s: unknown field %q", a.Name, r.Field)
+		}
+		// The bounds must be in order: min <= idealMin <= idealMax <= max.
+		var prev *float64
+		for _, b := range []*float64{r.Min, r.IdealMin, r.IdealMax, r.Max} {
+			if b == nil {
+				continue
+			}
+			if prev != nil && *b < *prev {
+				return fmt.Errorf("%s: bounds of %s are out of order", a.Name, r.Field)
+			}
+			prev = b
+		}
+	}
+	return nil
+}
+
+// Hour is the suitability of an hour for an activity.
+type Hour struct {
+	Time     time.Time
+	Accepted bool
+	Score    float64  // from 0 to 1; 0 for rejected hours
+	Reasons  []string // why the hour was rejected
+}
+
+// Evaluate scores every hour of the forecast's hourly data.
+func (a Activity) Evaluate(f *darksky.Forecast) []Hour {
+	hours := make([]Hour, 0, len(f.Hourly.Data))
+	for _, p := range f.Hourly.Data {
+		h := Hour{Time: time.Unix(int64(p.Time), 0), Accepted: true, Score: 1}
+		for _, r := range a.Rules {
+			v := Fields[r.Field](p)
+			score, reason := r.score(v)
+			if reason != "" {
+				h.Accepted = false
+				h.Reasons = append(h.Reasons, reason)
+			}
+			h.Score = math.Min(h.Score, score)
+		}
+		if !h.Accepted {
+			h.Score = 0
+		}
+		hours = append(hours, h)
+	}
+	return hours
+}
+
+// score returns how close v is to the rule's ideal range, from 1 within it
+// to 0 at the limits, or a reason if v is beyond the limits.
+func (r Rule) score(v float64) (float64, string) {
+	switch {
+	case r.Min != nil && v < *r.Min:
+		return 0, fmt.Sprintf("%s %v is below the minimum of %v", r.Field, v, *r.Min)
+	case r.Max != nil && v > *r.Max:
+		return 0, fmt.Sprintf("%s %v is above the maximum of %v", r.Field, v, *r.Max)
+	case r.IdealMin != nil && v < *r.IdealMin:
+		return ramp(v, r.Min, *r.IdealMin), ""
+	case r.IdealMax != nil && v > *r.IdealMax:
+		return ramp(v, r.Max, *r.IdealMax), ""
+	}
+	return 1, ""
+}
+
+// ramp falls linearly from 1 at ideal to 0 at limit. Without a limit, being
+// outside the ideal range costs half the score.
+func ramp(v float64, limit *float64, ideal float64) float64 {
+	if limit == nil || *limit == ideal {
+		return 0.5
+	}
+	return 1 - (v-ideal)/(*limit-ideal)
+}
+
+// Window is a run of consecutive accepted hours.
+type Window struct {
+	Start time.Time
+	End   time.Time // the end of the last hour
+	Score float64   // the mean score of its hours
+}
+
+// BestWindows returns up to n runs of consecutive accepted hours lasting at
+// least the activity's MinHours, best first. Zero n returns them all.
+func (a Activity) BestWindows(f *darksky.Forecast, n int) []Window {
+	hours := a.Evaluate(f)
+	minHours := a.MinHours
+	if minHours < 1 {
+		minHours = 1
+	}
+	var windows []Window
+	for i := 0; i < len(hours); {
+		if !hours[i].Accepted {
+			i++
+			continue
+		}
+		j, total := i, 0.0
+		for j < len(hours) && hours[j].Accepted {
+			total += hours[j].Score
+			j++
+		}
+		if j-i >= minHours {
+			windows = append(windows, Window{
+				Start: hours[i].Time,
+				End:   hours[j-1].Time.Add(time.Hour),
+				Score: total / float64(j-i),
+			})
+		}
+		i = j
+	}
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].Score > windows[j].Score
+	})
+	if n > 0 && len(windows) > n {
+		windows = windows[:n]
+	}
+	return windows
+}
@@ -0,0 +1,123 @@
+package activity_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/activity"
+)
+
+func loadForecast(t *testing.T) *darksky.Forecast {
+	data, err := ioutil.ReadFile("../SouthernTerminus.json")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	var fc darksky.Forecast
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	return &fc
+}
+
+func TestDefault(t *testing.T) {
+	for _, name := range []string{"running", "cycling", "drone flight", "concrete pouring", "crane operation"} {
+		if _, ok := activity.Find(activity.Default(), name); !ok {
+			t.Errorf("Default() has no %q", name)
+		}
+	}
+}
+
+func TestActivity_Evaluate(t *testing.T) {
+	fc := loadForecast(t)
+	drone, _ := activity.Find(activity.Default(), "Drone Flight")
+	hours := drone.Evaluate(fc)
+	if len(hours) != len(fc.Hourly.Data) {
+		t.Fatalf("len(hours) = %d; want %d", len(hours), len(fc.Hourly.Data))
+	}
+
+	// The first hour gusts to 40.1 mph with a 24.86 mph wind.
+	first := hours[0]
+	if first.Accepted || first.Score != 0 {
+		t.Errorf("hours[0] = %+v; want rejected with a zero score", first)
+	}
+	want := []string{
+		"windGust 40.1 is above the maximum of 20",
+		"windSpeed 24.86 is above the maximum of 15",
+	}
+	if strings.Join(first.Reasons, "; ") != strings.Join(want, "; ") {
+		t.Errorf("hours[0].Reasons = %q; want %q", first.Reasons, want)
+	}
+
+	for i, h := range hours {
+		if h.Accepted && (h.Score < 0 || h.Score > 1 || len(h.Reasons) > 0) {
+			t.Errorf("hours[%d] = %+v; want a score in [0, 1] and no reasons", i, h)
+		}
+	}
+}
+
+func TestActivity_BestWindows(t *testing.T) {
+	fc := loadForecast(t)
+	drone, _ := activity.Find(activity.Default(), "drone flight")
+	windows := drone.BestWindows(fc, 0)
+	if len(windows) != 1 {
+		t.Fatalf("len(windows) = %d; want 1", len(windows))
+	}
+	// The wind drops below the limits at 2 AM on Wednesday and stays there.
+	if want := time.Unix(1576663200, 0); !windows[0].Start.Equal(want) {
+		t.Errorf("Start = %v; want %v", windows[0].Start, want)
+	}
+	if want := time.Unix(1576778400+3600, 0); !windows[0].End.Equal(want) {
+		t.Errorf("End = %v; want %v", windows[0].End, want)
+	}
+
+	// Requiring more hours than the calm spell lasts leaves no windows.
+	drone.MinHours = 100
+	if windows := drone.BestWindows(fc, 0); len(windows) != 0 {
+		t.Errorf("len(windows) = %d; want 0", len(windows))
+	}
+}
+
+func TestActivity_BestWindows_order(t *testing.T) {
+	as, err := activity.Load(strings.NewReader(`{"activities": [{
+		"name": "picnic",
+		"rules": [{"field": "temperature", "min": 40, "idealMin": 60}]
+	}]}`))
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	var fc darksky.Forecast
+	for i, temp := range []float64{50, 50, 30, 70, 70, 30, 45} {
+		fc.Hourly.Data = append(fc.Hourly.Data, darksky.HourlyDataPoint{Time: i * 3600, Temperature: temp})
+	}
+	windows := as[0].BestWindows(&fc, 2)
+	if len(windows) != 2 {
+		t.Fatalf("len(windows) = %d; want 2", len(windows))
+	}
+	if windows[0].Start.Unix() != 3*3600 || windows[0].Score != 1 {
+		t.Errorf("windows[0] = %+v; want the warm spell first, scoring 1", windows[0])
+	}
+	if windows[1].Start.Unix() != 0 || windows[1].Score != 0.5 {
+		t.Errorf("windows[1] = %+v; want the first spell, scoring 0.5", windows[1])
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":     `{"activities": [{"name": "a", "rules": [{"field": "sunshine", "max": 1}]}]}`,
+		"bounds disordered": `{"activities": [{"name": "a", "rules": [{"field": "windGust", "idealMax": 30, "max": 20}]}]}`,
+		"no name":           `{"activities": [{"rules": []}]}`,
+		"unknown key":       `{"activities": [{"name": "a", "maximum": 3}]}`,
+		"malformed":         `{"activities": [`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := activity.Load(strings.NewReader(input)); err == nil {
+				t.Errorf("err = nil; want non-nil")
+			}
+		})
+	}
+}
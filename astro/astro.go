@@ -0,0 +1,203 @@
+// Package astro computes the positions of the sun and moon and the times of
+// sunrise, sunset and twilight for any place and date, without the API.
+//
+// The sun follows NOAA's solar calculator, which is accurate to about a
+// minute between 1800 and 2100 outside the polar regions. The moon uses the
+// largest periodic terms of its longitude, which puts its phase within about
+// half a percent of a lunation.
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// Solar elevations in degrees that define the events of a day. Sunrise and
+// sunset allow for refraction and the radius of the sun's disc.
+const (
+	SunriseElevation      = -0.833
+	CivilElevation        = -6.0
+	NauticalElevation     = -12.0
+	AstronomicalElevation = -18.0
+	GoldenHourElevation   = 6.0
+)
+
+const rad = math.Pi / 180
+
+// Times are the solar events of a day. An event that does not happen on the
+// day, such as sunset during the polar summer, is the zero time.
+type Times struct {
+	SolarNoon time.Time
+	Sunrise   time.Time
+	Sunset    time.Time
+
+	CivilDawn        time.Time
+	CivilDusk        time.Time
+	NauticalDawn     time.Time
+	NauticalDusk     time.Time
+	AstronomicalDawn time.Time
+	AstronomicalDusk time.Time
+
+	// The morning golden hour runs from Sunrise to GoldenHourEnd and the
+	// evening one from GoldenHourStart to Sunset.
+	GoldenHourEnd   time.Time
+	GoldenHourStart time.Time
+}
+
+// SunTimes returns the solar events on the day of date in date's location,
+// at the given coordinates in decimal degrees.
+func SunTimes(date time.Time, lat, long float64) Times {
+	loc := date.Location()
+	noon := solarNoon(time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, loc), long)
+	t := Times{SolarNoon: noon.In(loc)}
+	event := func(elevation float64, rising bool) time.Time {
+		e, ok := solarEvent(noon, lat, long, elevation, rising)
+		if !ok {
+			return time.Time{}
+		}
+		return e.In(loc)
+	}
+	t.Sunrise, t.Sunset = event(SunriseElevation, true), event(SunriseElevation, false)
+	t.CivilDawn, t.CivilDusk = event(CivilElevation, true), event(CivilElevation, false)
+	t.NauticalDawn, t.NauticalDusk = event(NauticalElevation, true), event(NauticalElevation, false)
+	t.AstronomicalDawn, t.AstronomicalDusk = event(AstronomicalElevation, true), event(AstronomicalElevation, false)
+	t.GoldenHourEnd, t.GoldenHourStart = event(GoldenHourElevation, true), event(GoldenHourElevation, false)
+	return t
+}
+
+// SunPosition returns the sun's elevation above the horizon and its azimuth
+// clockwise from north, in degrees, ignoring refraction.
+func SunPosition(t time.Time, lat, long float64) (elevation, azimuth float64) {
+	decl, eqTime := sun(julianDay(t))
+	u := t.UTC()
+	minutes := float64(u.Hour()*60+u.Minute()) + (float64(u.Second())+float64(u.Nanosecond())/1e9)/60
+	hourAngle := (minutes+eqTime+4*long)/4 - 180
+	phi, delta, ha := lat*rad, decl*rad, hourAngle*rad
+	sinEl := math.Sin(phi)*math.Sin(delta) + math.Cos(phi)*math.Cos(delta)*math.Cos(ha)
+	elevation = math.Asin(clamp(sinEl)) / rad
+	azimuth = math.Atan2(math.Sin(ha), math.Cos(ha)*math.Sin(phi)-math.Tan(delta)*math.Cos(phi))/rad + 180
+	return elevation, math.Mod(azimuth, 360)
+}
+
+// MoonPhase returns the fraction of the lunation at t, as Dark Sky's
+// moonPhase does: 0 is a new moon, 0.25 the first quarter, 0.5 a full moon
+// and 0.75 the last quarter.
+func MoonPhase(t time.Time) float64 {
+	return elongation(julianDay(t)) / 360
+}
+
+// MoonIllumination returns the illuminated fraction of the moon's disc at t.
+func MoonIllumination(t time.Time) float64 {
+	return (1 - math.Cos(elongation(julianDay(t))*rad)) / 2
+}
+
+// solarNoon returns the sun's transit nearest to t.
+func solarNoon(t time.Time, long float64) time.Time {
+	noon := t
+	for i := 0; i < 2; i++ {
+		_, eqTime := sun(julianDay(noon))
+		day := time.Date(noon.UTC().Year(), noon.UTC().Month(), noon.UTC().Day(), 0, 0, 0, 0, time.UTC)
+		noon = day.Add(minutes(720 - 4*long - eqTime))
+		// The transit on the UTC day may be a day away from t.
+		if d := noon.Sub(t); d > 12*time.Hour {
+			noon = noon.Add(-24 * time.Hour)
+		} else if d < -12*time.Hour {
+			noon = noon.Add(24 * time.Hour)
+		}
+	}
+	return noon
+}
+
+// solarEvent returns when the sun passes the elevation before or after noon.
+func solarEvent(noon time.Time, lat, long, elevation float64, rising bool) (time.Time, bool) {
+	_, noonEqTime := sun(julianDay(noon))
+	t := noon
+	for i := 0; i < 3; i++ {
+		decl, eqTime := sun(julianDay(t))
+		phi, delta := lat*rad, decl*rad
+		cosH := (math.Sin(elevation*rad) - math.Sin(phi)*math.Sin(delta)) / (math.Cos(phi) * math.Cos(delta))
+		if cosH < -1 || cosH > 1 {
+			return time.Time{}, false
+		}
+		h := math.Acos(cosH) / rad
+		if rising {
+			h = -h
+		}
+		// The equation of time drifts a little between noon and the event.
+		t = noon.Add(minutes(4*h + noonEqTime - eqTime))
+	}
+	return t, true
+}
+
+// sun returns the sun's declination in degrees and the equation of time in
+// minutes on a Julian day.
+func sun(jd float64) (decl, eqTime float64) {
+	T := (jd - 2451545) / 36525
+	l0 := math.Mod(280.46646+T*(36000.76983+T*0.0003032), 360)
+	m := 357.52911 + T*(35999.05029-0.0001537*T)
+	e := 0.016708634 - T*(0.000042037+0.0000001267*T)
+	lambda := apparentLongitude(T, l0, m)
+	omega := 125.04 - 1934.136*T
+	eps0 := 23 + (26+(21.448-T*(46.815+T*(0.00059-T*0.001813)))/60)/60
+	eps := eps0 + 0.00256*math.Cos(omega*rad)
+
+	decl = math.Asin(math.Sin(eps*rad)*math.Sin(lambda*rad)) / rad
+	y := math.Pow(math.Tan(eps*rad/2), 2)
+	eqTime = 4 / rad * (y*math.Sin(2*l0*rad) - 2*e*math.Sin(m*rad) +
+		4*e*y*math.Sin(m*rad)*math.Cos(2*l0*rad) -
+		0.5*y*y*math.Sin(4*l0*rad) - 1.25*e*e*math.Sin(2*m*rad))
+	return decl, eqTime
+}
+
+// apparentLongitude returns the sun's apparent ecliptic longitude in degrees
+// from its mean longitude and mean anomaly.
+func apparentLongitude(T, l0, m float64) float64 {
+	c := math.Sin(m*rad)*(1.914602-T*(0.004817+0.000014*T)) +
+		math.Sin(2*m*rad)*(0.019993-0.000101*T) +
+		math.Sin(3*m*rad)*0.000289
+	omega := 125.04 - 1934.136*T
+	return l0 + c - 0.00569 - 0.00478*math.Sin(omega*rad)
+}
+
+// elongation returns how far east of the sun the moon is along the
+// ecliptic, in degrees in [0, 360).
+func elongation(jd float64) float64 {
+	d := jd - 2451545
+	T := d / 36525
+	sunL0 := 280.46646 + 36000.76983*T
+	sunM := 357.52911 + 35999.05029*T
+	sunLong := apparentLongitude(T, sunL0, sunM)
+
+	l := 218.316 + 13.176396*d   // mean longitude
+	m := 134.963 + 13.064993*d   // mean anomaly
+	D := 297.850 + 12.190749*d   // mean elongation
+	f := 93.272 + 13.229350*d    // argument of latitude
+	ms := 357.529 + 0.98560028*d // the sun's mean anomaly
+	moonLong := l +
+		6.289*math.Sin(m*rad) -
+		1.274*math.Sin((m-2*D)*rad) +
+		0.658*math.Sin(2*D*rad) -
+		0.186*math.Sin(ms*rad) -
+		0.059*math.Sin((2*m-2*D)*rad) -
+		0.057*math.Sin((m-2*D+ms)*rad) +
+		0.053*math.Sin((m+2*D)*rad) +
+		0.046*math.Sin((2*D-ms)*rad) +
+		0.041*math.Sin((m-ms)*rad) -
+		0.035*math.Sin(D*rad) -
+		0.031*math.Sin((m+ms)*rad) -
+		0.015*math.Sin((2*f-2*D)*rad) +
+		0.011*math.Sin((m-4*D)*rad)
+	return math.Mod(math.Mod(moonLong-sunLong, 360)+360, 360)
+}
+
+func julianDay(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5
+}
+
+func minutes(m float64) time.Duration {
+	return time.Duration(m * float64(time.Minute))
+}
+
+func clamp(x float64) float64 {
+	return math.Max(-1, math.Min(1, x))
+}
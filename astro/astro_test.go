@@ -0,0 +1,173 @@
+package astro_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/astro"
+)
+
+func within(t *testing.T, name string, got, want time.Time, tolerance time.Duration) {
+	t.Helper()
+	if d := got.Sub(want); d > tolerance || d < -tolerance {
+		t.Errorf("%s = %v; want %v within %v", name, got, want, tolerance)
+	}
+}
+
+func TestSunTimes_London(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	times := astro.SunTimes(time.Date(2020, 6, 21, 0, 0, 0, 0, london), 51.5074, -0.1278)
+	within(t, "Sunrise", times.Sunrise, time.Date(2020, 6, 21, 4, 43, 0, 0, london), 2*time.Minute)
+	within(t, "Sunset", times.Sunset, time.Date(2020, 6, 21, 21, 21, 0, 0, london), 2*time.Minute)
+	within(t, "SolarNoon", times.SolarNoon, time.Date(2020, 6, 21, 13, 2, 0, 0, london), 2*time.Minute)
+	if times.Sunrise.Location() != london {
+		t.Errorf("Sunrise is in %v; want %v", times.Sunrise.Location(), london)
+	}
+	// The sun never gets 18° below the horizon in London at midsummer.
+	if !times.AstronomicalDawn.IsZero() || !times.AstronomicalDusk.IsZero() {
+		t.Errorf("astronomical twilight = %v, %v; want zero times", times.AstronomicalDawn, times.AstronomicalDusk)
+	}
+	for name, ts := range map[string][]time.Time{
+		"morning": {times.NauticalDawn, times.CivilDawn, times.Sunrise, times.GoldenHourEnd, times.SolarNoon},
+		"evening": {times.SolarNoon, times.GoldenHourStart, times.Sunset, times.CivilDusk, times.NauticalDusk},
+	} {
+		for i := 1; i < len(ts); i++ {
+			if !ts[i].After(ts[i-1]) {
+				t.Errorf("%s events are out of order: %v", name, ts)
+			}
+		}
+	}
+}
+
+func TestSunTimes_polar(t *testing.T) {
+	tromso, err := time.LoadLocation("Europe/Oslo")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	summer := astro.SunTimes(time.Date(2020, 6, 21, 0, 0, 0, 0, tromso), 69.6492, 18.9553)
+	if !summer.Sunrise.IsZero() || !summer.Sunset.IsZero() {
+		t.Errorf("midnight sun: Sunrise, Sunset = %v, %v; want zero times", summer.Sunrise, summer.Sunset)
+	}
+	winter := astro.SunTimes(time.Date(2020, 12, 21, 0, 0, 0, 0, tromso), 69.6492, 18.9553)
+	if !winter.Sunrise.IsZero() || winter.CivilDawn.IsZero() {
+		t.Errorf("polar night: Sunrise, CivilDawn = %v, %v; want no sunrise but a civil dawn", winter.Sunrise, winter.CivilDawn)
+	}
+}
+
+func TestSunPosition(t *testing.T) {
+	places := map[string]struct{ lat, long float64 }{
+		"southern terminus": {32.58972, -116.466988},
+		"sydney":            {-33.8688, 151.2093},
+		"quito":             {-0.1807, -78.4678},
+		"reykjavik":         {64.1466, -21.9426},
+	}
+	for name, p := range places {
+		t.Run(name, func(t *testing.T) {
+			for _, date := range []time.Time{
+				time.Date(2019, 12, 17, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, 3, 20, 0, 0, 0, 0, time.UTC),
+				time.Date(2020, 6, 21, 0, 0, 0, 0, time.UTC),
+			} {
+				times := astro.SunTimes(date, p.lat, p.long)
+				for event, want := range map[time.Time]float64{
+					times.Sunrise:         astro.SunriseElevation,
+					times.Sunset:          astro.SunriseElevation,
+					times.CivilDusk:       astro.CivilElevation,
+					times.GoldenHourStart: astro.GoldenHourElevation,
+				} {
+					if event.IsZero() {
+						continue
+					}
+					if el, _ := astro.SunPosition(event, p.lat, p.long); math.Abs(el-want) > 0.05 {
+						t.Errorf("%v: elevation at %v = %f; want %f", date.Format("2006-01-02"), event, el, want)
+					}
+				}
+				// At noon the sun is due south or due north, or overhead.
+				el, az := astro.SunPosition(times.SolarNoon, p.lat, p.long)
+				if el < 89 && math.Abs(az-180) > 0.5 && math.Abs(az) > 0.5 && math.Abs(az-360) > 0.5 {
+					t.Errorf("%v: azimuth at noon = %f; want 0 or 180", date.Format("2006-01-02"), az)
+				}
+			}
+		})
+	}
+}
+
+func TestMoonPhase(t *testing.T) {
+	tests := map[string]struct {
+		at           time.Time
+		phase        float64
+		illumination float64
+	}{
+		"full moon":     {at: time.Date(2019, 12, 12, 5, 12, 0, 0, time.UTC), phase: 0.5, illumination: 1},
+		"last quarter":  {at: time.Date(2019, 12, 19, 4, 57, 0, 0, time.UTC), phase: 0.75, illumination: 0.5},
+		"new moon":      {at: time.Date(2019, 12, 26, 5, 13, 0, 0, time.UTC), phase: 1, illumination: 0},
+		"first quarter": {at: time.Date(2020, 1, 3, 4, 45, 0, 0, time.UTC), phase: 0.25, illumination: 0.5},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			phase := astro.MoonPhase(tc.at)
+			if phase < 0 || phase >= 1 {
+				t.Fatalf("MoonPhase() = %f; want a value in [0, 1)", phase)
+			}
+			diff := phase - tc.phase
+			diff -= math.Round(diff)
+			if math.Abs(diff) > 0.005 {
+				t.Errorf("MoonPhase() = %f; want %f", phase, tc.phase)
+			}
+			if illum := astro.MoonIllumination(tc.at); math.Abs(illum-tc.illumination) > 0.02 {
+				t.Errorf("MoonIllumination() = %f; want %f", illum, tc.illumination)
+			}
+		})
+	}
+}
+
+func loadForecast(t *testing.T) *darksky.Forecast {
+	data, err := ioutil.ReadFile("../SouthernTerminus.json")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	var fc darksky.Forecast
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	return &fc
+}
+
+func TestCrossCheck(t *testing.T) {
+	fc := loadForecast(t)
+	ds, err := astro.CrossCheck(fc, astro.DefaultTolerance)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(ds) != 0 {
+		t.Errorf("CrossCheck() = %+v; want no discrepancies", ds)
+	}
+
+	fc.Daily.Data[2].SunsetTime += 3600
+	fc.Daily.Data[5].MoonPhase = 0.2
+	ds, err = astro.CrossCheck(fc, astro.DefaultTolerance)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(ds) != 2 {
+		t.Fatalf("len(CrossCheck()) = %d; want 2", len(ds))
+	}
+	if ds[0].Field != "sunsetTime" || math.Abs(ds[0].Difference-3600) > 180 {
+		t.Errorf("ds[0] = %+v; want sunsetTime about an hour late", ds[0])
+	}
+	if ds[1].Field != "moonPhase" || ds[1].Day.Day() != 22 {
+		t.Errorf("ds[1] = %+v; want moonPhase on the 22nd", ds[1])
+	}
+
+	fc.Timezone = "Nowhere/Special"
+	if _, err := astro.CrossCheck(fc, astro.DefaultTolerance); err != darksky.ErrUnableToLoadTimezone {
+		t.Errorf("err = %v; want %v", err, darksky.ErrUnableToLoadTimezone)
+	}
+}
@@ -0,0 +1,84 @@
+package astro
+
+import (
+	"math"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// Tolerance is how far a forecast may differ from the computed values
+// before CrossCheck reports it.
+type Tolerance struct {
+	Time      time.Duration // for sunrise and sunset
+	MoonPhase float64       // in fractions of a lunation
+}
+
+// DefaultTolerance allows for Dark Sky rounding times to the minute and
+// reporting a single moon phase for the whole day.
+var DefaultTolerance = Tolerance{Time: 3 * time.Minute, MoonPhase: 0.03}
+
+// Discrepancy is a daily field of a forecast that disagrees with the
+// computed value. Times are given as UNIX time.
+type Discrepancy struct {
+	Day        time.Time
+	Field      string // the JSON name of the field, e.g. sunriseTime
+	Forecast   float64
+	Computed   float64
+	Difference float64 // in seconds for times
+}
+
+// CrossCheck compares the sunrise, sunset and moon phase of each day of the
+// forecast with the values computed for the forecast's coordinates, and
+// returns the ones that differ by more than the tolerance. Days without a
+// sunrise or sunset, which Dark Sky omits, are skipped. It returns
+// darksky.ErrUnableToLoadTimezone if the forecast's time zone is unknown.
+func CrossCheck(f *darksky.Forecast, tol Tolerance) ([]Discrepancy, error) {
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return nil, darksky.ErrUnableToLoadTimezone
+	}
+	var ds []Discrepancy
+	for _, d := range f.Daily.Data {
+		day := time.Unix(int64(d.Time), 0).In(loc)
+		times := SunTimes(day, f.Latitude, f.Longitude)
+		for _, c := range []struct {
+			field    string
+			forecast int
+			computed time.Time
+		}{
+			{"sunriseTime", d.SunriseTime, times.Sunrise},
+			{"sunsetTime", d.SunsetTime, times.Sunset},
+		} {
+			if c.forecast == 0 || c.computed.IsZero() {
+				continue
+			}
+			diff := float64(c.forecast) - float64(c.computed.UnixNano())/1e9
+			if math.Abs(diff) > tol.Time.Seconds() {
+				ds = append(ds, Discrepancy{
+					Day:        day,
+					Field:      c.field,
+					Forecast:   float64(c.forecast),
+					Computed:   float64(c.computed.Unix()),
+					Difference: diff,
+				})
+			}
+		}
+
+		// Dark Sky reports the phase of the moon at noon.
+		phase := MoonPhase(time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, loc))
+		diff := d.MoonPhase - phase
+		// Phases wrap around at the new moon.
+		diff -= math.Round(diff)
+		if math.Abs(diff) > tol.MoonPhase {
+			ds = append(ds, Discrepancy{
+				Day:        day,
+				Field:      "moonPhase",
+				Forecast:   d.MoonPhase,
+				Computed:   phase,
+				Difference: diff,
+			})
+		}
+	}
+	return ds, nil
+}
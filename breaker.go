@@ -0,0 +1,243 @@
+package darksky
+
+import (
+	"sync"
+	"time"
+)
+
+// Defaults for a Breaker.
+const (
+	DefaultBreakerWindow      = time.Minute
+	DefaultBreakerMinRequests = 10
+	DefaultBreakerFailureRate = 0.5
+	DefaultBreakerCooldown    = 30 * time.Second
+	DefaultBreakerProbes      = 1
+)
+
+// BreakerState is the state of a Breaker.
+type BreakerState int
+
+const (
+	// Closed lets every request through.
+	Closed BreakerState = iota
+
+	// Open rejects every request with ErrCircuitOpen.
+	Open
+
+	// HalfOpen lets a few probe requests through to find out whether the
+	// endpoint has recovered.
+	HalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Clock tells the time. Breaker uses it so tests can control time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Breaker is a circuit breaker. It opens when at least FailureRate of the
+// requests in the last Window failed, provided there were at least
+// MinRequests of them. After Cooldown it lets Probes requests through and
+// closes if they all succeed or opens again as soon as one fails. The zero
+// value uses the defaults and is ready to use.
+type Breaker struct {
+	Window      time.Duration // DefaultBreakerWindow if zero
+	MinRequests int           // DefaultBreakerMinRequests if zero
+	FailureRate float64       // DefaultBreakerFailureRate if zero
+	Cooldown    time.Duration // DefaultBreakerCooldown if zero
+	Probes      int           // DefaultBreakerProbes if zero
+
+	// OnStateChange, if set, is called after every change of state.
+	OnStateChange func(from, to BreakerState)
+
+	// Clock is the system clock if nil.
+	Clock Clock
+
+	mu       sync.Mutex
+	state    BreakerState
+	openedAt time.Time
+	outcomes []outcome
+	probing  int    // probes let through and not yet done
+	probed   int    // probes that succeeded
+	gen      uint64 // incremented on every change of state
+}
+
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// State returns the state of the breaker, which changes from Open to
+// HalfOpen once the cool-down has passed.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	notify := b.advance()
+	s := b.state
+	b.mu.Unlock()
+	if notify != nil {
+		notify()
+	}
+	return s
+}
+
+// Allow returns ErrCircuitOpen if a request may not be made now. Otherwise
+// the caller must report the outcome of the request with Done or Abandon,
+// passing on the generation Allow returned.
+func (b *Breaker) Allow() (gen uint64, err error) {
+	b.mu.Lock()
+	notify := b.advance()
+	switch b.state {
+	case Open:
+		err = ErrCircuitOpen
+	case HalfOpen:
+		if b.probing+b.probed >= b.probes() {
+			err = ErrCircuitOpen
+		} else {
+			b.probing++
+		}
+	}
+	gen = b.gen
+	b.mu.Unlock()
+	if notify != nil {
+		notify()
+	}
+	return gen, err
+}
+
+// Done records whether a request let through by Allow failed. Requests let
+// through before the breaker last changed state are ignored: they say
+// nothing about the endpoint since, and were not counted as probes.
+func (b *Breaker) Done(gen uint64, failed bool) {
+	b.mu.Lock()
+	now := b.now()
+	var notify func()
+	switch {
+	case gen != b.gen:
+	case b.state == Closed:
+		b.outcomes = append(b.outcomes, outcome{now, failed})
+		b.prune(now)
+		if failed && b.tripped() {
+			notify = b.set(Open, now)
+		}
+	case b.state == HalfOpen:
+		if b.probing > 0 {
+			b.probing--
+		}
+		if failed {
+			notify = b.set(Open, now)
+		} else if b.probed++; b.probed >= b.probes() {
+			notify = b.set(Closed, now)
+		}
+	}
+	b.mu.Unlock()
+	if notify != nil {
+		notify()
+	}
+}
+
+// Abandon records that a request let through by Allow ended without
+// showing whether the endpoint works, e.g. because its context was
+// cancelled.
+func (b *Breaker) Abandon(gen uint64) {
+	b.mu.Lock()
+	if gen == b.gen && b.state == HalfOpen && b.probing > 0 {
+		b.probing--
+	}
+	b.mu.Unlock()
+}
+
+// advance moves an open breaker to half-open once the cool-down has passed.
+func (b *Breaker) advance() func() {
+	cooldown := b.Cooldown
+	if cooldown == 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	now := b.now()
+	if b.state == Open && !now.Before(b.openedAt.Add(cooldown)) {
+		return b.set(HalfOpen, now)
+	}
+	return nil
+}
+
+// set changes the state and returns the callback to run once the lock is
+// released, or nil.
+func (b *Breaker) set(s BreakerState, now time.Time) func() {
+	from := b.state
+	b.state = s
+	b.gen++
+	b.probing, b.probed = 0, 0
+	switch s {
+	case Open:
+		b.openedAt = now
+	case Closed:
+		b.outcomes = nil
+	}
+	if b.OnStateChange == nil || from == s {
+		return nil
+	}
+	onStateChange := b.OnStateChange
+	return func() { onStateChange(from, s) }
+}
+
+func (b *Breaker) tripped() bool {
+	minRequests := b.MinRequests
+	if minRequests == 0 {
+		minRequests = DefaultBreakerMinRequests
+	}
+	rate := b.FailureRate
+	if rate == 0 {
+		rate = DefaultBreakerFailureRate
+	}
+	if len(b.outcomes) < minRequests {
+		return false
+	}
+	failed := 0
+	for _, o := range b.outcomes {
+		if o.failed {
+			failed++
+		}
+	}
+	return float64(failed) >= rate*float64(len(b.outcomes))
+}
+
+// prune forgets the outcomes that have left the window.
+func (b *Breaker) prune(now time.Time) {
+	window := b.Window
+	if window == 0 {
+		window = DefaultBreakerWindow
+	}
+	i := 0
+	for i < len(b.outcomes) && !b.outcomes[i].at.After(now.Add(-window)) {
+		i++
+	}
+	b.outcomes = b.outcomes[i:]
+}
+
+func (b *Breaker) probes() int {
+	if b.Probes == 0 {
+		return DefaultBreakerProbes
+	}
+	return b.Probes
+}
+
+func (b *Breaker) now() time.Time {
+	if b.Clock == nil {
+		return systemClock{}.Now()
+	}
+	return b.Clock.Now()
+}
@@ -0,0 +1,201 @@
+package darksky_test
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/darkskytest"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newBreaker(clock *fakeClock, changes *[]string) *darksky.Breaker {
+	return &darksky.Breaker{
+		Window:      time.Minute,
+		MinRequests: 4,
+		FailureRate: 0.5,
+		Cooldown:    30 * time.Second,
+		Probes:      2,
+		Clock:       clock,
+		OnStateChange: func(from, to darksky.BreakerState) {
+			*changes = append(*changes, fmt.Sprintf("%v->%v", from, to))
+		},
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1576605600, 0)}
+	var changes []string
+	b := newBreaker(clock, &changes)
+
+	request := func(failed bool) error {
+		gen, err := b.Allow()
+		if err != nil {
+			return err
+		}
+		b.Done(gen, failed)
+		return nil
+	}
+	for i, failed := range []bool{false, true, false, true} {
+		clock.Advance(time.Second)
+		if err := request(failed); err != nil {
+			t.Fatalf("request %d: err = %v; want nil", i, err)
+		}
+	}
+	if got := b.State(); got != darksky.Open {
+		t.Fatalf("State() = %v; want %v", got, darksky.Open)
+	}
+	if _, err := b.Allow(); err != darksky.ErrCircuitOpen {
+		t.Errorf("err = %v; want %v", err, darksky.ErrCircuitOpen)
+	}
+
+	clock.Advance(29 * time.Second)
+	if got := b.State(); got != darksky.Open {
+		t.Errorf("State() before the cool-down = %v; want %v", got, darksky.Open)
+	}
+	clock.Advance(time.Second)
+	if got := b.State(); got != darksky.HalfOpen {
+		t.Fatalf("State() after the cool-down = %v; want %v", got, darksky.HalfOpen)
+	}
+	// Only two probes at a time, and a failed one opens the breaker again.
+	gen1, err1 := b.Allow()
+	gen2, err2 := b.Allow()
+	if err1 != nil || err2 != nil {
+		t.Fatalf("the probes were not allowed")
+	}
+	if _, err := b.Allow(); err != darksky.ErrCircuitOpen {
+		t.Errorf("third probe: err = %v; want %v", err, darksky.ErrCircuitOpen)
+	}
+	b.Done(gen1, false)
+	b.Done(gen2, true)
+	if got := b.State(); got != darksky.Open {
+		t.Fatalf("State() after a failed probe = %v; want %v", got, darksky.Open)
+	}
+
+	clock.Advance(30 * time.Second)
+	if request(false) != nil || request(false) != nil {
+		t.Fatalf("the probes were not allowed")
+	}
+	if got := b.State(); got != darksky.Closed {
+		t.Errorf("State() after the probes = %v; want %v", got, darksky.Closed)
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %v; want %v", changes, want)
+	}
+}
+
+func TestBreaker_window(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1576605600, 0)}
+	var changes []string
+	b := newBreaker(clock, &changes)
+	// Failures spread out over more than the window never add up.
+	for i := 0; i < 10; i++ {
+		gen, err := b.Allow()
+		if err != nil {
+			t.Fatalf("request %d: err = %v; want nil", i, err)
+		}
+		b.Done(gen, true)
+		clock.Advance(20 * time.Second)
+	}
+	if got := b.State(); got != darksky.Closed {
+		t.Errorf("State() = %v; want %v", got, darksky.Closed)
+	}
+
+	// Abandoned probes free their slot without closing the breaker.
+	for i := 0; i < 4; i++ {
+		gen, _ := b.Allow()
+		b.Done(gen, true)
+	}
+	clock.Advance(30 * time.Second)
+	b.Allow()
+	gen, _ := b.Allow()
+	b.Abandon(gen)
+	if _, err := b.Allow(); err != nil {
+		t.Errorf("err = %v; want nil", err)
+	}
+	if got := b.State(); got != darksky.HalfOpen {
+		t.Errorf("State() = %v; want %v", got, darksky.HalfOpen)
+	}
+}
+
+func TestBreaker_staleDone(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1576605600, 0)}
+	var changes []string
+	b := newBreaker(clock, &changes)
+	// A slow request let through while closed finishes after the breaker
+	// opened and went half-open. Neither its success nor its abandonment
+	// counts as a probe.
+	stale, _ := b.Allow()
+	stale2, _ := b.Allow()
+	for i := 0; i < 4; i++ {
+		gen, _ := b.Allow()
+		b.Done(gen, true)
+	}
+	clock.Advance(30 * time.Second)
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	b.Done(stale, false)
+	b.Abandon(stale2)
+	b.Done(probe, false)
+	if got := b.State(); got != darksky.HalfOpen {
+		t.Errorf("State() = %v; want %v after one of two probes", got, darksky.HalfOpen)
+	}
+	if _, err := b.Allow(); err != nil {
+		t.Errorf("err = %v; want nil for the second probe", err)
+	}
+	if _, err := b.Allow(); err != darksky.ErrCircuitOpen {
+		t.Errorf("err = %v; want %v once both probes were let through", err, darksky.ErrCircuitOpen)
+	}
+}
+
+func TestClient_Breaker(t *testing.T) {
+	server := darkskytest.NewServer(1)
+	defer server.Close()
+	clock := &fakeClock{t: time.Unix(1576605600, 0)}
+	var changes []string
+	c := &darksky.Client{Key: "key", BaseURL: server.URL, Breaker: newBreaker(clock, &changes)}
+
+	// Requests the API rejects as bad are not failures of the API.
+	server.Handler().FailNext(4, http.StatusBadRequest)
+	for i := 0; i < 4; i++ {
+		if _, err := c.Forecast(stLat, stLong); err != darksky.ErrBadRequest {
+			t.Fatalf("err = %v; want %v", err, darksky.ErrBadRequest)
+		}
+	}
+	if got := c.Breaker.State(); got != darksky.Closed {
+		t.Fatalf("State() = %v; want %v", got, darksky.Closed)
+	}
+
+	server.Handler().FailNext(4, http.StatusServiceUnavailable)
+	for i := 0; i < 4; i++ {
+		c.Forecast(stLat, stLong)
+	}
+	requests := server.Handler().Requests()
+	if _, err := c.Forecast(stLat, stLong); err != darksky.ErrCircuitOpen {
+		t.Fatalf("err = %v; want %v", err, darksky.ErrCircuitOpen)
+	}
+	if got := server.Handler().Requests(); got != requests {
+		t.Errorf("Requests() = %d; want %d, no request while open", got, requests)
+	}
+
+	clock.Advance(30 * time.Second)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Forecast(stLat, stLong); err != nil {
+			t.Fatalf("probe %d: err = %v; want nil", i, err)
+		}
+	}
+	if got := c.Breaker.State(); got != darksky.Closed {
+		t.Errorf("State() = %v; want %v", got, darksky.Closed)
+	}
+}
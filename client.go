@@ -1,11 +1,13 @@
 package darksky
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
+
+	"github.com/sophiaehlen/darksky-client/geo"
 )
 
 const (
@@ -13,20 +15,33 @@ const (
 )
 
 type Client struct {
-	Key        string
-	BaseURL    string
-	HttpClient interface {
-		Do(*http.Request) (*http.Response, error)
-	}
+	Key     string
+	BaseURL string
+	// HttpClient sends the requests, a plain http.Client if nil. Wrap it with
+	// Chain to add middlewares.
+	HttpClient Doer
+
+	// Precision is the number of decimal places coordinates are sent with.
+	// It defaults to DefaultPrecision.
+	Precision int
+
+	// MaxBodySize is the largest response body, in bytes, that is read
+	// before giving up with ErrResponseTooLarge. It defaults to
+	// DefaultMaxBodySize.
+	MaxBodySize int64
+
+	// Breaker, if set, stops requests while the API keeps failing, so that
+	// they fail fast with ErrCircuitOpen instead of waiting for a timeout.
+	Breaker *Breaker
+
+	// Hooks observe every request, in order. See SlogHooks, TraceHooks and
+	// MetricsHooks.
+	Hooks []Hooks
 
 	// Services for different endpoints of the Dark Sky API
 	ForecastS *ForecastService
 }
 
-type httpClient interface {
-	Do(*http.Request) (*http.Response, error)
-}
-
 // func NewClient(key string) *Client {
 
 // 	c := &Client{
@@ -47,7 +62,24 @@ func (c *Client) do(req *http.Request) (*http.Response, error) {
 		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	}
 	req.SetBasicAuth(c.Key, "")
-	return httpClient.Do(req)
+	if c.Breaker == nil {
+		return httpClient.Do(req)
+	}
+	gen, err := c.Breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+	res, err := httpClient.Do(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		c.Breaker.Abandon(gen)
+	case err != nil:
+		c.Breaker.Done(gen, true)
+	default:
+		// Errors in the request, like a bad key, are not the API's fault.
+		c.Breaker.Done(gen, res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests)
+	}
+	return res, err
 }
 
 func (c *Client) url(path string) string {
@@ -57,35 +89,107 @@ func (c *Client) url(path string) string {
 	return fmt.Sprintf("%s%s", c.BaseURL, path)
 }
 
-func (c *Client) latlong(lat, long float64) string {
-	return fmt.Sprintf("%f,%f", lat, long)
+// latlong validates the coordinates and formats them for the API path.
+func (c *Client) latlong(lat, long float64) (string, error) {
+	loc := Location{Lat: lat, Long: long}
+	if err := loc.Validate(); err != nil {
+		return "", err
+	}
+	precision := c.Precision
+	if precision <= 0 {
+		precision = DefaultPrecision
+	}
+	return loc.Format(precision), nil
 }
 
+// Forecast returns the current conditions and the forecast for the next week
+// at the given coordinates. Invalid coordinates are rejected with a
+// *ValidationError without making a request.
 func (c *Client) Forecast(lat, long float64) (*Forecast, error) {
-	endpoint := c.url("/forecast")
-	endpoint = endpoint + "/" + c.Key + "/" + c.latlong(lat, long)
-	req, err := http.NewRequest(http.MethodGet, endpoint, strings.NewReader(endpoint))
+	return c.ForecastContext(context.Background(), lat, long)
+}
 
-	res, err := c.do(req)
+// ForecastContext is like Forecast but makes the request with ctx.
+func (c *Client) ForecastContext(ctx context.Context, lat, long float64) (*Forecast, error) {
+	location, err := c.latlong(lat, long)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
-	body, err := ioutil.ReadAll(res.Body)
+	return c.forecast(ctx, location)
+}
+
+// TimeMachine returns the observed or forecast conditions at the given
+// coordinates on the day of t, as seen at t. Invalid coordinates are
+// rejected with a *ValidationError without making a request.
+func (c *Client) TimeMachine(lat, long float64, t time.Time) (*Forecast, error) {
+	location, err := c.latlong(lat, long)
 	if err != nil {
 		return nil, err
 	}
-	if res.StatusCode >= 400 {
-		return nil, ErrBadRequest
+	return c.forecast(context.Background(), location+","+strconv.FormatInt(t.Unix(), 10))
+}
+
+// ForecastPlace resolves a place name such as "Jacumba, CA" with the
+// embedded gazetteer of the geo package and returns the forecast there. It
+// returns geo.ErrNotFound if the place is unknown.
+func (c *Client) ForecastPlace(ctx context.Context, place string) (*Forecast, error) {
+	p, err := geo.Default().Lookup(place)
+	if err != nil {
+		return nil, err
 	}
-	var forecast Forecast
-	err = json.Unmarshal(body, &forecast)
+	location, err := c.latlong(p.Lat, p.Long)
 	if err != nil {
 		return nil, err
 	}
+	return c.forecast(ctx, location)
+}
+
+func (c *Client) forecast(ctx context.Context, location string) (*Forecast, error) {
+	var forecast Forecast
+	if err := c.fetch(ctx, location, "", &forecast); err != nil {
+		return nil, err
+	}
 	return &forecast, nil
 }
 
+// fetch requests the forecast at location, leaving out the blocks in
+// exclude, and decodes the response into v.
+func (c *Client) fetch(ctx context.Context, location, exclude string, v interface{}) error {
+	endpoint := c.url("/forecast")
+	endpoint = endpoint + "/" + c.Key + "/" + location
+	if exclude != "" {
+		endpoint += "?exclude=" + exclude
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	info := c.requestInfo(req, location)
+	ctx = c.hookStart(ctx, info)
+	req = req.WithContext(ctx)
+
+	res, err := c.do(req)
+	if err != nil {
+		c.hookError(ctx, info, nil, err)
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		c.hookError(ctx, info, res, ErrBadRequest)
+		return ErrBadRequest
+	}
+	maxBodySize := c.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxBodySize
+	}
+	if err := decodeJSON(res, maxBodySize, v); err != nil {
+		c.hookError(ctx, info, res, err)
+		return err
+	}
+	c.hookResponse(ctx, info, res)
+	return nil
+}
+
 // func parseError(data []byte) error {
 // 	var se Error
 // 	err := json.Unmarshal(data, &se)
@@ -1,17 +1,19 @@
 package darksky_test
 
 import (
-	"encoding/json"
+	"context"
 	"flag"
-	"fmt"
-	"io/ioutil"
+	"math"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/darkskytest"
+	"github.com/sophiaehlen/darksky-client/geo"
+	"github.com/sophiaehlen/darksky-client/recorder"
 )
 
 var (
@@ -25,13 +27,7 @@ func init() {
 }
 
 func TestClient_Local(t *testing.T) {
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader((200))
-		fmt.Fprintf(w, sample())
-	})
-	server := httptest.NewServer(mux)
+	server := darkskytest.NewServer(1)
 	defer server.Close()
 	c := darksky.Client{
 		Key:     "gibberish-key",
@@ -43,83 +39,74 @@ func TestClient_Local(t *testing.T) {
 	}
 }
 
-func darkskyClient(t *testing.T) (*darksky.Client, func()) {
-	teardown := make([]func(), 0)
+func TestClient_TimeMachine(t *testing.T) {
+	var gotPath string
+	fake := darkskytest.NewHandler(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
 	c := darksky.Client{
-		Key: apiKey,
-	}
-	if apiKey == "" {
-		count := 0
-		handler := func(w http.ResponseWriter, r *http.Request) {
-			resp := readResponse(t, count)
-			w.WriteHeader(resp.StatusCode)
-			w.Write(resp.Body)
-			count++
-		}
-		server := httptest.NewServer(http.HandlerFunc(handler))
-		c.BaseURL = server.URL
-		teardown = append(teardown, server.Close)
-	}
-	if update {
-		rc := &recorderClient{}
-		c.HttpClient = rc
-		teardown = append(teardown, func() {
-			t.Logf("len(responses) = %d", len(rc.responses))
-			for i, res := range rc.responses {
-				recordResponse(t, res, i)
-			}
-		})
-	}
-	return &c, func() {
-		for _, fn := range teardown {
-			fn()
-		}
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
 	}
-}
-
-func responsePath(t *testing.T, count int) string {
-	return filepath.Join("testdata", filepath.FromSlash(fmt.Sprintf("%s.%d.json", t.Name(), count)))
-}
-
-func readResponse(t *testing.T, count int) response {
-	var resp response
-	path := responsePath(t, count)
-	f, err := os.Open(path)
+	fc, err := c.TimeMachine(1.234, -1.234, time.Unix(1576521551, 0))
 	if err != nil {
-		t.Fatalf("failed to open the response file: %s. err = %v", path, err)
+		t.Fatalf("err = %v; want nil", err)
 	}
-	defer f.Close()
-	jsonBytes, err := ioutil.ReadAll(f)
-	if err != nil {
-		t.Fatalf("failed to read the response file: %s. err = %v", path, err)
+	want := "/forecast/gibberish-key/1.234000,-1.234000,1576521551"
+	if gotPath != want {
+		t.Errorf("path = %q; want %q", gotPath, want)
 	}
-	err = json.Unmarshal(jsonBytes, &resp)
-	if err != nil {
-		t.Fatalf("failed to json unmarshal the response file: %s. err = %v", path, err)
+	if fc.Currently.Time != 1576521551 {
+		t.Errorf("Currently.Time = %d; want %d", fc.Currently.Time, 1576521551)
 	}
-	return resp
 }
 
-func recordResponse(t *testing.T, resp response, count int) {
-	path := responsePath(t, count)
-	err := os.MkdirAll(filepath.Dir(path), 0700)
-	if err != nil {
-		t.Fatalf("failed to create the response dir: %s. err = %v", filepath.Dir(path), err)
+// darkskyClient returns a client for the test. Without an API key it
+// replays the test's cassette, which must exist; with one it talks to the
+// real API, recording a new cassette if the update flag is set.
+func darkskyClient(t *testing.T) (*darksky.Client, func()) {
+	mode := recorder.ModeReplay
+	switch {
+	case apiKey != "" && update:
+		mode = recorder.ModeRecord
+	case apiKey != "":
+		mode = recorder.ModePassthrough
 	}
-	f, err := os.Create(path)
+	rec, err := recorder.New(cassettePath(t), mode)
 	if err != nil {
-		t.Fatalf("failed to create the response file: %s. err = %v", path, err)
+		t.Fatalf("failed to load the cassette: %s. err = %v", cassettePath(t), err)
 	}
-	defer f.Close()
-	jsonBytes, err := json.MarshalIndent(resp, "", "  ")
-	if err != nil {
-		t.Fatalf("failed to marshal JSON for response file: %s. err = %v", path, err)
+	c := darksky.Client{
+		Key:        apiKey,
+		HttpClient: rec,
 	}
-	_, err = f.Write(jsonBytes)
-	if err != nil {
-		t.Fatalf("Failed to write json bytes for response file: %s. err = %v", path, err)
+	return &c, func() {
+		if mode == recorder.ModeRecord {
+			t.Logf("len(interactions) = %d", len(rec.Cassette.Interactions))
+		}
+		if len(rec.Cassette.Interactions) == 0 {
+			return
+		}
+		err := rec.Save()
+		if err != nil {
+			t.Fatalf("failed to save the cassette: %s. err = %v", cassettePath(t), err)
+		}
 	}
+}
 
+// offlineDoer fails the test if a request is made with it.
+func offlineDoer(t *testing.T) darksky.Doer {
+	return darksky.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected request to %s", req.URL.Path)
+		return nil, nil
+	})
+}
+
+func cassettePath(t *testing.T) string {
+	return filepath.Join("testdata", filepath.FromSlash(t.Name()+".json"))
 }
 
 func TestClient_Forecast(t *testing.T) {
@@ -165,11 +152,21 @@ func TestClient_Forecast(t *testing.T) {
 			}
 		}
 	}
+	isValidationErr := func() checkFn {
+		return func(t *testing.T, fc *darksky.Forecast, err error) {
+			if _, ok := err.(*darksky.ValidationError); !ok {
+				t.Errorf("err = %v; want *ValidationError", err)
+			}
+		}
+	}
 
 	tests := map[string]struct {
 		lat    float64
 		long   float64
 		checks []checkFn
+		// offline cases must fail before a request is made, so they
+		// have no cassette.
+		offline bool
 	}{
 		"valid forecast with correct coords": {
 			lat:  32.589720,
@@ -181,19 +178,29 @@ func TestClient_Forecast(t *testing.T) {
 				hasCurrTemperature()),
 		},
 		"invalid latitude": {
-			lat:    132.0,
-			long:   -116.466988,
-			checks: check(hasErr()),
+			lat:     132.0,
+			long:    -116.466988,
+			checks:  check(hasErr(), isValidationErr()),
+			offline: true,
 		},
 		"invalid longitude": {
-			lat:    32.0,
-			long:   -181.0,
-			checks: check(hasErr()),
+			lat:     32.0,
+			long:    -181.0,
+			checks:  check(hasErr(), isValidationErr()),
+			offline: true,
 		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
+			if tc.offline {
+				c := darksky.Client{Key: "key", HttpClient: offlineDoer(t)}
+				fc, err := c.Forecast(tc.lat, tc.long)
+				for _, check := range tc.checks {
+					check(t, fc, err)
+				}
+				return
+			}
 			c, teardown := darkskyClient(t)
 			defer teardown()
 			fc, err := c.Forecast(tc.lat, tc.long)
@@ -204,152 +211,109 @@ func TestClient_Forecast(t *testing.T) {
 	}
 }
 
-func sample() string {
-	return `{
-    "latitude": 32.58972,
-    "longitude": -116.466988,
-    "timezone": "America/Los_Angeles",
-    "currently": {
-        "time": 1576521551,
-        "summary": "Clear",
-        "icon": "clear-day",
-        "nearestStormDistance": 296,
-        "nearestStormBearing": 39,
-        "precipIntensity": 0,
-        "precipProbability": 0,
-        "temperature": 50.53,
-        "apparentTemperature": 50.79,
-        "dewPoint": 18.03,
-        "humidity": 0.27,
-        "pressure": 1024.3,
-        "windSpeed": 21.65,
-        "windGust": 31.5,
-        "windBearing": 60,
-        "cloudCover": 0.01,
-        "uvIndex": 3,
-        "visibility": 10,
-        "ozone": 288.6
-    },
-    "minutely": {
-        "summary": "Clear for the hour.",
-        "icon": "clear-day",
-        "data": [
-            {
-                "time": 1576525140,
-                "precipIntensity": 0,
-                "precipProbability": 0
-            }
-        ]
-    },
-    "hourly": {
-        "summary": "Windy starting this evening, continuing until tomorrow morning.",
-        "icon": "wind",
-        "data": [
-            {
-                "time": 1576692000,
-                "summary": "Clear",
-                "icon": "clear-day",
-                "precipIntensity": 0,
-                "precipProbability": 0,
-                "temperature": 49.03,
-                "apparentTemperature": 46.85,
-                "dewPoint": -1.14,
-                "humidity": 0.12,
-                "pressure": 1019.4,
-                "windSpeed": 5.4,
-                "windGust": 5.4,
-                "windBearing": 71,
-                "cloudCover": 0.2,
-                "uvIndex": 2,
-                "visibility": 10,
-                "ozone": 314.6
-            }
-        ]
-    },
-    "daily": {
-        "summary": "Possible light rain next Monday.",
-        "icon": "rain",
-        "data": [
-            {
-                "time": 1576569600,
-                "summary": "Windy in the morning.",
-                "icon": "wind",
-                "sunriseTime": 1576593780,
-                "sunsetTime": 1576629840,
-                "moonPhase": 0.71,
-                "precipIntensity": 0.0001,
-                "precipIntensityMax": 0.0002,
-                "precipIntensityMaxTime": 1576626720,
-                "precipProbability": 0.02,
-                "precipType": "rain",
-                "temperatureHigh": 48.48,
-                "temperatureHighTime": 1576616280,
-                "temperatureLow": 34.28,
-                "temperatureLowTime": 1576679460,
-                "apparentTemperatureHigh": 39.98,
-                "apparentTemperatureHighTime": 1576616460,
-                "apparentTemperatureLow": 27.14,
-                "apparentTemperatureLowTime": 1576679040,
-                "dewPoint": -3.45,
-                "humidity": 0.15,
-                "pressure": 1025.2,
-                "windSpeed": 23.52,
-                "windGust": 46.02,
-                "windGustTime": 1576597320,
-                "windBearing": 62,
-                "cloudCover": 0.37,
-                "uvIndex": 3,
-                "uvIndexTime": 1576610700,
-                "visibility": 10,
-                "ozone": 277,
-                "temperatureMin": 37.66,
-                "temperatureMinTime": 1576592400,
-                "temperatureMax": 48.48,
-                "temperatureMaxTime": 1576616280,
-                "apparentTemperatureMin": 26.69,
-                "apparentTemperatureMinTime": 1576592760,
-                "apparentTemperatureMax": 39.98,
-                "apparentTemperatureMaxTime": 1576616460
-            }
-        ]
-    },
-    "alerts": [
-        {
-            "title": "Wind Advisory",
-            "regions": [
-                "Orange County Inland",
-                "Riverside County Mountains",
-                "San Bernardino County Mountains",
-                "San Bernardino and Riverside County Valleys-The Inland Empire",
-                "San Diego County Inland Valleys",
-                "San Diego County Mountains",
-                "San Gorgonio Pass Near Banning",
-                "Santa Ana Mountains and Foothills"
-            ],
-            "severity": "advisory",
-            "time": 1576497600,
-            "expires": 1576648800,
-            "description": "...WIND ADVISORY REMAINS IN EFFECT UNTIL 10 PM PST TUESDAY... * WHAT...Areas of northeast winds 20 to 30 mph with gusts to 50 mph are expected. * WHERE...Mountains, valleys, and inland Orange County. The stronger gusts are expected in the Inland Empire below the Cajon and Banning Passes. near the coastal slopes of the Santa Ana Mountains, and near the ridge tops in the San Diego County mountains. * WHEN...Until 10 PM PST Tuesday. * IMPACTS...Gusty winds could blow around unsecured objects. Tree limbs could be blown down and a few power outages may result. * ADDITIONAL DETAILS...Winds will gradually increase today, peaking tonight into Tuesday morning.\n",
-            "uri": "https://alerts.weather.gov/cap/wwacapget.php?x=CA125D22439AE0.WindAdvisory.125D22614AE0CA.SGXNPWSGX.36b83adb84b37e195e6eff16da67f679"
-        }
-    ],
-    "flags": {
-        "sources": [
-            "nwspa",
-            "cmc",
-            "gfs",
-            "hrrr",
-            "icon",
-            "isd",
-            "madis",
-            "nam",
-            "sref",
-            "darksky",
-            "nearest-precip"
-        ],
-        "nearest-station": 0.307,
-        "units": "us"
-    },
-    "offset": -8
-	}`
+func TestClient_ValidationError(t *testing.T) {
+	server := darkskytest.NewServer(1)
+	defer server.Close()
+	c := darksky.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
+	}
+
+	tests := map[string]struct {
+		lat       float64
+		long      float64
+		wantField string
+	}{
+		"latitude too large": {lat: 132.0, long: -116.466988, wantField: "Latitude"},
+		"latitude too small": {lat: -90.5, long: -116.466988, wantField: "Latitude"},
+		"latitude is NaN":    {lat: math.NaN(), long: 0, wantField: "Latitude"},
+		"longitude is Inf":   {lat: 0, long: math.Inf(1), wantField: "Longitude"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := c.Forecast(tc.lat, tc.long)
+			verr, ok := err.(*darksky.ValidationError)
+			if !ok {
+				t.Fatalf("err = %v; want *ValidationError", err)
+			}
+			if verr.Field != tc.wantField {
+				t.Errorf("Field = %q; want %q", verr.Field, tc.wantField)
+			}
+			_, err = c.TimeMachine(tc.lat, tc.long, time.Unix(1576521551, 0))
+			if _, ok := err.(*darksky.ValidationError); !ok {
+				t.Errorf("TimeMachine err = %v; want *ValidationError", err)
+			}
+		})
+	}
+	if n := server.Handler().Requests(); n != 0 {
+		t.Errorf("server received %d requests; want 0", n)
+	}
+}
+
+func TestClient_Precision(t *testing.T) {
+	var gotPath string
+	fake := darkskytest.NewHandler(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		precision int
+		want      string
+	}{
+		"default":  {precision: 0, want: "/forecast/key/32.589720,-116.466988"},
+		"rounded":  {precision: 2, want: "/forecast/key/32.59,-116.47"},
+		"extended": {precision: 8, want: "/forecast/key/32.58972000,-116.46698800"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := darksky.Client{Key: "key", BaseURL: server.URL, Precision: tc.precision}
+			_, err := c.Forecast(32.58972, -116.466988)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if gotPath != tc.want {
+				t.Errorf("path = %q; want %q", gotPath, tc.want)
+			}
+		})
+	}
+}
+
+func TestClient_ForecastPlace(t *testing.T) {
+	var gotPath string
+	fake := darkskytest.NewHandler(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	c := darksky.Client{Key: "key", BaseURL: server.URL}
+
+	fc, err := c.ForecastPlace(context.Background(), "Jacumba, CA")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if want := "/forecast/key/32.618700,-116.187800"; gotPath != want {
+		t.Errorf("path = %q; want %q", gotPath, want)
+	}
+	if fc.Latitude != 32.6187 {
+		t.Errorf("Latitude = %f; want 32.6187", fc.Latitude)
+	}
+
+	_, err = c.ForecastPlace(context.Background(), "Xyzzyville")
+	if err != geo.ErrNotFound {
+		t.Errorf("err = %v; want %v", err, geo.ErrNotFound)
+	}
+	if n := fake.Requests(); n != 1 {
+		t.Errorf("server received %d requests; want 1", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.ForecastPlace(ctx, "Jacumba, CA")
+	if err == nil {
+		t.Errorf("err = nil; want the context's error")
+	}
 }
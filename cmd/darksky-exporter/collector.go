@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the API latency
+// histogram buckets.
+var latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// gauge describes a metric read from the current conditions of a forecast.
+type gauge struct {
+	name  string
+	help  string
+	value func(fc *darksky.Forecast) float64
+}
+
+var gauges = []gauge{
+	{"darksky_temperature_fahrenheit", "Current air temperature.", func(fc *darksky.Forecast) float64 { return fc.Currently.Temperature }},
+	{"darksky_apparent_temperature_fahrenheit", "Current apparent (feels like) temperature.", func(fc *darksky.Forecast) float64 { return fc.Currently.ApparentTemperature }},
+	{"darksky_humidity_ratio", "Current relative humidity between 0 and 1.", func(fc *darksky.Forecast) float64 { return fc.Currently.Humidity }},
+	{"darksky_pressure_hectopascals", "Current sea-level air pressure.", func(fc *darksky.Forecast) float64 { return fc.Currently.Pressure }},
+	{"darksky_wind_speed_mph", "Current wind speed.", func(fc *darksky.Forecast) float64 { return fc.Currently.WindSpeed }},
+	{"darksky_wind_gust_mph", "Current wind gust speed.", func(fc *darksky.Forecast) float64 { return fc.Currently.WindGust }},
+	{"darksky_wind_bearing_degrees", "Current direction the wind is coming from, clockwise from true north.", func(fc *darksky.Forecast) float64 { return float64(fc.Currently.WindBearing) }},
+	{"darksky_cloud_cover_ratio", "Current fraction of the sky occluded by clouds between 0 and 1.", func(fc *darksky.Forecast) float64 { return fc.Currently.CloudCover }},
+	{"darksky_uv_index", "Current UV index.", func(fc *darksky.Forecast) float64 { return float64(fc.Currently.UvIndex) }},
+	{"darksky_precip_probability_ratio", "Current probability of precipitation between 0 and 1.", func(fc *darksky.Forecast) float64 { return fc.Currently.PrecipProbability }},
+}
+
+// collector fetches forecasts for its locations and serves the most recent
+// values, along with metrics about the API calls themselves, as an
+// http.Handler.
+type collector struct {
+	client    darksky.Provider
+	locations []location
+	now       func() time.Time
+
+	mu          sync.Mutex
+	forecasts   map[string]*darksky.Forecast
+	lastSuccess map[string]time.Time
+	calls       map[string]int
+	errors      map[string]int
+	buckets     []int // cumulative counts per latencyBuckets entry
+	count       int
+	sum         float64
+}
+
+func newCollector(c darksky.Provider, locations []location) *collector {
+	return &collector{
+		client:      c,
+		locations:   locations,
+		now:         time.Now,
+		forecasts:   make(map[string]*darksky.Forecast),
+		lastSuccess: make(map[string]time.Time),
+		calls:       make(map[string]int),
+		errors:      make(map[string]int),
+		buckets:     make([]int, len(latencyBuckets)),
+	}
+}
+
+// collect fetches a forecast for every location, one at a time. A failed
+// fetch keeps the previous values for that location around.
+func (col *collector) collect() {
+	for _, l := range col.locations {
+		start := col.now()
+		fc, err := col.client.Forecast(l.Lat, l.Long)
+		elapsed := col.now().Sub(start).Seconds()
+
+		col.mu.Lock()
+		col.calls[l.Name]++
+		col.count++
+		col.sum += elapsed
+		for i, le := range latencyBuckets {
+			if elapsed <= le {
+				col.buckets[i]++
+			}
+		}
+		if err != nil {
+			col.errors[l.Name]++
+		} else {
+			col.forecasts[l.Name] = fc
+			col.lastSuccess[l.Name] = col.now()
+		}
+		col.mu.Unlock()
+	}
+}
+
+func (col *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	col.writeTo(w)
+}
+
+// writeTo writes every metric in the Prometheus text exposition format.
+func (col *collector) writeTo(w io.Writer) {
+	col.mu.Lock()
+	defer col.mu.Unlock()
+
+	names := make([]string, 0, len(col.locations))
+	for _, l := range col.locations {
+		names = append(names, l.Name)
+	}
+	sort.Strings(names)
+
+	for _, g := range gauges {
+		writeHeader(w, g.name, g.help, "gauge")
+		for _, name := range names {
+			fc, ok := col.forecasts[name]
+			if !ok {
+				continue
+			}
+			writeSample(w, g.name, name, g.value(fc))
+		}
+	}
+
+	writeHeader(w, "darksky_last_success_timestamp_seconds", "Unix time of the last successful forecast fetch.", "gauge")
+	for _, name := range names {
+		if ts, ok := col.lastSuccess[name]; ok {
+			writeSample(w, "darksky_last_success_timestamp_seconds", name, float64(ts.Unix()))
+		}
+	}
+	writeHeader(w, "darksky_api_requests_total", "Forecast requests made to the Dark Sky API.", "counter")
+	for _, name := range names {
+		writeSample(w, "darksky_api_requests_total", name, float64(col.calls[name]))
+	}
+	writeHeader(w, "darksky_api_errors_total", "Forecast requests to the Dark Sky API that returned an error.", "counter")
+	for _, name := range names {
+		writeSample(w, "darksky_api_errors_total", name, float64(col.errors[name]))
+	}
+
+	const hist = "darksky_api_request_duration_seconds"
+	writeHeader(w, hist, "Latency of forecast requests to the Dark Sky API.", "histogram")
+	for i, le := range latencyBuckets {
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", hist, formatFloat(le), col.buckets[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", hist, col.count)
+	fmt.Fprintf(w, "%s_sum %s\n", hist, formatFloat(col.sum))
+	fmt.Fprintf(w, "%s_count %d\n", hist, col.count)
+}
+
+func writeHeader(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+func writeSample(w io.Writer, name, loc string, value float64) {
+	fmt.Fprintf(w, "%s{location=\"%s\"} %s\n", name, escapeLabel(loc), formatFloat(value))
+}
+
+// escapeLabel escapes a label value as required by the exposition format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+func TestParseLocation(t *testing.T) {
+	tests := map[string]struct {
+		value   string
+		want    location
+		wantErr bool
+	}{
+		"valid":             {value: "jacumba=32.58972,-116.466988", want: location{"jacumba", 32.58972, -116.466988}},
+		"spaces in coords":  {value: "boston=42.3601, -71.0589", want: location{"boston", 42.3601, -71.0589}},
+		"missing name":      {value: "=1,2", wantErr: true},
+		"missing longitude": {value: "x=1", wantErr: true},
+		"bad latitude":      {value: "x=north,2", wantErr: true},
+		"out of range":      {value: "x=91,2", wantErr: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseLocation(tc.value)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("err = nil; want non-nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if got != tc.want {
+				t.Errorf("parseLocation() = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCollector(t *testing.T) {
+	body, err := ioutil.ReadFile("../../SouthernTerminus.json")
+	if err != nil {
+		t.Fatalf("failed to read the sample forecast. err = %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/forecast/key/32.589720,-116.466988", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := &darksky.Client{Key: "key", BaseURL: server.URL}
+	col := newCollector(c, []location{
+		{Name: "jacumba", Lat: 32.589720, Long: -116.466988},
+		{Name: "nowhere", Lat: 1, Long: 1},
+	})
+	col.collect()
+
+	rec := httptest.NewRecorder()
+	col.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	got := rec.Body.String()
+
+	for _, want := range []string{
+		"# TYPE darksky_temperature_fahrenheit gauge\n",
+		"darksky_temperature_fahrenheit{location=\"jacumba\"} 45.24\n",
+		"darksky_wind_bearing_degrees{location=\"jacumba\"} 51\n",
+		"darksky_uv_index{location=\"jacumba\"} 3\n",
+		"darksky_api_requests_total{location=\"jacumba\"} 1\n",
+		"darksky_api_requests_total{location=\"nowhere\"} 1\n",
+		"darksky_api_errors_total{location=\"jacumba\"} 0\n",
+		"darksky_api_errors_total{location=\"nowhere\"} 1\n",
+		"darksky_api_request_duration_seconds_bucket{le=\"+Inf\"} 2\n",
+		"darksky_api_request_duration_seconds_count 2\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics missing %q; got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "darksky_temperature_fahrenheit{location=\"nowhere\"}") {
+		t.Errorf("metrics contain a temperature for a location that was never fetched")
+	}
+}
@@ -0,0 +1,117 @@
+// Command darksky-exporter periodically fetches the current conditions for a
+// set of locations from the Dark Sky API and serves them on /metrics in the
+// Prometheus text exposition format.
+//
+// Usage:
+//
+//	darksky-exporter -key $DARKSKY_KEY \
+//		-location jacumba=32.589720,-116.466988 \
+//		-location boston=42.3601,-71.0589
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// location is a named set of coordinates to collect conditions for.
+type location struct {
+	Name string
+	Lat  float64
+	Long float64
+}
+
+// locationsFlag implements flag.Value so that -location can be repeated.
+type locationsFlag []location
+
+func (lf *locationsFlag) String() string {
+	parts := make([]string, 0, len(*lf))
+	for _, l := range *lf {
+		parts = append(parts, fmt.Sprintf("%s=%f,%f", l.Name, l.Lat, l.Long))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (lf *locationsFlag) Set(value string) error {
+	l, err := parseLocation(value)
+	if err != nil {
+		return err
+	}
+	*lf = append(*lf, l)
+	return nil
+}
+
+// parseLocation parses a location in the name=lat,long format.
+func parseLocation(value string) (location, error) {
+	eq := strings.Index(value, "=")
+	if eq <= 0 {
+		return location{}, fmt.Errorf("location %q is not in the name=lat,long format", value)
+	}
+	coords := strings.Split(value[eq+1:], ",")
+	if len(coords) != 2 {
+		return location{}, fmt.Errorf("location %q is not in the name=lat,long format", value)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
+	if err != nil {
+		return location{}, fmt.Errorf("location %q has an invalid latitude: %v", value, err)
+	}
+	long, err := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
+	if err != nil {
+		return location{}, fmt.Errorf("location %q has an invalid longitude: %v", value, err)
+	}
+	if err := (darksky.Location{Lat: lat, Long: long}).Validate(); err != nil {
+		return location{}, fmt.Errorf("location %q is invalid: %v", value, err)
+	}
+	return location{Name: value[:eq], Lat: lat, Long: long}, nil
+}
+
+func main() {
+	var (
+		key       string
+		baseURL   string
+		addr      string
+		interval  time.Duration
+		locations locationsFlag
+	)
+	flag.StringVar(&key, "key", os.Getenv("DARKSKY_KEY"), "Your secret key for the Dark Sky API. Defaults to $DARKSKY_KEY.")
+	flag.StringVar(&baseURL, "base-url", darksky.DefaultBaseURL, "The base URL of the Dark Sky API.")
+	flag.StringVar(&addr, "addr", ":9798", "The address to serve /metrics on.")
+	flag.DurationVar(&interval, "interval", 10*time.Minute, "How often to fetch forecasts for every location.")
+	flag.Var(&locations, "location", "A location to collect conditions for, in the name=lat,long format. May be repeated.")
+	flag.Parse()
+
+	if key == "" {
+		log.Fatal("a Dark Sky API key is required; set -key or $DARKSKY_KEY")
+	}
+	if len(locations) == 0 {
+		log.Fatal("at least one -location is required")
+	}
+	if interval <= 0 {
+		log.Fatal("-interval must be positive")
+	}
+
+	c := &darksky.Client{
+		Key:     key,
+		BaseURL: baseURL,
+	}
+	col := newCollector(c, locations)
+	go func() {
+		for {
+			col.collect()
+			time.Sleep(interval)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", col)
+	log.Printf("serving metrics for %d location(s) on %s/metrics", len(locations), addr)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
@@ -0,0 +1,75 @@
+// Command darksky-proxy is a caching proxy in front of the Dark Sky API. It
+// serves the same /forecast/{key}/{lat},{long}[,{time}] paths, but with
+// tokens of its own in place of the API key, so that several services can
+// share one key and one cache by setting their Client.BaseURL to the proxy.
+//
+// Usage:
+//
+//	darksky-proxy -key $DARKSKY_KEY -token billing=500 -token maps=2000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// tokensFlag implements flag.Value so that -token can be repeated.
+type tokensFlag map[string]int
+
+func (tf tokensFlag) String() string {
+	parts := make([]string, 0, len(tf))
+	for token, quota := range tf {
+		parts = append(parts, fmt.Sprintf("%s=%d", token, quota))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (tf tokensFlag) Set(value string) error {
+	eq := strings.Index(value, "=")
+	if eq <= 0 {
+		return fmt.Errorf("token %q is not in the token=quota format", value)
+	}
+	quota, err := strconv.Atoi(value[eq+1:])
+	if err != nil || quota < 0 {
+		return fmt.Errorf("token %q has an invalid quota", value)
+	}
+	tf[value[:eq]] = quota
+	return nil
+}
+
+func main() {
+	var (
+		key     string
+		baseURL string
+		addr    string
+		ttl     time.Duration
+		timeout time.Duration
+		tokens  = make(tokensFlag)
+	)
+	flag.StringVar(&key, "key", os.Getenv("DARKSKY_KEY"), "Your secret key for the Dark Sky API. Defaults to $DARKSKY_KEY.")
+	flag.StringVar(&baseURL, "base-url", darksky.DefaultBaseURL, "The base URL of the Dark Sky API.")
+	flag.StringVar(&addr, "addr", ":8080", "The address to serve on.")
+	flag.DurationVar(&ttl, "ttl", 10*time.Minute, "How long responses are cached for.")
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "The timeout for requests to the Dark Sky API.")
+	flag.Var(tokens, "token", "A client token and the number of calls it may make per UTC day, in the token=quota format. May be repeated.")
+	flag.Parse()
+
+	if key == "" {
+		log.Fatal("a Dark Sky API key is required; set -key or $DARKSKY_KEY")
+	}
+	if len(tokens) == 0 {
+		log.Fatal("at least one -token is required")
+	}
+
+	upstream := &http.Client{Timeout: timeout}
+	log.Printf("proxying %s for %d token(s) on %s", baseURL, len(tokens), addr)
+	log.Fatal(http.ListenAndServe(addr, newProxy(upstream, baseURL, key, ttl, tokens)))
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// maxCacheEntries caps the number of responses the proxy caches.
+const maxCacheEntries = 10000
+
+// proxy serves /forecast/{token}/{lat},{long}[,{time}] like the Dark Sky API
+// does, authenticating callers with their own tokens and answering from a
+// shared cache where possible. Requests are forwarded with the proxy's key
+// and their query string, and responses are passed back byte for byte.
+type proxy struct {
+	doer    darksky.Doer
+	baseURL string
+	key     string
+	ttl     time.Duration
+	quotas  map[string]int // calls allowed per token per UTC day
+	now     func() time.Time
+
+	mu       sync.Mutex
+	cache    map[string]cacheEntry
+	swept    time.Time
+	inflight map[string]*call
+	usage    map[string]int
+	day      string
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// call is an upstream request that other requests for the same location
+// wait on instead of making their own.
+type call struct {
+	done   chan struct{}
+	body   []byte
+	status int
+}
+
+func newProxy(doer darksky.Doer, baseURL, key string, ttl time.Duration, quotas map[string]int) *proxy {
+	return &proxy{
+		doer:     doer,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		key:      key,
+		ttl:      ttl,
+		quotas:   quotas,
+		now:      time.Now,
+		cache:    make(map[string]cacheEntry),
+		inflight: make(map[string]*call),
+		usage:    make(map[string]int),
+	}
+}
+
+// apiError is the body the Dark Sky API returns alongside error statuses.
+type apiError struct {
+	Code  int    `json:"code"`
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiError{Code: status, Error: msg})
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET requests are supported.")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "forecast" {
+		writeError(w, http.StatusNotFound, "Not found.")
+		return
+	}
+	token, location := parts[1], parts[2]
+	if _, ok := p.quotas[token]; !ok {
+		writeError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	key, err := parseLocation(location)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "The given location is invalid.")
+		return
+	}
+	if !p.allow(token) {
+		writeError(w, http.StatusTooManyRequests, "daily usage limit exceeded")
+		return
+	}
+
+	query := r.URL.Query().Encode()
+	if query != "" {
+		key += "?" + query
+	}
+	body, status := p.fetch(key, func() ([]byte, int) {
+		return p.forward(location, query)
+	})
+	if status != http.StatusOK {
+		writeError(w, status, string(body))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(body)
+}
+
+// allow reports whether token is within its quota, counting the request
+// against it if so. Usage resets at midnight UTC.
+func (p *proxy) allow(token string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	day := p.now().UTC().Format("2006-01-02")
+	if day != p.day {
+		p.day = day
+		p.usage = make(map[string]int)
+	}
+	if p.usage[token] >= p.quotas[token] {
+		return false
+	}
+	p.usage[token]++
+	return true
+}
+
+// fetch returns the cached response for key, waits for an in-flight request
+// for key, or calls fn and caches its result. On failure the status is not
+// http.StatusOK and the body holds the error message.
+func (p *proxy) fetch(key string, fn func() ([]byte, int)) ([]byte, int) {
+	p.mu.Lock()
+	if e, ok := p.cache[key]; ok && p.now().Before(e.expires) {
+		p.mu.Unlock()
+		return e.body, http.StatusOK
+	}
+	if c, ok := p.inflight[key]; ok {
+		p.mu.Unlock()
+		<-c.done
+		return c.body, c.status
+	}
+	c := &call{done: make(chan struct{})}
+	p.inflight[key] = c
+	p.mu.Unlock()
+
+	c.body, c.status = fn()
+
+	p.mu.Lock()
+	delete(p.inflight, key)
+	if c.status == http.StatusOK {
+		p.store(key, c.body)
+	}
+	p.mu.Unlock()
+	close(c.done)
+	return c.body, c.status
+}
+
+// store caches body under key. Expired entries are swept at most once per
+// TTL, and an arbitrary entry is evicted if the cache is still full. p.mu
+// must be held.
+func (p *proxy) store(key string, body []byte) {
+	now := p.now()
+	if now.Sub(p.swept) >= p.ttl || len(p.cache) >= maxCacheEntries {
+		for k, e := range p.cache {
+			if !now.Before(e.expires) {
+				delete(p.cache, k)
+			}
+		}
+		p.swept = now
+	}
+	for k := range p.cache {
+		if len(p.cache) < maxCacheEntries {
+			break
+		}
+		delete(p.cache, k)
+	}
+	p.cache[key] = cacheEntry{body: body, expires: now.Add(p.ttl)}
+}
+
+// forward requests the location from the Dark Sky API with the proxy's key.
+// Errors caused by the request are passed back as they are; others become
+// http.StatusBadGateway, or http.StatusServiceUnavailable if the proxy's
+// key is rate limited. On failure the body holds the error message. The
+// request is not tied to the caller's, since others may be waiting on it.
+func (p *proxy) forward(location, query string) ([]byte, int) {
+	u := p.baseURL + "/forecast/" + p.key + "/" + location
+	if query != "" {
+		u += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return []byte(err.Error()), http.StatusInternalServerError
+	}
+	res, err := p.doer.Do(req)
+	if err != nil {
+		return []byte("The upstream request failed."), http.StatusBadGateway
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, darksky.DefaultMaxBodySize+1))
+	if err != nil {
+		return []byte("The upstream response could not be read."), http.StatusBadGateway
+	}
+	if len(body) > darksky.DefaultMaxBodySize {
+		return []byte(darksky.ErrResponseTooLarge.Error()), http.StatusBadGateway
+	}
+	switch {
+	case res.StatusCode == http.StatusOK:
+		return body, http.StatusOK
+	case res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusNotFound:
+		var e apiError
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			return []byte(e.Error), res.StatusCode
+		}
+		return []byte(http.StatusText(res.StatusCode)), res.StatusCode
+	case res.StatusCode == http.StatusTooManyRequests:
+		return []byte("The upstream usage limit was reached."), http.StatusServiceUnavailable
+	}
+	return []byte(fmt.Sprintf("The upstream API returned %d.", res.StatusCode)), http.StatusBadGateway
+}
+
+// parseLocation validates the {lat},{long}[,{time}] path segment and
+// returns the normalised form of it used for caching. The time is kept as
+// given, since times without a zone are local to the location and are
+// left for the API to interpret.
+func parseLocation(s string) (key string, err error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 2 && len(fields) != 3 {
+		return "", fmt.Errorf("location %q is not lat,long[,time]", s)
+	}
+	lat, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", err
+	}
+	long, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return "", err
+	}
+	loc := darksky.Location{Lat: lat, Long: long}
+	if err = loc.Validate(); err != nil {
+		return "", err
+	}
+	key = loc.String()
+	if len(fields) == 3 {
+		if !validTime(fields[2]) {
+			return "", fmt.Errorf("time %q is invalid", fields[2])
+		}
+		key += "," + fields[2]
+	}
+	return key, nil
+}
+
+// validTime reports whether s is a Time Machine time, which is either UNIX
+// time or [YYYY]-[MM]-[DD]T[HH]:[MM]:[SS] with an optional time zone.
+func validTime(s string) bool {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return true
+	}
+	if _, err := time.Parse(time.RFC3339, s); err == nil {
+		return true
+	}
+	_, err := time.Parse("2006-01-02T15:04:05", s)
+	return err == nil
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// fakeUpstream is a Dark Sky API that answers every forecast request with
+// the same body, or with status if it is set.
+type fakeUpstream struct {
+	calls   int32
+	status  int
+	release chan struct{} // if non-nil, calls block until it is closed
+
+	mu   sync.Mutex
+	urls []string
+}
+
+const upstreamBody = `{"latitude":32.58972,"longitude":-116.466988,"timezone":"America/Los_Angeles","offset":-8,` +
+	`"currently":{"time":1576521551,"temperature":50.5},"flags":{"units":"us","sources":["cmc"]}}`
+
+func (fu *fakeUpstream) Do(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&fu.calls, 1)
+	fu.mu.Lock()
+	fu.urls = append(fu.urls, req.URL.String())
+	fu.mu.Unlock()
+	if fu.release != nil {
+		<-fu.release
+	}
+	rec := httptest.NewRecorder()
+	if fu.status != 0 {
+		rec.WriteHeader(fu.status)
+		rec.WriteString(`{"code":` + strconv.Itoa(fu.status) + `,"error":"upstream says no"}`)
+		return rec.Result(), nil
+	}
+	rec.WriteString(upstreamBody)
+	return rec.Result(), nil
+}
+
+func (fu *fakeUpstream) lastURL() string {
+	fu.mu.Lock()
+	defer fu.mu.Unlock()
+	return fu.urls[len(fu.urls)-1]
+}
+
+func newTestProxy(up *fakeUpstream, quotas map[string]int) *proxy {
+	return newProxy(up, "https://api.example.com/", "the-real-key", time.Minute, quotas)
+}
+
+func TestProxy_Client(t *testing.T) {
+	up := &fakeUpstream{}
+	server := httptest.NewServer(newTestProxy(up, map[string]int{"token": 10}))
+	defer server.Close()
+
+	type checkFn func(*testing.T, *darksky.Forecast, error)
+	hasNoErr := func(t *testing.T, fc *darksky.Forecast, err error) {
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+	}
+	hasErr := func(t *testing.T, fc *darksky.Forecast, err error) {
+		if err == nil {
+			t.Fatalf("err = nil; want non-nil")
+		}
+	}
+	hasTemperature := func(t *testing.T, fc *darksky.Forecast, err error) {
+		if fc.Currently.Temperature != 50.5 {
+			t.Errorf("Currently.Temperature = %f; want 50.5", fc.Currently.Temperature)
+		}
+	}
+	hasTime := func(t *testing.T, fc *darksky.Forecast, err error) {
+		if fc.Currently.Time != 1576521551 {
+			t.Errorf("Currently.Time = %d; want 1576521551", fc.Currently.Time)
+		}
+	}
+
+	tests := map[string]struct {
+		token  string
+		fetch  func(c *darksky.Client) (*darksky.Forecast, error)
+		checks []checkFn
+	}{
+		"forecast": {
+			token:  "token",
+			fetch:  func(c *darksky.Client) (*darksky.Forecast, error) { return c.Forecast(32.58972, -116.466988) },
+			checks: []checkFn{hasNoErr, hasTemperature},
+		},
+		"time machine": {
+			token: "token",
+			fetch: func(c *darksky.Client) (*darksky.Forecast, error) {
+				return c.TimeMachine(32.58972, -116.466988, time.Unix(1576521551, 0))
+			},
+			checks: []checkFn{hasNoErr, hasTemperature, hasTime},
+		},
+		"invalid location": {
+			token:  "token",
+			fetch:  func(c *darksky.Client) (*darksky.Forecast, error) { return c.Forecast(132, -116.466988) },
+			checks: []checkFn{hasErr},
+		},
+		"unknown token": {
+			token:  "the-real-key",
+			fetch:  func(c *darksky.Client) (*darksky.Forecast, error) { return c.Forecast(32.58972, -116.466988) },
+			checks: []checkFn{hasErr},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &darksky.Client{Key: tc.token, BaseURL: server.URL}
+			fc, err := tc.fetch(c)
+			for _, check := range tc.checks {
+				check(t, fc, err)
+			}
+		})
+	}
+}
+
+func TestProxy_Cache(t *testing.T) {
+	up := &fakeUpstream{}
+	p := newTestProxy(up, map[string]int{"token": 10})
+	now := time.Date(2019, 12, 17, 12, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+	get("/forecast/token/32.58972,-116.466988")
+	get("/forecast/token/32.589720,-116.4669880")
+	if up.calls != 1 {
+		t.Errorf("upstream calls = %d; want 1 for equivalent coordinates", up.calls)
+	}
+	now = now.Add(2 * time.Minute)
+	get("/forecast/token/32.58972,-116.466988")
+	if up.calls != 2 {
+		t.Errorf("upstream calls = %d; want 2 after the cache expired", up.calls)
+	}
+	get("/forecast/token/32.58972,-116.466988,1576521551")
+	if up.calls != 3 {
+		t.Errorf("upstream calls = %d; want 3 for a Time Machine request", up.calls)
+	}
+	get("/forecast/token/32.58972,-116.466988?units=si")
+	get("/forecast/token/32.58972,-116.466988?units=si")
+	if up.calls != 4 {
+		t.Errorf("upstream calls = %d; want 4 for a request with other units", up.calls)
+	}
+
+	// Expired entries are dropped rather than kept forever.
+	now = now.Add(2 * time.Minute)
+	get("/forecast/token/1,2")
+	if len(p.cache) != 1 {
+		t.Errorf("len(cache) = %d; want 1 after the others expired", len(p.cache))
+	}
+}
+
+func TestProxy_Passthrough(t *testing.T) {
+	up := &fakeUpstream{}
+	p := newTestProxy(up, map[string]int{"token": 10})
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forecast/token/32.58972,-116.466988,2019-12-16T10:39:11?units=si&exclude=minutely&lang=de", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Body.String(); got != upstreamBody {
+		t.Errorf("body = %s; want the upstream body unchanged", got)
+	}
+	// Times without a zone are local to the location, so they are left to
+	// the API.
+	want := "https://api.example.com/forecast/the-real-key/32.58972,-116.466988,2019-12-16T10:39:11?exclude=minutely&lang=de&units=si"
+	if got := up.lastURL(); got != want {
+		t.Errorf("upstream URL = %s; want %s", got, want)
+	}
+}
+
+func TestProxy_UpstreamErrors(t *testing.T) {
+	tests := map[int]int{
+		http.StatusBadRequest:          http.StatusBadRequest,
+		http.StatusUnauthorized:        http.StatusBadGateway,
+		http.StatusForbidden:           http.StatusBadGateway,
+		http.StatusTooManyRequests:     http.StatusServiceUnavailable,
+		http.StatusInternalServerError: http.StatusBadGateway,
+	}
+	for upstream, want := range tests {
+		t.Run(strconv.Itoa(upstream), func(t *testing.T) {
+			up := &fakeUpstream{status: upstream}
+			p := newTestProxy(up, map[string]int{"token": 10})
+			rec := httptest.NewRecorder()
+			p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forecast/token/1,2", nil))
+			if rec.Code != want {
+				t.Errorf("status = %d; want %d", rec.Code, want)
+			}
+			if upstream == http.StatusBadRequest && !strings.Contains(rec.Body.String(), "upstream says no") {
+				t.Errorf("body = %s; want the upstream error", rec.Body.String())
+			}
+			// Failures are not cached.
+			p.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/forecast/token/1,2", nil))
+			if up.calls != 2 {
+				t.Errorf("upstream calls = %d; want 2", up.calls)
+			}
+		})
+	}
+}
+
+func TestProxy_Coalescing(t *testing.T) {
+	up := &fakeUpstream{release: make(chan struct{})}
+	p := newTestProxy(up, map[string]int{"token": 100})
+
+	var wg sync.WaitGroup
+	codes := make([]int, 10)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forecast/token/1,2", nil))
+			codes[i] = rec.Code
+		}(i)
+	}
+	// Give the requests time to pile up behind the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(up.release)
+	wg.Wait()
+
+	if up.calls != 1 {
+		t.Errorf("upstream calls = %d; want 1", up.calls)
+	}
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("status[%d] = %d; want %d", i, code, http.StatusOK)
+		}
+	}
+}
+
+func TestProxy_Quota(t *testing.T) {
+	p := newTestProxy(&fakeUpstream{}, map[string]int{"small": 2, "large": 100})
+	now := time.Date(2019, 12, 17, 23, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	get := func(token string) int {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/forecast/"+token+"/1,2", nil))
+		return rec.Code
+	}
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if got := get("small"); got != want {
+			t.Errorf("request %d: status = %d; want %d", i, got, want)
+		}
+	}
+	if got := get("large"); got != http.StatusOK {
+		t.Errorf("other token: status = %d; want %d", got, http.StatusOK)
+	}
+	now = now.Add(2 * time.Hour)
+	if got := get("small"); got != http.StatusOK {
+		t.Errorf("next day: status = %d; want %d", got, http.StatusOK)
+	}
+}
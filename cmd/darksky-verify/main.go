@@ -0,0 +1,140 @@
+// Command darksky-verify measures how accurate Dark Sky forecasts are for a
+// set of sites. Run it with -site to archive the current forecasts, for
+// example hourly from cron, and without to score the archived forecasts
+// against Time Machine observations.
+//
+// Usage:
+//
+//	darksky-verify -dir forecasts -site 32.5897,-116.4670 -site 47.6062,-122.3321
+//	darksky-verify -dir forecasts -lead 6,12,24,48
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/history"
+)
+
+// sitesFlag implements flag.Value so that -site can be repeated.
+type sitesFlag []darksky.Location
+
+func (sf *sitesFlag) String() string {
+	parts := make([]string, len(*sf))
+	for i, loc := range *sf {
+		parts[i] = loc.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+func (sf *sitesFlag) Set(value string) error {
+	fields := strings.Split(value, ",")
+	if len(fields) != 2 {
+		return fmt.Errorf("site %q is not in the lat,long format", value)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+	if err != nil {
+		return fmt.Errorf("site %q has an invalid latitude", value)
+	}
+	long, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	if err != nil {
+		return fmt.Errorf("site %q has an invalid longitude", value)
+	}
+	loc := darksky.Location{Lat: lat, Long: long}
+	if err := loc.Validate(); err != nil {
+		return err
+	}
+	*sf = append(*sf, loc)
+	return nil
+}
+
+func main() {
+	var (
+		key     string
+		baseURL string
+		dir     string
+		lead    string
+		asJSON  bool
+		timeout time.Duration
+		sites   sitesFlag
+	)
+	flag.StringVar(&key, "key", os.Getenv("DARKSKY_KEY"), "Your secret key for the Dark Sky API. Defaults to $DARKSKY_KEY.")
+	flag.StringVar(&baseURL, "base-url", darksky.DefaultBaseURL, "The base URL of the Dark Sky API.")
+	flag.StringVar(&dir, "dir", "forecasts", "The directory forecasts are archived in.")
+	flag.StringVar(&lead, "lead", "", "The upper bounds of the lead time groups in hours, comma separated. Defaults to 6,12,24,48,168.")
+	flag.BoolVar(&asJSON, "json", false, "Write the report as JSON.")
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "The timeout for requests to the Dark Sky API.")
+	flag.Var(&sites, "site", "A site to archive the forecast of, in the lat,long format. May be repeated.")
+	flag.Parse()
+
+	if key == "" {
+		log.Fatal("a Dark Sky API key is required; set -key or $DARKSKY_KEY")
+	}
+	store, err := history.Open(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := &darksky.Client{
+		Key:        key,
+		BaseURL:    baseURL,
+		HttpClient: &http.Client{Timeout: timeout},
+	}
+
+	if len(sites) > 0 {
+		p := store.Archive(c, func(err error) { log.Printf("saving forecast: %v", err) })
+		failed := false
+		for _, site := range sites {
+			if _, err := p.Forecast(site.Lat, site.Long); err != nil {
+				log.Printf("forecast for %s: %v", site, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	v := &history.Verifier{
+		Observer: c,
+		OnError:  func(err error) { log.Printf("observations: %v", err) },
+	}
+	if lead != "" {
+		for _, s := range strings.Split(lead, ",") {
+			hours, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || hours <= 0 {
+				log.Fatalf("lead time %q is not a positive number of hours", s)
+			}
+			if n := len(v.LeadTimes); n > 0 && hours <= v.LeadTimes[n-1] {
+				log.Fatalf("lead times %q are not in increasing order", lead)
+			}
+			v.LeadTimes = append(v.LeadTimes, hours)
+		}
+	}
+	entries, err := store.Entries()
+	if err != nil {
+		log.Fatal(err)
+	}
+	report, err := v.Verify(entries)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,454 @@
+package darksky
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Strategy is how a Composite uses its sources.
+type Strategy int
+
+const (
+	// Failover asks the sources in order and returns the first plausible
+	// forecast.
+	Failover Strategy = iota
+
+	// Hedged asks the first source and, every HedgeDelay it goes without
+	// a plausible forecast, the next one too, returning the first
+	// plausible forecast from any of them.
+	Hedged
+
+	// Consensus asks every source at once and averages the numeric fields
+	// of the plausible forecasts.
+	Consensus
+)
+
+// Defaults for how long a Composite skips a failing source.
+const (
+	DefaultSourceFailureThreshold = 3
+	DefaultSourceCooldown         = time.Minute
+)
+
+// ProviderFunc adapts a function to Provider.
+type ProviderFunc func(ctx context.Context, lat, long float64) (*Forecast, error)
+
+// Forecast calls f with a background context.
+func (f ProviderFunc) Forecast(lat, long float64) (*Forecast, error) {
+	return f(context.Background(), lat, long)
+}
+
+// ForecastContext calls f.
+func (f ProviderFunc) ForecastContext(ctx context.Context, lat, long float64) (*Forecast, error) {
+	return f(ctx, lat, long)
+}
+
+// Composite is a Provider backed by several others. A source that fails
+// FailureThreshold times in a row is skipped for Cooldown, after which a
+// single request probes whether it has recovered. Unlike a Breaker, which
+// trips on the failure rate of a window of requests, this only looks at
+// consecutive failures, so a source that works now and then stays in use.
+type Composite struct {
+	Sources    []Provider
+	Strategy   Strategy
+	HedgeDelay time.Duration // zero asks every source at once
+
+	// Check rejects implausible forecasts, which count as failures.
+	// CheckForecast is used if it is nil.
+	Check func(*Forecast) error
+
+	FailureThreshold int           // DefaultSourceFailureThreshold if zero
+	Cooldown         time.Duration // DefaultSourceCooldown if zero
+
+	mu     sync.Mutex
+	health []SourceHealth
+}
+
+var _ Provider = (*Composite)(nil)
+
+// SourceHealth is what a Composite knows about one of its sources.
+type SourceHealth struct {
+	Successes           int
+	Failures            int
+	ConsecutiveFailures int
+	LastError           error
+	LastLatency         time.Duration
+	OpenUntil           time.Time // while in the future the source is skipped
+	probing             bool
+}
+
+// CheckForecast returns ErrImplausibleForecast if the current conditions of
+// f are missing or outside physical limits.
+func CheckForecast(f *Forecast) error {
+	c := f.Currently
+	switch {
+	case c.Time == 0,
+		c.Temperature < -130 || c.Temperature > 140 || math.IsNaN(c.Temperature),
+		c.Humidity < 0 || c.Humidity > 1,
+		c.CloudCover < 0 || c.CloudCover > 1,
+		c.PrecipProbability < 0 || c.PrecipProbability > 1,
+		c.PrecipIntensity < 0,
+		c.WindSpeed < 0,
+		c.Pressure != 0 && (c.Pressure < 850 || c.Pressure > 1090):
+		return ErrImplausibleForecast
+	}
+	return nil
+}
+
+// Forecast returns a forecast from the sources according to the strategy.
+func (c *Composite) Forecast(lat, long float64) (*Forecast, error) {
+	return c.ForecastContext(context.Background(), lat, long)
+}
+
+// ForecastContext is like Forecast but makes the requests with ctx. If no
+// source gives a plausible forecast it returns the last error, or
+// ErrNoHealthySources if every source is being skipped.
+func (c *Composite) ForecastContext(ctx context.Context, lat, long float64) (*Forecast, error) {
+	switch c.Strategy {
+	case Hedged:
+		return c.hedged(ctx, lat, long)
+	case Consensus:
+		f, _, err := c.ConsensusContext(ctx, lat, long)
+		return f, err
+	}
+	return c.failover(ctx, lat, long)
+}
+
+// Health returns the health of each source, in the order of Sources.
+func (c *Composite) Health() []SourceHealth {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	return append([]SourceHealth(nil), c.health...)
+}
+
+func (c *Composite) failover(ctx context.Context, lat, long float64) (*Forecast, error) {
+	if err := (Location{Lat: lat, Long: long}).Validate(); err != nil {
+		return nil, err
+	}
+	err := ErrNoHealthySources
+	for i := range c.Sources {
+		if !c.allow(i) {
+			continue
+		}
+		var f *Forecast
+		if f, err = c.fetch(ctx, i, lat, long); err == nil {
+			return f, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, err
+}
+
+func (c *Composite) hedged(ctx context.Context, lat, long float64) (*Forecast, error) {
+	if err := (Location{Lat: lat, Long: long}).Validate(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		f   *Forecast
+		err error
+	}
+	results := make(chan result, len(c.Sources))
+	pending := 0
+	next := 0
+	// start asks the next healthy source, reporting whether there was one.
+	start := func() bool {
+		for ; next < len(c.Sources); next++ {
+			if !c.allow(next) {
+				continue
+			}
+			i := next
+			next++
+			pending++
+			go func() {
+				f, err := c.fetch(ctx, i, lat, long)
+				results <- result{f, err}
+			}()
+			return true
+		}
+		return false
+	}
+
+	if !start() {
+		return nil, ErrNoHealthySources
+	}
+	if c.HedgeDelay == 0 {
+		for start() {
+		}
+	}
+	var timer <-chan time.Time
+	if c.HedgeDelay > 0 {
+		timer = time.After(c.HedgeDelay)
+	}
+	var err error
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.f, nil
+			}
+			err = r.err
+			// Don't wait out the delay once a request has failed.
+			start()
+		case <-timer:
+			if start() {
+				timer = time.After(c.HedgeDelay)
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return nil, err
+}
+
+// Spread is how far apart the sources of a consensus forecast were: the
+// difference between the highest and lowest value of each numeric field,
+// keyed by its JSON name.
+type Spread struct {
+	Sources   int // the number of sources averaged
+	Currently map[string]float64
+	Hourly    []map[string]float64 // parallel to Forecast.Hourly.Data
+	Daily     []map[string]float64 // parallel to Forecast.Daily.Data
+}
+
+// ConsensusContext asks every healthy source at once and averages the
+// measurements of the plausible forecasts, whatever the strategy. Hourly
+// and daily points are matched by time; the points, text, icons and times
+// such as sunriseTime are those of the first source that answered in
+// Sources order. Wind bearings and moon phases are averaged around the
+// circle.
+func (c *Composite) ConsensusContext(ctx context.Context, lat, long float64) (*Forecast, *Spread, error) {
+	if err := (Location{Lat: lat, Long: long}).Validate(); err != nil {
+		return nil, nil, err
+	}
+	forecasts := make([]*Forecast, len(c.Sources))
+	errs := make([]error, len(c.Sources))
+	var wg sync.WaitGroup
+	asked := false
+	for i := range c.Sources {
+		if !c.allow(i) {
+			continue
+		}
+		asked = true
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			forecasts[i], errs[i] = c.fetch(ctx, i, lat, long)
+		}(i)
+	}
+	wg.Wait()
+	if !asked {
+		return nil, nil, ErrNoHealthySources
+	}
+
+	var ok []*Forecast
+	var err error
+	for i, f := range forecasts {
+		if errs[i] != nil {
+			err = errs[i]
+		} else if f != nil {
+			ok = append(ok, f)
+		}
+	}
+	if len(ok) == 0 {
+		return nil, nil, err
+	}
+	f, s := average(ok)
+	return f, s, nil
+}
+
+// allow reports whether source i may be asked, letting a single probe
+// through once its cool-down has passed.
+func (c *Composite) allow(i int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+	h := &c.health[i]
+	if h.OpenUntil.IsZero() {
+		return true
+	}
+	if h.probing || time.Now().Before(h.OpenUntil) {
+		return false
+	}
+	h.probing = true
+	return true
+}
+
+// fetch asks source i and records the outcome.
+func (c *Composite) fetch(ctx context.Context, i int, lat, long float64) (*Forecast, error) {
+	start := time.Now()
+	f, err := c.Sources[i].ForecastContext(ctx, lat, long)
+	if err == nil {
+		check := c.Check
+		if check == nil {
+			check = CheckForecast
+		}
+		err = check(f)
+	}
+	// A request cancelled because another source won says nothing about
+	// this one.
+	if err != nil && ctx.Err() != nil {
+		c.mu.Lock()
+		c.health[i].probing = false
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := &c.health[i]
+	h.LastLatency = time.Since(start)
+	h.probing = false
+	if err == nil {
+		h.Successes++
+		h.ConsecutiveFailures = 0
+		h.LastError = nil
+		h.OpenUntil = time.Time{}
+		return f, nil
+	}
+	h.Failures++
+	h.ConsecutiveFailures++
+	h.LastError = err
+	threshold := c.FailureThreshold
+	if threshold == 0 {
+		threshold = DefaultSourceFailureThreshold
+	}
+	if h.ConsecutiveFailures >= threshold {
+		cooldown := c.Cooldown
+		if cooldown == 0 {
+			cooldown = DefaultSourceCooldown
+		}
+		h.OpenUntil = time.Now().Add(cooldown)
+	}
+	return nil, err
+}
+
+func (c *Composite) init() {
+	if len(c.health) != len(c.Sources) {
+		c.health = make([]SourceHealth, len(c.Sources))
+	}
+}
+
+// average merges forecasts into the first one and measures their spread.
+func average(fs []*Forecast) (*Forecast, *Spread) {
+	base := *fs[0]
+	base.Hourly.Data = append([]HourlyDataPoint(nil), base.Hourly.Data...)
+	base.Daily.Data = append([]DailyDataPoint(nil), base.Daily.Data...)
+	s := &Spread{Sources: len(fs)}
+
+	points := make([]reflect.Value, len(fs))
+	for i, f := range fs {
+		points[i] = reflect.ValueOf(&f.Currently).Elem()
+	}
+	s.Currently = merge(reflect.ValueOf(&base.Currently).Elem(), points)
+
+	s.Hourly = make([]map[string]float64, len(base.Hourly.Data))
+	for j := range base.Hourly.Data {
+		p := &base.Hourly.Data[j]
+		points := []reflect.Value{reflect.ValueOf(*p)}
+		for _, f := range fs[1:] {
+			for _, q := range f.Hourly.Data {
+				if q.Time == p.Time {
+					points = append(points, reflect.ValueOf(q))
+					break
+				}
+			}
+		}
+		s.Hourly[j] = merge(reflect.ValueOf(p).Elem(), points)
+	}
+
+	s.Daily = make([]map[string]float64, len(base.Daily.Data))
+	for j := range base.Daily.Data {
+		p := &base.Daily.Data[j]
+		points := []reflect.Value{reflect.ValueOf(*p)}
+		for _, f := range fs[1:] {
+			for _, q := range f.Daily.Data {
+				if q.Time == p.Time {
+					points = append(points, reflect.ValueOf(q))
+					break
+				}
+			}
+		}
+		s.Daily[j] = merge(reflect.ValueOf(p).Elem(), points)
+	}
+	return &base, s
+}
+
+// averaged are the numeric fields merge averages: those every adapter
+// fills in. Others, including every *Time field, are left as the first
+// source has them, since sources that don't provide them leave them zero.
+var averaged = map[string]bool{
+	"temperature": true, "apparentTemperature": true, "dewPoint": true,
+	"humidity": true, "pressure": true, "windSpeed": true, "windGust": true,
+	"windBearing": true, "cloudCover": true, "uvIndex": true,
+	"visibility": true, "precipIntensity": true, "precipProbability": true,
+	"temperatureHigh": true, "temperatureLow": true, "temperatureMin": true,
+	"temperatureMax": true, "moonPhase": true,
+}
+
+// periods are the numeric fields that wrap around.
+var periods = map[string]float64{"windBearing": 360, "moonPhase": 1}
+
+// merge sets each averaged field of dst to the mean of the field in points
+// and returns the spread of each.
+func merge(dst reflect.Value, points []reflect.Value) map[string]float64 {
+	spread := make(map[string]float64)
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("json")
+		kind := t.Field(i).Type.Kind()
+		if !averaged[name] || (kind != reflect.Float64 && kind != reflect.Int) {
+			continue
+		}
+		values := make([]float64, len(points))
+		for j, p := range points {
+			if kind == reflect.Int {
+				values[j] = float64(p.Field(i).Int())
+			} else {
+				values[j] = p.Field(i).Float()
+			}
+		}
+		var mean float64
+		if period, ok := periods[name]; ok {
+			mean, spread[name] = circularMean(values, period)
+		} else {
+			lo, hi := values[0], values[0]
+			for _, v := range values {
+				mean += v / float64(len(values))
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+			spread[name] = hi - lo
+		}
+		if kind == reflect.Int {
+			dst.Field(i).SetInt(int64(math.Round(mean)))
+		} else {
+			dst.Field(i).SetFloat(mean)
+		}
+	}
+	return spread
+}
+
+// circularMean returns the mean of values that wrap around at period, in
+// [0, period), and the widest distance of any of them from it.
+func circularMean(values []float64, period float64) (mean, spread float64) {
+	var x, y float64
+	for _, v := range values {
+		a := v / period * 2 * math.Pi
+		x += math.Cos(a)
+		y += math.Sin(a)
+	}
+	mean = math.Mod(math.Atan2(y, x)/(2*math.Pi)*period+period, period)
+	for _, v := range values {
+		d := math.Mod(math.Abs(v-mean), period)
+		d = math.Min(d, period-d)
+		spread = math.Max(spread, 2*d)
+	}
+	return mean, spread
+}
@@ -0,0 +1,185 @@
+package darksky_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// source returns a provider that counts its calls and answers with fc and
+// err.
+func source(calls *int32, fc *darksky.Forecast, err error) darksky.Provider {
+	return darksky.ProviderFunc(func(ctx context.Context, lat, long float64) (*darksky.Forecast, error) {
+		atomic.AddInt32(calls, 1)
+		if err != nil {
+			return nil, err
+		}
+		dup := *fc
+		return &dup, nil
+	})
+}
+
+func TestComposite_Failover(t *testing.T) {
+	good := loadForecast(t, "SouthernTerminus.json")
+	bad := *good
+	bad.Currently.Temperature = 500
+	down := errors.New("down")
+
+	var calls [3]int32
+	c := &darksky.Composite{
+		Sources: []darksky.Provider{
+			source(&calls[0], nil, down),
+			source(&calls[1], &bad, nil),
+			source(&calls[2], good, nil),
+		},
+		FailureThreshold: 2,
+		Cooldown:         20 * time.Millisecond,
+	}
+	for i := 0; i < 3; i++ {
+		fc, err := c.Forecast(stLat, stLong)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if fc.Currently.Temperature != good.Currently.Temperature {
+			t.Errorf("Temperature = %v; want %v", fc.Currently.Temperature, good.Currently.Temperature)
+		}
+	}
+	// The failing sources are skipped after their second failure.
+	if calls != [3]int32{2, 2, 3} {
+		t.Errorf("calls = %v; want [2 2 3]", calls)
+	}
+	health := c.Health()
+	if health[0].LastError != down || health[0].OpenUntil.IsZero() {
+		t.Errorf("health[0] = %+v; want open after %v", health[0], down)
+	}
+	if health[1].LastError != darksky.ErrImplausibleForecast {
+		t.Errorf("health[1].LastError = %v; want %v", health[1].LastError, darksky.ErrImplausibleForecast)
+	}
+	if health[2].Successes != 3 || health[2].Failures != 0 {
+		t.Errorf("health[2] = %+v; want 3 successes", health[2])
+	}
+
+	// After the cool-down each failing source gets a single probe.
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.Forecast(stLat, stLong); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if calls != [3]int32{3, 3, 4} {
+		t.Errorf("calls = %v; want [3 3 4]", calls)
+	}
+
+	if _, err := c.Forecast(91, stLong); !isValidationError(err) {
+		t.Errorf("err = %v; want a *ValidationError", err)
+	}
+}
+
+func TestComposite_Failover_allFail(t *testing.T) {
+	down := errors.New("down")
+	var calls int32
+	c := &darksky.Composite{Sources: []darksky.Provider{source(&calls, nil, down)}, FailureThreshold: 1}
+	if _, err := c.Forecast(stLat, stLong); err != down {
+		t.Errorf("err = %v; want %v", err, down)
+	}
+	if _, err := c.Forecast(stLat, stLong); err != darksky.ErrNoHealthySources {
+		t.Errorf("err = %v; want %v", err, darksky.ErrNoHealthySources)
+	}
+}
+
+func TestComposite_Hedged(t *testing.T) {
+	good := loadForecast(t, "SouthernTerminus.json")
+	slow := darksky.ProviderFunc(func(ctx context.Context, lat, long float64) (*darksky.Forecast, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	var calls int32
+	c := &darksky.Composite{
+		Sources:    []darksky.Provider{slow, source(&calls, good, nil)},
+		Strategy:   darksky.Hedged,
+		HedgeDelay: 10 * time.Millisecond,
+	}
+	start := time.Now()
+	fc, err := c.Forecast(stLat, stLong)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if fc.Currently.Time != good.Currently.Time || calls != 1 {
+		t.Errorf("Forecast() = %v from %d calls; want the second source's", fc.Currently.Time, calls)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond || d > time.Second {
+		t.Errorf("Forecast() took %v; want about the hedge delay", d)
+	}
+	// The slow source was cancelled, which is not its fault.
+	time.Sleep(10 * time.Millisecond)
+	if h := c.Health()[0]; h.Failures != 0 {
+		t.Errorf("health[0] = %+v; want no failures", h)
+	}
+}
+
+func TestComposite_Consensus(t *testing.T) {
+	a := loadForecast(t, "SouthernTerminus.json")
+	b := loadForecast(t, "SouthernTerminus.json")
+	b.Currently.Temperature = a.Currently.Temperature + 4
+	a.Currently.WindBearing, b.Currently.WindBearing = 350, 20
+	b.Hourly.Data = b.Hourly.Data[1:]
+	b.Hourly.Data[0].Temperature += 2
+	// Fields a source doesn't provide are left zero by the adapters.
+	b.Currently.Ozone, b.Daily.Data[0].TemperatureHighTime, b.Daily.Data[0].SunriseTime = 0, 0, 0
+	hour1 := a.Hourly.Data[1].Temperature
+	down := errors.New("down")
+
+	var calls [3]int32
+	c := &darksky.Composite{
+		Sources: []darksky.Provider{
+			source(&calls[0], a, nil),
+			source(&calls[1], nil, down),
+			source(&calls[2], b, nil),
+		},
+		Strategy: darksky.Consensus,
+	}
+	fc, spread, err := c.ConsensusContext(context.Background(), stLat, stLong)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if spread.Sources != 2 {
+		t.Errorf("Sources = %d; want 2", spread.Sources)
+	}
+	if want := a.Currently.Temperature + 2; math.Abs(fc.Currently.Temperature-want) > 1e-9 || spread.Currently["temperature"] != 4 {
+		t.Errorf("Temperature = %v with spread %v; want %v with spread 4", fc.Currently.Temperature, spread.Currently["temperature"], want)
+	}
+	if fc.Currently.WindBearing != 5 || math.Abs(spread.Currently["windBearing"]-30) > 1e-9 {
+		t.Errorf("WindBearing = %d with spread %v; want 5 with spread 30", fc.Currently.WindBearing, spread.Currently["windBearing"])
+	}
+	if fc.Currently.Summary != a.Currently.Summary || fc.Currently.Time != a.Currently.Time || fc.Currently.Ozone != a.Currently.Ozone {
+		t.Errorf("Currently = %+v; want the text, time and ozone of the first source", fc.Currently)
+	}
+	if d := fc.Daily.Data[0]; d.TemperatureHighTime != a.Daily.Data[0].TemperatureHighTime || d.SunriseTime != a.Daily.Data[0].SunriseTime {
+		t.Errorf("Daily.Data[0] times = %d, %d; want those of the first source", d.TemperatureHighTime, d.SunriseTime)
+	}
+
+	if len(fc.Hourly.Data) != len(a.Hourly.Data) || len(spread.Hourly) != len(a.Hourly.Data) {
+		t.Fatalf("len(Hourly.Data) = %d; want %d", len(fc.Hourly.Data), len(a.Hourly.Data))
+	}
+	// Only the first source has the first hour.
+	if fc.Hourly.Data[0].Temperature != a.Hourly.Data[0].Temperature || spread.Hourly[0]["temperature"] != 0 {
+		t.Errorf("Hourly.Data[0].Temperature = %v; want %v", fc.Hourly.Data[0].Temperature, a.Hourly.Data[0].Temperature)
+	}
+	if want := hour1 + 1; math.Abs(fc.Hourly.Data[1].Temperature-want) > 1e-9 {
+		t.Errorf("Hourly.Data[1].Temperature = %v; want %v", fc.Hourly.Data[1].Temperature, want)
+	}
+	if a.Hourly.Data[1].Temperature != hour1 {
+		t.Errorf("the first source's forecast was modified")
+	}
+	if c.Health()[1].Failures != 1 {
+		t.Errorf("health[1] = %+v; want 1 failure", c.Health()[1])
+	}
+
+	c.Sources = []darksky.Provider{source(&calls[1], nil, down)}
+	if _, err := c.Forecast(stLat, stLong); err != down {
+		t.Errorf("err = %v; want %v", err, down)
+	}
+}
@@ -0,0 +1,136 @@
+package darkskytest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// Metrics is an in-memory darksky.Metrics for tests.
+type Metrics struct {
+	mu        sync.Mutex
+	calls     map[string]int
+	errors    map[[2]string]int
+	latencies map[string][]time.Duration
+}
+
+var _ darksky.Metrics = (*Metrics)(nil)
+
+// IncCalls implements darksky.Metrics.
+func (m *Metrics) IncCalls(endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.calls == nil {
+		m.calls = make(map[string]int)
+	}
+	m.calls[endpoint]++
+}
+
+// ObserveLatency implements darksky.Metrics.
+func (m *Metrics) ObserveLatency(endpoint string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.latencies == nil {
+		m.latencies = make(map[string][]time.Duration)
+	}
+	m.latencies[endpoint] = append(m.latencies[endpoint], d)
+}
+
+// IncErrors implements darksky.Metrics.
+func (m *Metrics) IncErrors(endpoint, class string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.errors == nil {
+		m.errors = make(map[[2]string]int)
+	}
+	m.errors[[2]string{endpoint, class}]++
+}
+
+// Calls returns the number of requests to the endpoint.
+func (m *Metrics) Calls(endpoint string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[endpoint]
+}
+
+// Errors returns the number of failed requests to the endpoint in the class.
+func (m *Metrics) Errors(endpoint, class string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.errors[[2]string{endpoint, class}]
+}
+
+// Histogram returns how many requests to the endpoint took at most each of
+// the bounds, which must be in increasing order, as a cumulative histogram
+// does. The last count is of all requests.
+func (m *Metrics) Histogram(endpoint string, bounds ...time.Duration) []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make([]int, len(bounds)+1)
+	for _, d := range m.latencies[endpoint] {
+		i := sort.Search(len(bounds), func(i int) bool { return d <= bounds[i] })
+		for ; i <= len(bounds); i++ {
+			counts[i]++
+		}
+	}
+	return counts
+}
+
+// Tracer is an in-memory darksky.Tracer for tests.
+type Tracer struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+var _ darksky.Tracer = (*Tracer)(nil)
+
+// Start implements darksky.Tracer.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, darksky.Span) {
+	s := &Span{Name: name, Attributes: make(map[string]interface{})}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return ctx, s
+}
+
+// Spans returns the spans started so far.
+func (t *Tracer) Spans() []*Span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Span(nil), t.spans...)
+}
+
+// Span is a span recorded by a Tracer. Read its fields only once it has
+// ended.
+type Span struct {
+	Name       string
+	Attributes map[string]interface{}
+	Errors     []error
+	Ended      bool
+
+	mu sync.Mutex
+}
+
+// SetAttribute implements darksky.Span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// RecordError implements darksky.Span.
+func (s *Span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Errors = append(s.Errors, err)
+}
+
+// End implements darksky.Span.
+func (s *Span) End() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Ended = true
+}
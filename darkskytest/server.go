@@ -0,0 +1,262 @@
+// Package darkskytest provides a fake Dark Sky API server for tests and local
+// development.
+//
+// The server synthesises plausible forecasts for any coordinates without
+// network access. Forecasts are deterministic: the same seed, coordinates and
+// time always produce the same response. Faults such as latency, rate
+// limiting and server errors can be injected to test how callers cope with
+// them.
+//
+//	srv := darkskytest.NewServer(1)
+//	defer srv.Close()
+//	c := darksky.Client{Key: "any", BaseURL: srv.URL}
+package darkskytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake Dark Sky API listening on a local address.
+type Server struct {
+	// URL is the base URL of the server, for use as Client.BaseURL.
+	URL string
+
+	srv     *httptest.Server
+	handler *Handler
+}
+
+// NewServer starts a Server whose forecasts are synthesised from seed.
+func NewServer(seed int64) *Server {
+	h := NewHandler(seed)
+	srv := httptest.NewServer(h)
+	return &Server{
+		URL:     srv.URL,
+		srv:     srv,
+		handler: h,
+	}
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Handler returns the handler serving the fake API, which is where the
+// server's behaviour is configured.
+func (s *Server) Handler() *Handler {
+	return s.handler
+}
+
+// Handler serves the fake Dark Sky API. It can be used without a Server, for
+// example with httptest.NewRecorder or behind another handler.
+type Handler struct {
+	// Seed determines the synthesised weather.
+	Seed int64
+
+	// Now returns the time of the current conditions of forecasts. It
+	// defaults to time.Now.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	key      string
+	latency  time.Duration
+	faults   []int
+	requests int
+}
+
+// NewHandler returns a Handler whose forecasts are synthesised from seed.
+func NewHandler(seed int64) *Handler {
+	return &Handler{Seed: seed}
+}
+
+// RequireKey makes the handler reject requests for any other key with the
+// 403 the real API returns. By default every key is accepted.
+func (h *Handler) RequireKey(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.key = key
+}
+
+// SetLatency delays every response by d.
+func (h *Handler) SetLatency(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latency = d
+}
+
+// FailNext makes the next n requests fail with the HTTP status code status,
+// such as http.StatusTooManyRequests or http.StatusServiceUnavailable.
+// Failures queued by earlier calls are served first.
+func (h *Handler) FailNext(n int, status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := 0; i < n; i++ {
+		h.faults = append(h.faults, status)
+	}
+}
+
+// Requests returns the number of requests the handler has received.
+func (h *Handler) Requests() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+// apiError is the body the Dark Sky API returns alongside error statuses.
+type apiError struct {
+	Code  int    `json:"code"`
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, apiError{Code: status, Error: msg})
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests++
+	latency, key := h.latency, h.key
+	fault := 0
+	if len(h.faults) > 0 {
+		fault, h.faults = h.faults[0], h.faults[1:]
+	}
+	h.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	switch {
+	case fault == http.StatusTooManyRequests:
+		w.Header().Set("Retry-After", "1")
+		writeError(w, fault, "daily usage limit exceeded")
+		return
+	case fault != 0:
+		writeError(w, fault, http.StatusText(fault))
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "forecast" {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if key != "" && parts[1] != key {
+		writeError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	req, err := parseRequest(parts[2], r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	writeJSON(w, http.StatusOK, synthesise(h.Seed, req, now()))
+}
+
+// request is a parsed forecast or Time Machine request.
+type request struct {
+	lat, long float64
+	at        time.Time // zero for forecast requests
+	units     string
+	exclude   map[string]bool
+	extend    bool
+}
+
+type badRequest string
+
+func (e badRequest) Error() string {
+	return string(e)
+}
+
+const (
+	errInvalidLocation = badRequest("The given location is invalid.")
+	errInvalidTime     = badRequest("The given time is invalid.")
+	errInvalidUnits    = badRequest("The given units are invalid.")
+)
+
+func parseRequest(location string, q map[string][]string) (request, error) {
+	var req request
+	fields := strings.Split(location, ",")
+	if len(fields) != 2 && len(fields) != 3 {
+		return req, errInvalidLocation
+	}
+	var err error
+	// The comparisons are written so that NaN fails them.
+	req.lat, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil || !(req.lat >= -90 && req.lat <= 90) {
+		return req, errInvalidLocation
+	}
+	req.long, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil || !(req.long >= -180 && req.long <= 180) {
+		return req, errInvalidLocation
+	}
+	if len(fields) == 3 {
+		req.at, err = parseTime(fields[2], req.long)
+		if err != nil {
+			return req, errInvalidTime
+		}
+	}
+
+	req.units = "us"
+	if u := first(q["units"]); u != "" {
+		switch u {
+		case "auto", "us", "si", "ca", "uk2":
+			req.units = u
+		default:
+			return req, errInvalidUnits
+		}
+	}
+	if req.units == "auto" {
+		req.units = "si"
+		if req.lat > 24 && req.lat < 50 && req.long > -125 && req.long < -66 {
+			req.units = "us"
+		}
+	}
+	req.exclude = make(map[string]bool)
+	for _, block := range strings.Split(first(q["exclude"]), ",") {
+		if block = strings.TrimSpace(block); block != "" {
+			req.exclude[block] = true
+		}
+	}
+	req.extend = first(q["extend"]) == "hourly"
+	return req, nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// parseTime parses a Time Machine time: either UNIX time or
+// [YYYY]-[MM]-[DD]T[HH]:[MM]:[SS] with an optional zone, which is otherwise
+// the local zone of the longitude.
+func parseTime(s string, long float64) (time.Time, error) {
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", s, zoneFor(long))
+}
@@ -0,0 +1,307 @@
+package darkskytest_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/darkskytest"
+)
+
+var now = time.Date(2019, 12, 17, 18, 4, 39, 0, time.UTC)
+
+func newServer(t *testing.T) (*darkskytest.Server, *darksky.Client) {
+	srv := darkskytest.NewServer(1)
+	srv.Handler().Now = func() time.Time { return now }
+	t.Cleanup(srv.Close)
+	return srv, &darksky.Client{Key: "key", BaseURL: srv.URL}
+}
+
+func TestServer_Forecast(t *testing.T) {
+	_, c := newServer(t)
+
+	type checkFn func(*testing.T, *darksky.Forecast, error)
+	check := func(fns ...checkFn) []checkFn { return fns }
+
+	hasNoErr := func() checkFn {
+		return func(t *testing.T, fc *darksky.Forecast, err error) {
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+		}
+	}
+	hasErr := func() checkFn {
+		return func(t *testing.T, fc *darksky.Forecast, err error) {
+			if err == nil {
+				t.Fatalf("err = nil; want non-nil")
+			}
+		}
+	}
+	hasCoords := func(lat, long float64) checkFn {
+		return func(t *testing.T, fc *darksky.Forecast, err error) {
+			if fc.Latitude != lat || fc.Longitude != long {
+				t.Errorf("coords = %f,%f; want %f,%f", fc.Latitude, fc.Longitude, lat, long)
+			}
+		}
+	}
+	hasBlocks := func(hourly, daily int) checkFn {
+		return func(t *testing.T, fc *darksky.Forecast, err error) {
+			if len(fc.Hourly.Data) != hourly {
+				t.Errorf("len(Hourly.Data) = %d; want %d", len(fc.Hourly.Data), hourly)
+			}
+			if len(fc.Daily.Data) != daily {
+				t.Errorf("len(Daily.Data) = %d; want %d", len(fc.Daily.Data), daily)
+			}
+		}
+	}
+	isPlausible := func() checkFn {
+		return func(t *testing.T, fc *darksky.Forecast, err error) {
+			cur := fc.Currently
+			if cur.Temperature < -80 || cur.Temperature > 130 {
+				t.Errorf("Currently.Temperature = %f; want a plausible temperature", cur.Temperature)
+			}
+			if cur.Humidity < 0 || cur.Humidity > 1 {
+				t.Errorf("Currently.Humidity = %f; want within [0, 1]", cur.Humidity)
+			}
+			if cur.WindGust < cur.WindSpeed {
+				t.Errorf("Currently.WindGust = %f; want >= WindSpeed %f", cur.WindGust, cur.WindSpeed)
+			}
+			if cur.Summary == "" || cur.Icon == "" {
+				t.Errorf("Currently.Summary, Icon = %q, %q; want non-empty", cur.Summary, cur.Icon)
+			}
+			for _, d := range fc.Daily.Data {
+				if d.TemperatureHigh < d.TemperatureLow {
+					t.Errorf("TemperatureHigh = %f; want >= TemperatureLow %f", d.TemperatureHigh, d.TemperatureLow)
+				}
+				if d.SunriseTime >= d.SunsetTime {
+					t.Errorf("SunriseTime = %d; want before SunsetTime %d", d.SunriseTime, d.SunsetTime)
+				}
+			}
+			if _, err := fc.LocalTime(); err != nil {
+				t.Errorf("LocalTime() err = %v; want nil", err)
+			}
+		}
+	}
+
+	tests := map[string]struct {
+		lat    float64
+		long   float64
+		checks []checkFn
+	}{
+		"southern terminus": {
+			lat:    32.58972,
+			long:   -116.466988,
+			checks: check(hasNoErr(), hasCoords(32.58972, -116.466988), hasBlocks(49, 8), isPlausible()),
+		},
+		"arctic": {
+			lat:    78.2232,
+			long:   15.6267,
+			checks: check(hasNoErr(), hasCoords(78.2232, 15.6267), isPlausible()),
+		},
+		"invalid latitude": {
+			lat:    132.0,
+			long:   -116.466988,
+			checks: check(hasErr()),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fc, err := c.Forecast(tc.lat, tc.long)
+			for _, check := range tc.checks {
+				check(t, fc, err)
+			}
+		})
+	}
+}
+
+func TestServer_Deterministic(t *testing.T) {
+	_, c := newServer(t)
+	a, err := c.Forecast(32.58972, -116.466988)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	b, err := c.Forecast(32.58972, -116.466988)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("forecasts for the same seed, location and time differ")
+	}
+
+	other := darkskytest.NewServer(2)
+	defer other.Close()
+	other.Handler().Now = func() time.Time { return now }
+	oc := &darksky.Client{Key: "key", BaseURL: other.URL}
+	d, err := oc.Forecast(32.58972, -116.466988)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if reflect.DeepEqual(a, d) {
+		t.Errorf("forecasts for different seeds are identical")
+	}
+}
+
+func TestServer_TimeMachine(t *testing.T) {
+	_, c := newServer(t)
+	at := time.Date(2019, 7, 4, 15, 0, 0, 0, time.UTC)
+	fc, err := c.TimeMachine(32.58972, -116.466988, at)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if fc.Currently.Time != int(at.Unix()) {
+		t.Errorf("Currently.Time = %d; want %d", fc.Currently.Time, at.Unix())
+	}
+	if len(fc.Hourly.Data) != 24 {
+		t.Errorf("len(Hourly.Data) = %d; want 24", len(fc.Hourly.Data))
+	}
+	if len(fc.Daily.Data) != 1 {
+		t.Errorf("len(Daily.Data) = %d; want 1", len(fc.Daily.Data))
+	}
+}
+
+// get requests path from the handler and decodes the JSON response.
+func get(t *testing.T, h http.Handler, path string) (int, map[string]interface{}) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body, err := ioutil.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to read the response body. err = %v", err)
+	}
+	var doc map[string]interface{}
+	err = json.Unmarshal(body, &doc)
+	if err != nil {
+		t.Fatalf("failed to json unmarshal the response body: %s. err = %v", body, err)
+	}
+	return rec.Code, doc
+}
+
+func TestHandler_Query(t *testing.T) {
+	h := darkskytest.NewHandler(1)
+	h.Now = func() time.Time { return now }
+
+	_, us := get(t, h, "/forecast/key/32.58972,-116.466988")
+	_, si := get(t, h, "/forecast/key/32.58972,-116.466988?units=si")
+	usTemp := us["currently"].(map[string]interface{})["temperature"].(float64)
+	siTemp := si["currently"].(map[string]interface{})["temperature"].(float64)
+	if want := (usTemp - 32) * 5 / 9; siTemp < want-0.01 || siTemp > want+0.01 {
+		t.Errorf("si temperature = %f; want %f", siTemp, want)
+	}
+	if units := si["flags"].(map[string]interface{})["units"]; units != "si" {
+		t.Errorf("flags.units = %v; want si", units)
+	}
+
+	_, excluded := get(t, h, "/forecast/key/32.58972,-116.466988?exclude=minutely,hourly,flags")
+	for _, block := range []string{"minutely", "hourly", "flags"} {
+		if _, ok := excluded[block]; ok {
+			t.Errorf("response has excluded block %q", block)
+		}
+	}
+	if _, ok := excluded["daily"]; !ok {
+		t.Errorf("response is missing the daily block")
+	}
+
+	_, extended := get(t, h, "/forecast/key/32.58972,-116.466988?extend=hourly")
+	hourly := extended["hourly"].(map[string]interface{})["data"].([]interface{})
+	if len(hourly) != 169 {
+		t.Errorf("len(hourly.data) = %d; want 169 with extend=hourly", len(hourly))
+	}
+}
+
+func TestHandler_Errors(t *testing.T) {
+	tests := map[string]struct {
+		setup      func(h *darkskytest.Handler)
+		path       string
+		wantStatus int
+		wantError  string
+	}{
+		"invalid location": {
+			path:       "/forecast/key/132,-116.466988",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "The given location is invalid.",
+		},
+		"longitude out of range": {
+			path:       "/forecast/key/32,-181",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "The given location is invalid.",
+		},
+		"longitude is NaN": {
+			path:       "/forecast/key/32,NaN",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "The given location is invalid.",
+		},
+		"latitude is infinite": {
+			path:       "/forecast/key/+Inf,-116",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "The given location is invalid.",
+		},
+		"invalid time": {
+			path:       "/forecast/key/32,-116,yesterday",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "The given time is invalid.",
+		},
+		"wrong key": {
+			setup:      func(h *darkskytest.Handler) { h.RequireKey("secret") },
+			path:       "/forecast/key/32,-116",
+			wantStatus: http.StatusForbidden,
+			wantError:  "permission denied",
+		},
+		"rate limited": {
+			setup:      func(h *darkskytest.Handler) { h.FailNext(1, http.StatusTooManyRequests) },
+			path:       "/forecast/key/32,-116",
+			wantStatus: http.StatusTooManyRequests,
+			wantError:  "daily usage limit exceeded",
+		},
+		"server error": {
+			setup:      func(h *darkskytest.Handler) { h.FailNext(1, http.StatusServiceUnavailable) },
+			path:       "/forecast/key/32,-116",
+			wantStatus: http.StatusServiceUnavailable,
+			wantError:  "Service Unavailable",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := darkskytest.NewHandler(1)
+			if tc.setup != nil {
+				tc.setup(h)
+			}
+			status, doc := get(t, h, tc.path)
+			if status != tc.wantStatus {
+				t.Errorf("status = %d; want %d", status, tc.wantStatus)
+			}
+			if doc["code"] != float64(tc.wantStatus) || doc["error"] != tc.wantError {
+				t.Errorf("body = %v; want code %d and error %q", doc, tc.wantStatus, tc.wantError)
+			}
+		})
+	}
+}
+
+func TestHandler_FailNext(t *testing.T) {
+	h := darkskytest.NewHandler(1)
+	h.FailNext(2, http.StatusInternalServerError)
+	for i, want := range []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK} {
+		if status, _ := get(t, h, "/forecast/key/32,-116"); status != want {
+			t.Errorf("request %d: status = %d; want %d", i, status, want)
+		}
+	}
+	if h.Requests() != 3 {
+		t.Errorf("Requests() = %d; want 3", h.Requests())
+	}
+}
+
+func TestServer_Latency(t *testing.T) {
+	srv, c := newServer(t)
+	srv.Handler().SetLatency(50 * time.Millisecond)
+	start := time.Now()
+	_, err := c.Forecast(32.58972, -116.466988)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request took %v; want at least 50ms", elapsed)
+	}
+}
@@ -0,0 +1,454 @@
+package darkskytest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// zoneFor returns a fixed zone for the longitude, in whole hours from UTC.
+// Its name is the matching Etc/GMT zone, which uses inverted signs.
+func zoneFor(long float64) *time.Location {
+	hours := int(math.Round(long / 15))
+	name := "Etc/GMT"
+	if hours > 0 {
+		name = fmt.Sprintf("Etc/GMT-%d", hours)
+	} else if hours < 0 {
+		name = fmt.Sprintf("Etc/GMT+%d", -hours)
+	}
+	return time.FixedZone(name, hours*3600)
+}
+
+// weather synthesises the conditions at a location for a seed.
+type weather struct {
+	seed      int64
+	lat, long float64
+}
+
+// noise returns a deterministic value in [0, 1) for a variable and step.
+func (wx weather) noise(name string, step int64) float64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, v := range []uint64{uint64(wx.seed), math.Float64bits(round(wx.lat, 4)), math.Float64bits(round(wx.long, 4)), uint64(step)} {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	h.Write([]byte(name))
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
+
+// smooth returns noise for a variable that varies smoothly over a few hours.
+func (wx weather) smooth(name string, t time.Time) float64 {
+	const period = 6 * 60 * 60
+	pos := float64(t.Unix()) / period
+	step := math.Floor(pos)
+	frac := (1 - math.Cos((pos-step)*math.Pi)) / 2
+	a, b := wx.noise(name, int64(step)), wx.noise(name, int64(step)+1)
+	return a + (b-a)*frac
+}
+
+// solarHour returns the local solar time of t in hours.
+func (wx weather) solarHour(t time.Time) float64 {
+	u := t.UTC()
+	h := float64(u.Hour()) + float64(u.Minute())/60 + wx.long/15
+	return math.Mod(h+48, 24)
+}
+
+// season is 1 at the height of local summer and -1 at the height of winter.
+func (wx weather) season(t time.Time) float64 {
+	s := math.Cos(2 * math.Pi * float64(t.UTC().YearDay()-200) / 365.25)
+	if wx.lat < 0 {
+		s = -s
+	}
+	return s
+}
+
+// dayLength returns the approximate hours of daylight on the day of t.
+func (wx weather) dayLength(t time.Time) float64 {
+	return clamp(12+wx.season(t)*math.Min(math.Abs(wx.lat), 66)/66*6, 0, 24)
+}
+
+// conditions are the synthesised conditions at an instant, in US units.
+type conditions struct {
+	time                 time.Time
+	summary, icon        string
+	precipIntensity      float64
+	precipProbability    float64
+	precipType           string
+	temperature          float64
+	apparentTemperature  float64
+	dewPoint             float64
+	humidity             float64
+	pressure             float64
+	windSpeed, windGust  float64
+	windBearing          int
+	cloudCover           float64
+	uvIndex              int
+	visibility, ozone    float64
+	nearestStormDistance float64
+}
+
+func (wx weather) at(t time.Time) conditions {
+	c := conditions{time: t}
+	hour := wx.solarHour(t)
+	mean := 80 - 0.012*wx.lat*wx.lat
+	amplitude := 0.45 * math.Abs(wx.lat)
+	c.temperature = round(mean+amplitude*wx.season(t)+10*math.Cos(2*math.Pi*(hour-15)/24)+(wx.smooth("temperature", t)-0.5)*8, 2)
+
+	c.cloudCover = round(wx.smooth("cloud", t), 2)
+	c.precipProbability = round(clamp((c.cloudCover-0.6)/0.4, 0, 1)*wx.smooth("precip", t), 2)
+	if c.precipProbability > 0 {
+		c.precipIntensity = round(c.precipProbability*0.1*wx.smooth("intensity", t), 4)
+		switch {
+		case c.temperature < 32:
+			c.precipType = "snow"
+		case c.temperature < 35:
+			c.precipType = "sleet"
+		default:
+			c.precipType = "rain"
+		}
+	}
+	c.humidity = round(clamp(0.3+0.5*c.cloudCover+0.2*(wx.smooth("humidity", t)-0.5), 0.05, 1), 2)
+	// Magnus formula for the dew point, in Celsius.
+	tc := (c.temperature - 32) * 5 / 9
+	gamma := math.Log(c.humidity) + 17.62*tc/(243.12+tc)
+	c.dewPoint = round((243.12*gamma/(17.62-gamma))*9/5+32, 2)
+	c.pressure = round(1013+(wx.smooth("pressure", t)-0.5)*30-c.cloudCover*5, 1)
+
+	wind := wx.smooth("wind", t)
+	c.windSpeed = round(1+30*wind*wind, 2)
+	c.windGust = round(c.windSpeed*(1.3+0.5*wx.smooth("gust", t)), 2)
+	c.windBearing = int(wx.smooth("bearing", t)*360) % 360
+	c.apparentTemperature = apparent(c.temperature, c.humidity, c.windSpeed)
+
+	daylight := wx.dayLength(t)
+	if sun := (hour - (12 - daylight/2)) / daylight; sun > 0 && sun < 1 {
+		c.uvIndex = int(math.Round((1 - 0.7*c.cloudCover) * 11 * math.Cos(wx.lat*math.Pi/180) * math.Sin(sun*math.Pi)))
+	}
+	c.visibility = round(10-7*c.precipProbability, 3)
+	c.ozone = round(250+100*wx.smooth("ozone", t), 1)
+	c.nearestStormDistance = math.Round(300 * (1 - c.precipProbability) * wx.smooth("storm", t))
+
+	day := c.uvIndex > 0 || (hour > 12-daylight/2 && hour < 12+daylight/2)
+	switch {
+	case c.precipProbability >= 0.5:
+		c.icon = c.precipType
+	case c.windSpeed >= 20:
+		c.icon = "wind"
+	case c.cloudCover > 0.75:
+		c.icon = "cloudy"
+	case c.cloudCover > 0.4 && day:
+		c.icon = "partly-cloudy-day"
+	case c.cloudCover > 0.4:
+		c.icon = "partly-cloudy-night"
+	case day:
+		c.icon = "clear-day"
+	default:
+		c.icon = "clear-night"
+	}
+	c.summary = summaries[c.icon]
+	if c.icon == "cloudy" && c.cloudCover < 0.9 {
+		c.summary = "Mostly Cloudy"
+	}
+	return c
+}
+
+var summaries = map[string]string{
+	"clear-day":           "Clear",
+	"clear-night":         "Clear",
+	"partly-cloudy-day":   "Partly Cloudy",
+	"partly-cloudy-night": "Partly Cloudy",
+	"cloudy":              "Overcast",
+	"rain":                "Rain",
+	"snow":                "Snow",
+	"sleet":               "Sleet",
+	"wind":                "Windy",
+}
+
+// apparent returns the wind chill or heat index of the temperature.
+func apparent(temp, humidity, wind float64) float64 {
+	switch {
+	case temp <= 50 && wind > 3:
+		v := math.Pow(wind, 0.16)
+		return round(35.74+0.6215*temp-35.75*v+0.4275*temp*v, 2)
+	case temp >= 80:
+		rh := humidity * 100
+		return round(-42.379+2.04901523*temp+10.14333127*rh-0.22475541*temp*rh-
+			6.83783e-3*temp*temp-5.481717e-2*rh*rh+1.22874e-3*temp*temp*rh+
+			8.5282e-4*temp*rh*rh-1.99e-6*temp*temp*rh*rh, 2)
+	}
+	return temp
+}
+
+// moonPhase returns the fraction of the lunation at t, where 0 is a new moon
+// and 0.5 a full moon.
+func moonPhase(t time.Time) float64 {
+	const synodic = 29.530588853 * 24 * 60 * 60
+	newMoon := time.Date(2000, 1, 6, 18, 14, 0, 0, time.UTC)
+	phase := math.Mod(t.Sub(newMoon).Seconds()/synodic, 1)
+	if phase < 0 {
+		phase++
+	}
+	return round(phase, 2)
+}
+
+func round(f float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(f*p) / p
+}
+
+func clamp(f, min, max float64) float64 {
+	return math.Max(min, math.Min(max, f))
+}
+
+// units converts values from US units to those requested.
+type units string
+
+func (u units) temperature(f float64) float64 {
+	if u == "us" {
+		return f
+	}
+	return round((f-32)*5/9, 2)
+}
+
+func (u units) speed(mph float64) float64 {
+	switch u {
+	case "si":
+		return round(mph*0.44704, 2)
+	case "ca":
+		return round(mph*1.609344, 2)
+	}
+	return mph
+}
+
+func (u units) distance(miles float64) float64 {
+	if u == "us" || u == "uk2" {
+		return miles
+	}
+	return round(miles*1.609344, 3)
+}
+
+func (u units) intensity(inches float64) float64 {
+	if u == "us" {
+		return inches
+	}
+	return round(inches*25.4, 4)
+}
+
+// point renders conditions as a data point of the given block.
+func (u units) point(c conditions, block string) map[string]interface{} {
+	p := map[string]interface{}{
+		"time":              c.time.Unix(),
+		"precipIntensity":   u.intensity(c.precipIntensity),
+		"precipProbability": c.precipProbability,
+	}
+	if c.precipType != "" {
+		p["precipType"] = c.precipType
+	}
+	if block == "minutely" {
+		if c.precipProbability > 0 {
+			p["precipIntensityError"] = u.intensity(round(c.precipIntensity/4, 4))
+		}
+		return p
+	}
+	p["summary"] = c.summary
+	p["icon"] = c.icon
+	p["temperature"] = u.temperature(c.temperature)
+	p["apparentTemperature"] = u.temperature(c.apparentTemperature)
+	p["dewPoint"] = u.temperature(c.dewPoint)
+	p["humidity"] = c.humidity
+	p["pressure"] = c.pressure
+	p["windSpeed"] = u.speed(c.windSpeed)
+	p["windGust"] = u.speed(c.windGust)
+	p["windBearing"] = c.windBearing
+	p["cloudCover"] = c.cloudCover
+	p["uvIndex"] = c.uvIndex
+	p["visibility"] = u.distance(c.visibility)
+	p["ozone"] = c.ozone
+	if block == "currently" {
+		p["nearestStormDistance"] = u.distance(c.nearestStormDistance)
+		if c.nearestStormDistance > 0 {
+			p["nearestStormBearing"] = (c.windBearing + 180) % 360
+		}
+	}
+	return p
+}
+
+// daily renders the day starting at midnight as a daily data point.
+func (u units) daily(wx weather, midnight time.Time) map[string]interface{} {
+	var hours []conditions
+	for h := 0; h < 24; h++ {
+		hours = append(hours, wx.at(midnight.Add(time.Duration(h)*time.Hour)))
+	}
+	noon := hours[12]
+	p := u.point(noon, "daily")
+	delete(p, "temperature")
+	delete(p, "apparentTemperature")
+	p["time"] = midnight.Unix()
+
+	extreme := func(value func(conditions) float64, high bool) (float64, int64) {
+		best := hours[0]
+		for _, c := range hours[1:] {
+			if (high && value(c) > value(best)) || (!high && value(c) < value(best)) {
+				best = c
+			}
+		}
+		return value(best), best.time.Unix()
+	}
+	temp := func(c conditions) float64 { return c.temperature }
+	feels := func(c conditions) float64 { return c.apparentTemperature }
+	for _, e := range []struct {
+		name  string
+		value func(conditions) float64
+		high  bool
+	}{
+		{"temperatureHigh", temp, true},
+		{"temperatureLow", temp, false},
+		{"temperatureMax", temp, true},
+		{"temperatureMin", temp, false},
+		{"apparentTemperatureHigh", feels, true},
+		{"apparentTemperatureLow", feels, false},
+		{"apparentTemperatureMax", feels, true},
+		{"apparentTemperatureMin", feels, false},
+	} {
+		v, at := extreme(e.value, e.high)
+		p[e.name] = u.temperature(v)
+		p[e.name+"Time"] = at
+	}
+	maxIntensity, maxIntensityTime := extreme(func(c conditions) float64 { return c.precipIntensity }, true)
+	p["precipIntensityMax"] = u.intensity(maxIntensity)
+	p["precipIntensityMaxTime"] = maxIntensityTime
+	maxProb, _ := extreme(func(c conditions) float64 { return c.precipProbability }, true)
+	p["precipProbability"] = maxProb
+	maxGust, maxGustTime := extreme(func(c conditions) float64 { return c.windGust }, true)
+	p["windGust"] = u.speed(maxGust)
+	p["windGustTime"] = maxGustTime
+	maxUV, maxUVTime := extreme(func(c conditions) float64 { return float64(c.uvIndex) }, true)
+	p["uvIndex"] = int(maxUV)
+	p["uvIndexTime"] = maxUVTime
+
+	// Sunrise and sunset are symmetric around local solar noon.
+	daylight := wx.dayLength(noon.time)
+	solarNoon := time.Date(midnight.Year(), midnight.Month(), midnight.Day(), 0, 0, 0, 0, time.UTC).
+		Add(time.Duration((12 - wx.long/15) * float64(time.Hour)))
+	p["sunriseTime"] = solarNoon.Add(-time.Duration(daylight / 2 * float64(time.Hour))).Unix()
+	p["sunsetTime"] = solarNoon.Add(time.Duration(daylight / 2 * float64(time.Hour))).Unix()
+	p["moonPhase"] = moonPhase(noon.time)
+	p["summary"] = dailySummaries[noon.icon]
+	return p
+}
+
+var dailySummaries = map[string]string{
+	"clear-day":           "Clear throughout the day.",
+	"clear-night":         "Clear throughout the day.",
+	"partly-cloudy-day":   "Partly cloudy throughout the day.",
+	"partly-cloudy-night": "Partly cloudy throughout the day.",
+	"cloudy":              "Overcast throughout the day.",
+	"rain":                "Rain throughout the day.",
+	"snow":                "Snow throughout the day.",
+	"sleet":               "Sleet throughout the day.",
+	"wind":                "Windy throughout the day.",
+}
+
+// offsetHours returns the UTC offset of t in hours.
+func offsetHours(t time.Time) int {
+	_, offset := t.Zone()
+	return offset / 3600
+}
+
+// synthesise builds the response body for a request made at now.
+func synthesise(seed int64, req request, now time.Time) map[string]interface{} {
+	wx := weather{seed: seed, lat: req.lat, long: req.long}
+	u := units(req.units)
+	zone := zoneFor(req.long)
+
+	at := req.at
+	timeMachine := !at.IsZero()
+	if !timeMachine {
+		at = now
+	}
+	at = at.Truncate(time.Second).In(zone)
+	today := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, zone)
+
+	body := map[string]interface{}{
+		"latitude":  req.lat,
+		"longitude": req.long,
+		"timezone":  zone.String(),
+		"offset":    offsetHours(at),
+	}
+	block := func(points []map[string]interface{}) map[string]interface{} {
+		b := map[string]interface{}{"data": points}
+		if len(points) > 0 {
+			if icon, ok := points[0]["icon"]; ok {
+				b["icon"] = icon
+				b["summary"] = points[0]["summary"]
+			}
+		}
+		return b
+	}
+
+	body["currently"] = u.point(wx.at(at), "currently")
+
+	var hourly []map[string]interface{}
+	start, hours := at.Truncate(time.Hour), 49
+	if req.extend {
+		hours = 169
+	}
+	if timeMachine {
+		start, hours = today, 24
+	}
+	maxGust, maxGustTime := 0.0, time.Time{}
+	for h := 0; h < hours; h++ {
+		c := wx.at(start.Add(time.Duration(h) * time.Hour))
+		if c.windGust > maxGust {
+			maxGust, maxGustTime = c.windGust, c.time
+		}
+		hourly = append(hourly, u.point(c, "hourly"))
+	}
+	body["hourly"] = block(hourly)
+
+	var daily []map[string]interface{}
+	days := 8
+	if timeMachine {
+		days = 1
+	}
+	for d := 0; d < days; d++ {
+		daily = append(daily, u.daily(wx, today.AddDate(0, 0, d)))
+	}
+	body["daily"] = block(daily)
+
+	if !timeMachine {
+		var minutely []map[string]interface{}
+		for m := 0; m < 61; m++ {
+			minutely = append(minutely, u.point(wx.at(at.Truncate(time.Minute).Add(time.Duration(m)*time.Minute)), "minutely"))
+		}
+		b := block(minutely)
+		current := wx.at(at)
+		b["icon"], b["summary"] = current.icon, current.summary+" for the hour."
+		body["minutely"] = b
+
+		if maxGust >= 45 {
+			body["alerts"] = []map[string]interface{}{{
+				"title":       "Wind Advisory",
+				"regions":     []string{fmt.Sprintf("%.2f,%.2f", req.lat, req.long)},
+				"severity":    "advisory",
+				"time":        today.Unix(),
+				"expires":     maxGustTime.Add(6 * time.Hour).Unix(),
+				"description": fmt.Sprintf("...WIND ADVISORY IN EFFECT... Gusts up to %.0f mph are expected.\n", maxGust),
+				"uri":         fmt.Sprintf("https://darkskytest.invalid/alerts/%d/%.4f,%.4f", today.Unix(), req.lat, req.long),
+			}}
+		}
+	}
+	body["flags"] = map[string]interface{}{
+		"sources":         []string{"darkskytest"},
+		"nearest-station": 0,
+		"units":           req.units,
+	}
+
+	for name := range req.exclude {
+		delete(body, name)
+	}
+	return body
+}
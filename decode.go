@@ -0,0 +1,71 @@
+package darksky
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodySize is the largest response body a Client reads unless its
+// MaxBodySize says otherwise. A week of hourly data is well under 1 MB.
+const DefaultMaxBodySize = 8 << 20
+
+// decodeJSON decodes the body of res into v as it is read, decompressing it
+// if it is gzipped. It returns ErrResponseTooLarge once more than max bytes
+// have been read, and ErrTrailingData if anything but whitespace follows
+// the JSON value.
+func decodeJSON(res *http.Response, max int64, v interface{}) error {
+	var body io.Reader = &limitedReader{r: res.Body, n: max}
+	if strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		// Limit the decompressed size too, against gzip bombs.
+		body = &limitedReader{r: zr, n: max}
+	}
+	dec := json.NewDecoder(body)
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	// Reading to the end also lets the connection be reused.
+	switch _, err := dec.Token(); err {
+	case io.EOF:
+		return nil
+	case ErrResponseTooLarge:
+		return err
+	}
+	return ErrTrailingData
+}
+
+// limitedReader fails with ErrResponseTooLarge if there is more to read
+// than n bytes, unlike io.LimitedReader, which ends quietly. It never
+// returns bytes past the limit, so nothing can be decoded from them.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var b [1]byte
+		for {
+			n, err := l.r.Read(b[:])
+			if n > 0 {
+				return 0, ErrResponseTooLarge
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
@@ -0,0 +1,123 @@
+package darksky_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// bodyDoer answers every request with body, gzipped if gz is set.
+func bodyDoer(t testing.TB, body []byte, gz bool) darksky.Doer {
+	header := http.Header{"Content-Type": {"application/json"}}
+	if gz {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		if err := zw.Close(); err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		body = buf.Bytes()
+		header.Set("Content-Encoding", "gzip")
+	}
+	return darksky.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+		}, nil
+	})
+}
+
+func readFixture(t testing.TB) []byte {
+	data, err := ioutil.ReadFile("SouthernTerminus.json")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	return data
+}
+
+func TestClient_decode(t *testing.T) {
+	data := readFixture(t)
+	want := loadForecast(t, "SouthernTerminus.json")
+
+	tests := map[string]struct {
+		gz          bool
+		maxBodySize int64
+		trailer     string
+		err         error
+	}{
+		"plain":                  {},
+		"gzipped":                {gz: true},
+		"exactly the limit":      {maxBodySize: int64(len(data))},
+		"too large":              {maxBodySize: int64(len(data)) - 1, err: darksky.ErrResponseTooLarge},
+		"too large unzipped":     {gz: true, maxBodySize: 4096, err: darksky.ErrResponseTooLarge},
+		"much larger than limit": {maxBodySize: 1024, err: darksky.ErrResponseTooLarge},
+		"trailing newline":       {trailer: "\r\n"},
+		"trailing garbage":       {trailer: "\n<html>", err: darksky.ErrTrailingData},
+		"second document":        {gz: true, trailer: "{}", err: darksky.ErrTrailingData},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			body := append(append([]byte(nil), data...), tc.trailer...)
+			c := &darksky.Client{Key: "key", HttpClient: bodyDoer(t, body, tc.gz), MaxBodySize: tc.maxBodySize}
+			fc, err := c.Forecast(stLat, stLong)
+			if err != tc.err {
+				t.Fatalf("err = %v; want %v", err, tc.err)
+			}
+			if err == nil && (fc.Currently != want.Currently || len(fc.Hourly.Data) != len(want.Hourly.Data)) {
+				t.Errorf("Forecast() = %+v; want the fixture", fc.Currently)
+			}
+		})
+	}
+}
+
+// BenchmarkDecode compares reading the whole body before unmarshalling it,
+// as Client used to, with decoding it as it is read.
+func BenchmarkDecode(b *testing.B) {
+	data := readFixture(b)
+	b.Run("ReadAll", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			body, err := ioutil.ReadAll(bytes.NewReader(data))
+			if err != nil {
+				b.Fatal(err)
+			}
+			var fc darksky.Forecast
+			if err := json.Unmarshal(body, &fc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Stream", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for i := 0; i < b.N; i++ {
+			var fc darksky.Forecast
+			if err := json.NewDecoder(bytes.NewReader(data)).Decode(&fc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkClient_Forecast(b *testing.B) {
+	data := readFixture(b)
+	for name, gz := range map[string]bool{"plain": false, "gzipped": true} {
+		b.Run(name, func(b *testing.B) {
+			c := &darksky.Client{Key: "key", HttpClient: bodyDoer(b, data, gz)}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.Forecast(stLat, stLong); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package darksky
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Thresholds decide which changes Diff reports.
+type Thresholds struct {
+	// Fields holds the smallest change reported for each numeric field of
+	// the hourly and daily data points, keyed by its JSON name. Fields
+	// that are not listed are not compared. It defaults to
+	// DefaultFieldThresholds.
+	Fields map[string]float64
+
+	// PrecipProbability is the hourly probability at which precipitation
+	// counts as expected when looking for its onset. It defaults to 0.3.
+	PrecipProbability float64
+}
+
+// DefaultFieldThresholds are the field thresholds used when
+// Thresholds.Fields is nil, for forecasts in US units.
+var DefaultFieldThresholds = map[string]float64{
+	"temperature":         3,
+	"apparentTemperature": 3,
+	"temperatureHigh":     3,
+	"temperatureLow":      3,
+	"precipProbability":   0.2,
+	"precipIntensity":     0.05,
+	"windSpeed":           5,
+	"windGust":            10,
+	"windBearing":         45,
+	"humidity":            0.15,
+	"cloudCover":          0.25,
+	"uvIndex":             2,
+}
+
+// ForecastDiff is what changed between two forecasts for the same location.
+type ForecastDiff struct {
+	Timezone      string       `json:"timezone"`
+	Hourly        []PointDiff  `json:"hourly,omitempty"`
+	Daily         []PointDiff  `json:"daily,omitempty"`
+	PrecipOnset   *OnsetChange `json:"precipOnset,omitempty"`
+	AlertsAdded   []Alert      `json:"alertsAdded,omitempty"`
+	AlertsRemoved []Alert      `json:"alertsRemoved,omitempty"`
+}
+
+// PointDiff is what changed in an hourly or daily data point.
+type PointDiff struct {
+	Time       int           `json:"time"`
+	Fields     []FieldChange `json:"fields,omitempty"`
+	Icon       *TextChange   `json:"icon,omitempty"`
+	PrecipType *TextChange   `json:"precipType,omitempty"`
+}
+
+// FieldChange is a change in a numeric field, named by its JSON name.
+type FieldChange struct {
+	Field string  `json:"field"`
+	Old   float64 `json:"old"`
+	New   float64 `json:"new"`
+	Delta float64 `json:"delta"`
+}
+
+// TextChange is a change in a text field such as the icon.
+type TextChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// OnsetChange is a change in when precipitation is first expected. Old or
+// New is zero if no precipitation was or is expected.
+type OnsetChange struct {
+	Old        int    `json:"old"`
+	New        int    `json:"new"`
+	PrecipType string `json:"precipType,omitempty"`
+}
+
+// Diff compares two forecasts for the same location, old fetched before
+// new. Hourly and daily points are aligned by time; points in only one of
+// the forecasts are skipped. The onset of precipitation is only compared
+// within the hours both forecasts cover.
+func Diff(old, new *Forecast, thresholds Thresholds) *ForecastDiff {
+	fields := thresholds.Fields
+	if fields == nil {
+		fields = DefaultFieldThresholds
+	}
+	prob := thresholds.PrecipProbability
+	if prob <= 0 {
+		prob = 0.3
+	}
+
+	d := &ForecastDiff{Timezone: new.Timezone}
+	oldHours := make(map[int]HourlyDataPoint, len(old.Hourly.Data))
+	for _, p := range old.Hourly.Data {
+		oldHours[p.Time] = p
+	}
+	for _, p := range new.Hourly.Data {
+		o, ok := oldHours[p.Time]
+		if !ok {
+			continue
+		}
+		if pd, changed := diffPoint(p.Time, reflect.ValueOf(o), reflect.ValueOf(p), fields); changed {
+			d.Hourly = append(d.Hourly, pd)
+		}
+	}
+	oldDays := make(map[int]DailyDataPoint, len(old.Daily.Data))
+	for _, p := range old.Daily.Data {
+		oldDays[p.Time] = p
+	}
+	for _, p := range new.Daily.Data {
+		o, ok := oldDays[p.Time]
+		if !ok {
+			continue
+		}
+		if pd, changed := diffPoint(p.Time, reflect.ValueOf(o), reflect.ValueOf(p), fields); changed {
+			d.Daily = append(d.Daily, pd)
+		}
+	}
+
+	d.PrecipOnset = diffOnset(old.Hourly.Data, new.Hourly.Data, prob)
+
+	oldAlerts := make(map[string]bool, len(old.Alerts))
+	for _, a := range old.Alerts {
+		oldAlerts[alertKey(a)] = true
+	}
+	newAlerts := make(map[string]bool, len(new.Alerts))
+	for _, a := range new.Alerts {
+		newAlerts[alertKey(a)] = true
+		if !oldAlerts[alertKey(a)] {
+			d.AlertsAdded = append(d.AlertsAdded, a)
+		}
+	}
+	for _, a := range old.Alerts {
+		if !newAlerts[alertKey(a)] {
+			d.AlertsRemoved = append(d.AlertsRemoved, a)
+		}
+	}
+	return d
+}
+
+// Empty reports whether nothing changed.
+func (d *ForecastDiff) Empty() bool {
+	return len(d.Hourly) == 0 && len(d.Daily) == 0 && d.PrecipOnset == nil &&
+		len(d.AlertsAdded) == 0 && len(d.AlertsRemoved) == 0
+}
+
+// diffPoint compares the fields listed in thresholds and the icon and
+// precipitation type of two data points of the same type.
+func diffPoint(t int, old, new reflect.Value, thresholds map[string]float64) (PointDiff, bool) {
+	pd := PointDiff{Time: t}
+	typ := new.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		name := typ.Field(i).Tag.Get("json")
+		switch name {
+		case "icon", "precipType":
+			o, n := old.Field(i).String(), new.Field(i).String()
+			if o == n {
+				continue
+			}
+			if name == "icon" {
+				pd.Icon = &TextChange{Old: o, New: n}
+			} else {
+				pd.PrecipType = &TextChange{Old: o, New: n}
+			}
+			continue
+		}
+		threshold, ok := thresholds[name]
+		if !ok {
+			continue
+		}
+		var o, n float64
+		switch typ.Field(i).Type.Kind() {
+		case reflect.Float64:
+			o, n = old.Field(i).Float(), new.Field(i).Float()
+		case reflect.Int:
+			o, n = float64(old.Field(i).Int()), float64(new.Field(i).Int())
+		default:
+			continue
+		}
+		delta := n - o
+		if period, ok := periods[name]; ok {
+			delta = math.Mod(delta+period/2, period)
+			if delta < 0 {
+				delta += period
+			}
+			delta -= period / 2
+		}
+		if math.Abs(delta) >= threshold {
+			pd.Fields = append(pd.Fields, FieldChange{Field: name, Old: o, New: n, Delta: delta})
+		}
+	}
+	return pd, len(pd.Fields) > 0 || pd.Icon != nil || pd.PrecipType != nil
+}
+
+// diffOnset returns how the first hour with precipitation changed within
+// the hours both forecasts cover, or nil if it didn't.
+func diffOnset(old, new []HourlyDataPoint, prob float64) *OnsetChange {
+	if len(old) == 0 || len(new) == 0 {
+		return nil
+	}
+	from := new[0].Time
+	if old[0].Time > from {
+		from = old[0].Time
+	}
+	to := new[len(new)-1].Time
+	if old[len(old)-1].Time < to {
+		to = old[len(old)-1].Time
+	}
+	onset := func(data []HourlyDataPoint) (int, string) {
+		for _, p := range data {
+			if p.Time >= from && p.Time <= to && p.PrecipProbability >= prob && p.PrecipIntensity > 0 {
+				return p.Time, p.PrecipType
+			}
+		}
+		return 0, ""
+	}
+	o, oldType := onset(old)
+	n, newType := onset(new)
+	if o == n {
+		return nil
+	}
+	if newType == "" {
+		newType = oldType
+	}
+	return &OnsetChange{Old: o, New: n, PrecipType: newType}
+}
+
+// alertKey identifies an alert across fetches by its URI, or by its title
+// and time if it has none.
+func alertKey(a Alert) string {
+	if a.URI != "" {
+		return a.URI
+	}
+	return a.Title + "\x00" + strconv.Itoa(a.Time)
+}
+
+// String renders the diff as English text, one change per line, with
+// times in the forecast's timezone, for example "Rain now expected around
+// 3 PM instead of 6 PM".
+func (d *ForecastDiff) String() string {
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	at := func(t int, layout string) string {
+		return time.Unix(int64(t), 0).In(loc).Format(layout)
+	}
+
+	var lines []string
+	if o := d.PrecipOnset; o != nil {
+		precip := English.PrecipTypes[o.PrecipType]
+		if precip == "" {
+			precip = English.PrecipTypes["rain"]
+		}
+		switch {
+		case o.Old == 0:
+			lines = append(lines, fmt.Sprintf("%s now expected around %s", precip, at(o.New, "3 PM")))
+		case o.New == 0:
+			lines = append(lines, fmt.Sprintf("%s no longer expected around %s", precip, at(o.Old, "3 PM")))
+		default:
+			lines = append(lines, fmt.Sprintf("%s now expected around %s instead of %s", precip, at(o.New, "3 PM"), at(o.Old, "3 PM")))
+		}
+	}
+	for _, a := range d.AlertsAdded {
+		lines = append(lines, "New alert: "+a.Title)
+	}
+	for _, a := range d.AlertsRemoved {
+		lines = append(lines, "Alert ended: "+a.Title)
+	}
+	for _, p := range d.Hourly {
+		lines = append(lines, at(p.Time, "Mon 3 PM")+": "+p.changes())
+	}
+	for _, p := range d.Daily {
+		lines = append(lines, at(p.Time, "Monday")+": "+p.changes())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// changes lists the changes to a point, for example "temperature 61 to
+// 67 (+6), icon cloudy to rain".
+func (p PointDiff) changes() string {
+	var parts []string
+	for _, f := range p.Fields {
+		parts = append(parts, fmt.Sprintf("%s %s to %s (%s%s)", f.Field,
+			formatDelta(f.Old), formatDelta(f.New), sign(f.Delta), formatDelta(f.Delta)))
+	}
+	if p.Icon != nil {
+		parts = append(parts, fmt.Sprintf("icon %s to %s", textOrNone(p.Icon.Old), textOrNone(p.Icon.New)))
+	}
+	if p.PrecipType != nil {
+		parts = append(parts, fmt.Sprintf("precipType %s to %s", textOrNone(p.PrecipType.Old), textOrNone(p.PrecipType.New)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatDelta(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func sign(v float64) string {
+	if v > 0 {
+		return "+"
+	}
+	return ""
+}
+
+func textOrNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
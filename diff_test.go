@@ -0,0 +1,160 @@
+package darksky_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+func TestDiff(t *testing.T) {
+	fixture := loadForecast(t, "SouthernTerminus.json")
+	hour := func(i int) int { return fixture.Hourly.Data[i].Time }
+	rain := func(p *darksky.HourlyDataPoint) {
+		p.PrecipProbability, p.PrecipIntensity, p.PrecipType = 0.8, 0.1, "rain"
+	}
+
+	tests := map[string]struct {
+		change func(old, new *darksky.Forecast)
+		want   darksky.ForecastDiff
+	}{
+		"unchanged": {
+			change: func(old, new *darksky.Forecast) {},
+		},
+		"later fetch": {
+			change: func(old, new *darksky.Forecast) {
+				new.Hourly.Data = new.Hourly.Data[3:]
+				new.Daily.Data = new.Daily.Data[1:]
+			},
+		},
+		"below threshold": {
+			change: func(old, new *darksky.Forecast) {
+				new.Hourly.Data[1].Temperature += 2.9
+				old.Hourly.Data[1].WindBearing, new.Hourly.Data[1].WindBearing = 350, 20
+			},
+		},
+		"temperature": {
+			change: func(old, new *darksky.Forecast) {
+				old.Hourly.Data[1].Temperature, new.Hourly.Data[1].Temperature = 61, 67
+			},
+			want: darksky.ForecastDiff{Hourly: []darksky.PointDiff{{
+				Time:   hour(1),
+				Fields: []darksky.FieldChange{{Field: "temperature", Old: 61, New: 67, Delta: 6}},
+			}}},
+		},
+		"wind bearing across north": {
+			change: func(old, new *darksky.Forecast) {
+				old.Hourly.Data[2].WindBearing, new.Hourly.Data[2].WindBearing = 340, 40
+			},
+			want: darksky.ForecastDiff{Hourly: []darksky.PointDiff{{
+				Time:   hour(2),
+				Fields: []darksky.FieldChange{{Field: "windBearing", Old: 340, New: 40, Delta: 60}},
+			}}},
+		},
+		"daily icon": {
+			change: func(old, new *darksky.Forecast) {
+				old.Daily.Data[2].Icon, new.Daily.Data[2].Icon = "clear-day", "snow"
+			},
+			want: darksky.ForecastDiff{Daily: []darksky.PointDiff{{
+				Time: fixture.Daily.Data[2].Time,
+				Icon: &darksky.TextChange{Old: "clear-day", New: "snow"},
+			}}},
+		},
+		"alerts": {
+			change: func(old, new *darksky.Forecast) {
+				new.Alerts = []darksky.Alert{{Title: "Flood Watch", Time: 1576600000}}
+			},
+			want: darksky.ForecastDiff{
+				AlertsAdded:   []darksky.Alert{{Title: "Flood Watch", Time: 1576600000}},
+				AlertsRemoved: fixture.Alerts,
+			},
+		},
+		"rain earlier": {
+			change: func(old, new *darksky.Forecast) {
+				new.Hourly.Data = new.Hourly.Data[2:]
+				old.Hourly.Data[0].PrecipProbability = 0.8 // before the new forecast
+				old.Hourly.Data[0].PrecipIntensity = 0.1
+				rain(&old.Hourly.Data[8])
+				old.Hourly.Data[3].PrecipType, new.Hourly.Data[1].PrecipType = "", ""
+				new.Hourly.Data[1].PrecipProbability = 0.8
+				new.Hourly.Data[1].PrecipIntensity = 0.0017
+			},
+			want: darksky.ForecastDiff{
+				Hourly: []darksky.PointDiff{
+					{Time: hour(3), Fields: []darksky.FieldChange{{Field: "precipProbability", Old: 0, New: 0.8, Delta: 0.8}}},
+					{Time: hour(8), Fields: []darksky.FieldChange{
+						{Field: "precipIntensity", Old: 0.1, New: 0, Delta: -0.1},
+						{Field: "precipProbability", Old: 0.8, New: 0, Delta: -0.8},
+					}, PrecipType: &darksky.TextChange{Old: "rain", New: ""}},
+				},
+				PrecipOnset: &darksky.OnsetChange{Old: hour(8), New: hour(3), PrecipType: "rain"},
+			},
+		},
+		"rain no longer expected": {
+			change: func(old, new *darksky.Forecast) {
+				rain(&old.Hourly.Data[4])
+				rain(&new.Hourly.Data[4])
+				new.Hourly.Data[4].PrecipProbability = 0.25
+			},
+			want: darksky.ForecastDiff{
+				Hourly: []darksky.PointDiff{{
+					Time:   hour(4),
+					Fields: []darksky.FieldChange{{Field: "precipProbability", Old: 0.8, New: 0.25, Delta: -0.55}},
+				}},
+				PrecipOnset: &darksky.OnsetChange{Old: hour(4), PrecipType: "rain"},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			old := loadForecast(t, "SouthernTerminus.json")
+			new := loadForecast(t, "SouthernTerminus.json")
+			tc.change(old, new)
+			got := darksky.Diff(old, new, darksky.Thresholds{})
+			tc.want.Timezone = fixture.Timezone
+			if !reflect.DeepEqual(*got, tc.want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(tc.want)
+				t.Errorf("Diff() = %s; want %s", gotJSON, wantJSON)
+			}
+			if got.Empty() != (name == "unchanged" || name == "later fetch" || name == "below threshold") {
+				t.Errorf("Empty() = %v", got.Empty())
+			}
+		})
+	}
+}
+
+func TestDiff_thresholds(t *testing.T) {
+	old := loadForecast(t, "SouthernTerminus.json")
+	new := loadForecast(t, "SouthernTerminus.json")
+	new.Hourly.Data[0].Temperature += 1
+	new.Hourly.Data[0].Humidity += 0.5
+
+	d := darksky.Diff(old, new, darksky.Thresholds{Fields: map[string]float64{"temperature": 1}})
+	if len(d.Hourly) != 1 || len(d.Hourly[0].Fields) != 1 || d.Hourly[0].Fields[0].Field != "temperature" {
+		t.Errorf("Hourly = %+v; want only the temperature change", d.Hourly)
+	}
+}
+
+func TestForecastDiff_String(t *testing.T) {
+	old := loadForecast(t, "SouthernTerminus.json")
+	new := loadForecast(t, "SouthernTerminus.json")
+	// 1 PM and 4 PM on Tuesday, 17 December 2019 in Los Angeles.
+	old.Hourly.Data[6].PrecipProbability, old.Hourly.Data[6].PrecipIntensity = 0.9, 0.2
+	new.Hourly.Data[3].PrecipProbability, new.Hourly.Data[3].PrecipIntensity = 0.9, 0.2
+	new.Hourly.Data[6] = old.Hourly.Data[6]
+	new.Hourly.Data[3].Icon = "rain"
+	new.Alerts = nil
+
+	got := darksky.Diff(old, new, darksky.Thresholds{}).String()
+	want := []string{
+		"Rain now expected around 1 PM instead of 4 PM",
+		"Alert ended: High Wind Warning",
+		"Tue 1 PM: precipIntensity 0 to 0.2 (+0.2), precipProbability 0 to 0.9 (+0.9), icon wind to rain",
+	}
+	if got != strings.Join(want, "\n") {
+		t.Errorf("String() = %q; want %q", got, strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,142 @@
+package darksky
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Doer sends HTTP requests. *http.Client is a Doer.
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to Doer.
+type DoerFunc func(*http.Request) (*http.Response, error)
+
+// Do calls f.
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps a Doer to change the requests it sends or the responses
+// it returns.
+type Middleware func(Doer) Doer
+
+// Chain wraps d in the middlewares. The first middleware sees each request
+// first and each response last.
+func Chain(d Doer, middlewares ...Middleware) Doer {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		d = middlewares[i](d)
+	}
+	return d
+}
+
+// UserAgent sets the User-Agent header of requests that have none.
+func UserAgent(ua string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("User-Agent") == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set("User-Agent", ua)
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// RequestIDHeader is the header RequestID sets.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID sets the X-Request-ID header of requests that have none to an ID
+// from newID, or to 16 random hex digits if newID is nil.
+func RequestID(newID func() string) Middleware {
+	if newID == nil {
+		newID = func() string {
+			b := make([]byte, 8)
+			rand.Read(b)
+			return hex.EncodeToString(b)
+		}
+	}
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(RequestIDHeader) == "" {
+				req = req.Clone(req.Context())
+				req.Header.Set(RequestIDHeader, newID())
+			}
+			return next.Do(req)
+		})
+	}
+}
+
+// Gzip asks for gzipped responses and decompresses them. http.Transport does
+// this on its own, but not for Doers that don't use one, nor for requests
+// that already set Accept-Encoding.
+func Gzip() Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("Accept-Encoding", "gzip")
+			res, err := next.Do(req)
+			if err != nil || !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+				return res, err
+			}
+			zr, err := gzip.NewReader(res.Body)
+			if err != nil {
+				res.Body.Close()
+				return nil, err
+			}
+			res.Body = &gzipBody{zr: zr, body: res.Body}
+			res.Header.Del("Content-Encoding")
+			res.Header.Del("Content-Length")
+			res.ContentLength = -1
+			res.Uncompressed = true
+			return res, nil
+		})
+	}
+}
+
+type gzipBody struct {
+	zr   *gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) { return b.zr.Read(p) }
+
+func (b *gzipBody) Close() error {
+	b.zr.Close()
+	return b.body.Close()
+}
+
+// Timeout limits each request, including reading its response body, to d.
+func Timeout(d time.Duration) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			ctx, cancel := context.WithTimeout(req.Context(), d)
+			res, err := next.Do(req.WithContext(ctx))
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+			return res, nil
+		})
+	}
+}
+
+// cancelBody cancels the context of its request once closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,137 @@
+package darksky_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	mw := func(name string) darksky.Middleware {
+		return func(next darksky.Doer) darksky.Doer {
+			return darksky.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" in")
+				res, err := next.Do(req)
+				order = append(order, name+" out")
+				return res, err
+			})
+		}
+	}
+	d := darksky.Chain(darksky.DoerFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "doer")
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}), mw("a"), mw("b"))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if _, err := d.Do(req); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if got, want := strings.Join(order, ", "), "a in, b in, doer, b out, a out"; got != want {
+		t.Errorf("order = %s; want %s", got, want)
+	}
+}
+
+func TestMiddlewares(t *testing.T) {
+	var mu sync.Mutex
+	var headers http.Header
+	header := func(name string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return headers.Get(name)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = r.Header.Clone()
+		mu.Unlock()
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		json.NewEncoder(zw).Encode(map[string]string{"hello": "world"})
+		zw.Close()
+	}))
+	defer server.Close()
+
+	// A bare transport leaves decompression to the Gzip middleware.
+	d := darksky.Chain(&http.Client{Transport: &http.Transport{DisableCompression: true}},
+		darksky.UserAgent("darksky-client/test"),
+		darksky.RequestID(func() string { return "req-1" }),
+		darksky.Gzip(),
+		darksky.Timeout(50*time.Millisecond),
+	)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/forecast", nil)
+	res, err := d.Do(req)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if got := strings.TrimSpace(string(body)); got != `{"hello":"world"}` {
+		t.Errorf("body = %q; want the decompressed JSON", got)
+	}
+	if got := header("User-Agent"); got != "darksky-client/test" {
+		t.Errorf("User-Agent = %q; want darksky-client/test", got)
+	}
+	if got := header(darksky.RequestIDHeader); got != "req-1" {
+		t.Errorf("%s = %q; want req-1", darksky.RequestIDHeader, got)
+	}
+	if got := header("Accept-Encoding"); got != "gzip" {
+		t.Errorf("Accept-Encoding = %q; want gzip", got)
+	}
+	if req.Header.Get("User-Agent") != "" {
+		t.Errorf("the caller's request was modified")
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/slow", nil)
+	req.Header.Set(darksky.RequestIDHeader, "mine")
+	start := time.Now()
+	if _, err := d.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("request took %v; want the timeout", elapsed)
+	}
+	if got := header(darksky.RequestIDHeader); got != "mine" {
+		t.Errorf("%s = %q; want the caller's", darksky.RequestIDHeader, got)
+	}
+}
+
+func TestClient_HttpClientChain(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	var ua string
+	c := &darksky.Client{
+		Key: "key",
+		HttpClient: darksky.Chain(darksky.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			ua = req.Header.Get("User-Agent")
+			rec := httptest.NewRecorder()
+			json.NewEncoder(rec).Encode(fc)
+			return rec.Result(), nil
+		}), darksky.UserAgent("darksky-client/test")),
+	}
+	got, err := c.Forecast(stLat, stLong)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if got.Currently.Temperature != fc.Currently.Temperature || ua != "darksky-client/test" {
+		t.Errorf("Temperature, User-Agent = %v, %q; want %v, darksky-client/test", got.Currently.Temperature, ua, fc.Currently.Temperature)
+	}
+}
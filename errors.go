@@ -10,6 +10,40 @@ var (
 	// ErrUnableToLoadTimezone is returned when the timezone information
 	// cannot be loaded or parsed from the Forecast
 	ErrUnableToLoadTimezone = errors.New("Unable to Load Timezone Data")
+
+	// ErrEmptyRoute is returned when a route has no points
+	ErrEmptyRoute = errors.New("Empty Route")
+
+	// ErrOutOfRange is returned when conditions are requested for a time
+	// the forecast does not cover
+	ErrOutOfRange = errors.New("Time Outside Forecast Range")
+
+	// ErrInvalidPolyline is returned when an encoded polyline is malformed
+	ErrInvalidPolyline = errors.New("Invalid Encoded Polyline")
+
+	// ErrOutsideGrid is returned when a grid is interpolated at a point
+	// it does not cover
+	ErrOutsideGrid = errors.New("Point Outside Grid")
+
+	// ErrImplausibleForecast is returned when a source of a Composite
+	// returns a forecast that fails its check
+	ErrImplausibleForecast = errors.New("Implausible Forecast")
+
+	// ErrNoHealthySources is returned when every source of a Composite is
+	// being skipped after failing
+	ErrNoHealthySources = errors.New("No Healthy Sources")
+
+	// ErrCircuitOpen is returned without making a request while the
+	// circuit breaker of a Client is open
+	ErrCircuitOpen = errors.New("Circuit Breaker Open")
+
+	// ErrResponseTooLarge is returned when a response body is larger than
+	// the MaxBodySize of a Client
+	ErrResponseTooLarge = errors.New("Response Body Too Large")
+
+	// ErrTrailingData is returned when a response body holds more than
+	// the JSON document that was expected
+	ErrTrailingData = errors.New("Trailing Data in Response Body")
 )
 
 // type Error struct {
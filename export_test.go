@@ -0,0 +1,16 @@
+package darksky
+
+import "strings"
+
+// UnregisterCatalog removes the catalog registered for lang, so that tests
+// registering catalogs can restore the registry.
+func UnregisterCatalog(lang string) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	delete(catalogs, strings.ToLower(lang))
+}
+
+// Check returns the hazards of the conditions.
+func (h *HazardThresholds) Check(p HourlyDataPoint) []Hazard {
+	return h.check(p)
+}
@@ -4,101 +4,137 @@ import (
 	"time"
 )
 
+// Forecast is the response of the forecast and Time Machine endpoints.
 type Forecast struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Timezone  string  `json:"timezone"`
-	Currently struct {
-		Time                 int     `json:"time"`
-		Summary              string  `json:"summary"`
-		Icon                 string  `json:"icon"`
-		NearestStormDistance int     `json:"nearestStormDistance"`
-		PrecipIntensity      float64 `json:"precipIntensity"`
-		PrecipIntensityError float64 `json:"precipIntensityError"`
-		PrecipProbability    float64 `json:"precipProbability"`
-		PrecipType           string  `json:"precipType"`
-		Temperature          float64 `json:"temperature"`
-		ApparentTemperature  float64 `json:"apparentTemperature"` // "feels like temp in Fahrenheit"
-		DewPoint             float64 `json:"dewPoint"`
-		Humidity             float64 `json:"humidity"`
-		Pressure             float64 `json:"pressure"`
-		WindSpeed            float64 `json:"windSpeed"`
-		WindGust             float64 `json:"windGust"`
-		WindBearing          int     `json:"windBearing"`
-		CloudCover           float64 `json:"cloudCover"`
-	} `json:"currently"`
-	Minutely struct {
-		Summary string `json:"summary"`
-		Icon    string `json:"icon"`
-	} `json:"minutely"`
-	Hourly struct {
-		Summary string `json:"summary"`
-		Icon    string `json:"icon"`
-		Data    []struct {
-			Time                int     `json:"time"`
-			Summary             string  `json:"summary"`
-			Icon                string  `json:"icon"`
-			PrecipIntensity     float64 `json:"precipIntensity"`
-			PrecipProbability   float64 `json:"precipProbability"`
-			PrecipType          string  `json:"precipType"`
-			Temperature         float64 `json:"temperature"`
-			ApparentTemperature float64 `json:"apparentTemperature"`
-			DewPoint            float64 `json:"dewPoint"`
-			Humidity            float64 `json:"humidity"`
-			Pressure            float64 `json:"pressure"`
-			WindSpeed           float64 `json:"windSpeed"`
-			WindGust            float64 `json:"windGust"`
-			WindBearing         int     `json:"windBearing"`
-			CloudCover          float64 `json:"cloudCover"`
-			UvIndex             int     `json:"uvIndex"`
-			Visibility          float64 `json:"visibility"`
-			Ozone               float64 `json:"ozone"`
-		} `json:"data"`
-	} `json:"hourly"`
-	Daily struct {
-		Summary string `json:"summary"`
-		Icon    string `json:"icon"`
-		Data    []struct {
-			Time                        int     `json:"time"`
-			Summary                     string  `json:"summary"`
-			Icon                        string  `json:"icon"`
-			SunriseTime                 int     `json:"sunriseTime"`
-			SunsetTime                  int     `json:"sunsetTime"`
-			MoonPhase                   float64 `json:"moonPhase"`
-			PrecipProbability           float64 `json:"precipProbability"`
-			PrecipType                  string  `json:"precipType"`
-			TemperatureHigh             float64 `json:"temperatureHigh"`
-			TemperatureHighTime         int     `json:"temperatureHighTime"`
-			TemperatureLow              float64 `json:"temperatureLow"`
-			TemperatureLowTime          int     `json:"temperatureLowTime"`
-			ApparentTemperatureHigh     float64 `json:"apparentTemperatureHigh"`
-			ApparentTemperatureHighTime int     `json:"apparentTemperatureHighTime"`
-			ApparentTemperatureLow      float64 `json:"apparentTemperatureLow"`
-			ApparentTemperatureLowTime  int     `json:"apparentTemperatureLowTime"`
-			DewPoint                    float64 `json:"dewPoint"`
-			Humidity                    float64 `json:"humidity"`
-			Pressure                    float64 `json:"pressure"`
-			WindSpeed                   float64 `json:"windSpeed"`
-			WindGust                    float64 `json:"windGust"`
-			CloudCover                  float64 `json:"cloudCover"`
-			UvIndex                     int     `json:"uvIndex"`
-			TemperatureMin              float64 `json:"temperatureMin"`
-			TemperatureMinTime          int     `json:"temperatureMinTime"`
-			TemperatureMax              float64 `json:"temperatureMax"`
-			TemperatureMaxTime          int     `json:"temperatureMaxTime"`
-			ApparentTemperatureMin      float64 `json:"apparentTemperatureMin"`
-			ApparentTemperatureMinTime  int     `json:"apparentTemperatureMinTime"`
-			ApparentTemperatureMax      float64 `json:"apparentTemperatureMax"`
-			ApparentTemperatureMaxTime  int     `json:"apparentTemperatureMaxTime"`
-		} `json:"data"`
-	} `json:"daily"`
-	Alerts []struct {
-		Title       string `json:"title"`
-		Time        int    `json:"time"`
-		Expires     int    `json:"expires"`
-		Description string `json:"description"`
-		URI         string `json:"uri"`
-	} `json:"alerts"`
+	Latitude  float64           `json:"latitude"`
+	Longitude float64           `json:"longitude"`
+	Timezone  string            `json:"timezone"`
+	Currently CurrentDataPoint  `json:"currently"`
+	Minutely  MinutelyDataBlock `json:"minutely"`
+	Hourly    HourlyDataBlock   `json:"hourly"`
+	Daily     DailyDataBlock    `json:"daily"`
+	Alerts    []Alert           `json:"alerts"`
+}
+
+// CurrentDataPoint holds the conditions at the time of the request.
+type CurrentDataPoint struct {
+	Time                 int     `json:"time"`
+	Summary              string  `json:"summary"`
+	Icon                 string  `json:"icon"`
+	NearestStormDistance int     `json:"nearestStormDistance"`
+	PrecipIntensity      float64 `json:"precipIntensity"`
+	PrecipIntensityError float64 `json:"precipIntensityError"`
+	PrecipProbability    float64 `json:"precipProbability"`
+	PrecipType           string  `json:"precipType"`
+	Temperature          float64 `json:"temperature"`
+	ApparentTemperature  float64 `json:"apparentTemperature"` // "feels like temp in Fahrenheit"
+	DewPoint             float64 `json:"dewPoint"`
+	Humidity             float64 `json:"humidity"`
+	Pressure             float64 `json:"pressure"`
+	WindSpeed            float64 `json:"windSpeed"`
+	WindGust             float64 `json:"windGust"`
+	WindBearing          int     `json:"windBearing"`
+	CloudCover           float64 `json:"cloudCover"`
+	UvIndex              int     `json:"uvIndex"`
+	Visibility           float64 `json:"visibility"`
+	Ozone                float64 `json:"ozone"`
+}
+
+// MinutelyDataBlock holds the precipitation minute by minute for the next
+// hour.
+type MinutelyDataBlock struct {
+	Summary string              `json:"summary"`
+	Icon    string              `json:"icon"`
+	Data    []MinutelyDataPoint `json:"data"`
+}
+
+// MinutelyDataPoint holds the precipitation during a minute.
+type MinutelyDataPoint struct {
+	Time                 int     `json:"time"`
+	PrecipIntensity      float64 `json:"precipIntensity"`
+	PrecipIntensityError float64 `json:"precipIntensityError"`
+	PrecipProbability    float64 `json:"precipProbability"`
+	PrecipType           string  `json:"precipType"`
+}
+
+// HourlyDataBlock holds the conditions hour by hour.
+type HourlyDataBlock struct {
+	Summary string            `json:"summary"`
+	Icon    string            `json:"icon"`
+	Data    []HourlyDataPoint `json:"data"`
+}
+
+// HourlyDataPoint holds the conditions during an hour.
+type HourlyDataPoint struct {
+	Time                int     `json:"time"`
+	Summary             string  `json:"summary"`
+	Icon                string  `json:"icon"`
+	PrecipIntensity     float64 `json:"precipIntensity"`
+	PrecipProbability   float64 `json:"precipProbability"`
+	PrecipType          string  `json:"precipType"`
+	Temperature         float64 `json:"temperature"`
+	ApparentTemperature float64 `json:"apparentTemperature"`
+	DewPoint            float64 `json:"dewPoint"`
+	Humidity            float64 `json:"humidity"`
+	Pressure            float64 `json:"pressure"`
+	WindSpeed           float64 `json:"windSpeed"`
+	WindGust            float64 `json:"windGust"`
+	WindBearing         int     `json:"windBearing"`
+	CloudCover          float64 `json:"cloudCover"`
+	UvIndex             int     `json:"uvIndex"`
+	Visibility          float64 `json:"visibility"`
+	Ozone               float64 `json:"ozone"`
+}
+
+// DailyDataBlock holds the conditions day by day.
+type DailyDataBlock struct {
+	Summary string           `json:"summary"`
+	Icon    string           `json:"icon"`
+	Data    []DailyDataPoint `json:"data"`
+}
+
+// DailyDataPoint holds the conditions during a day.
+type DailyDataPoint struct {
+	Time                        int     `json:"time"`
+	Summary                     string  `json:"summary"`
+	Icon                        string  `json:"icon"`
+	SunriseTime                 int     `json:"sunriseTime"`
+	SunsetTime                  int     `json:"sunsetTime"`
+	MoonPhase                   float64 `json:"moonPhase"`
+	PrecipProbability           float64 `json:"precipProbability"`
+	PrecipType                  string  `json:"precipType"`
+	TemperatureHigh             float64 `json:"temperatureHigh"`
+	TemperatureHighTime         int     `json:"temperatureHighTime"`
+	TemperatureLow              float64 `json:"temperatureLow"`
+	TemperatureLowTime          int     `json:"temperatureLowTime"`
+	ApparentTemperatureHigh     float64 `json:"apparentTemperatureHigh"`
+	ApparentTemperatureHighTime int     `json:"apparentTemperatureHighTime"`
+	ApparentTemperatureLow      float64 `json:"apparentTemperatureLow"`
+	ApparentTemperatureLowTime  int     `json:"apparentTemperatureLowTime"`
+	DewPoint                    float64 `json:"dewPoint"`
+	Humidity                    float64 `json:"humidity"`
+	Pressure                    float64 `json:"pressure"`
+	WindSpeed                   float64 `json:"windSpeed"`
+	WindGust                    float64 `json:"windGust"`
+	CloudCover                  float64 `json:"cloudCover"`
+	UvIndex                     int     `json:"uvIndex"`
+	TemperatureMin              float64 `json:"temperatureMin"`
+	TemperatureMinTime          int     `json:"temperatureMinTime"`
+	TemperatureMax              float64 `json:"temperatureMax"`
+	TemperatureMaxTime          int     `json:"temperatureMaxTime"`
+	ApparentTemperatureMin      float64 `json:"apparentTemperatureMin"`
+	ApparentTemperatureMinTime  int     `json:"apparentTemperatureMinTime"`
+	ApparentTemperatureMax      float64 `json:"apparentTemperatureMax"`
+	ApparentTemperatureMaxTime  int     `json:"apparentTemperatureMaxTime"`
+}
+
+// Alert is a severe weather warning issued by a governmental authority.
+type Alert struct {
+	Title       string `json:"title"`
+	Time        int    `json:"time"`
+	Expires     int    `json:"expires"`
+	Description string `json:"description"`
+	URI         string `json:"uri"`
 }
 
 type ForecastService struct {
@@ -1,6 +1,8 @@
 package darksky_test
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"testing"
 
 	darksky "github.com/sophiaehlen/darksky-client"
@@ -11,6 +13,20 @@ var (
 	stLong = -116.466988
 )
 
+// loadForecast decodes the forecast stored in the JSON file at path.
+func loadForecast(t *testing.T, path string) *darksky.Forecast {
+	jsonBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the forecast file: %s. err = %v", path, err)
+	}
+	var fc darksky.Forecast
+	err = json.Unmarshal(jsonBytes, &fc)
+	if err != nil {
+		t.Fatalf("failed to json unmarshal the forecast file: %s. err = %v", path, err)
+	}
+	return &fc
+}
+
 func TestForecast_CurrentTemperature(t *testing.T) {
 	if apiKey == "" {
 		t.Log("No API key provided. Running unit tests using recorded responses. Be sure to run against the real API before commiting.")
@@ -0,0 +1,217 @@
+//go:build ignore
+
+// gen regenerates cities.tsv from a GeoNames cities dump, keeping the
+// places of the current table that are too small to be in the dump and
+// the alternate names it adds to the others.
+//
+// Usage:
+//
+//	go run gen.go [-src cities15000.zip] [-min 15000]
+//
+// The source may be a URL or a file, zipped or not. GeoNames data is
+// licensed under CC BY 4.0.
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// admin1 maps GeoNames' numeric first-level division codes to the postal
+// abbreviations people qualify names with. US codes already are those.
+var admin1 = map[string]map[string]string{
+	"CA": {
+		"01": "AB", "02": "BC", "03": "MB", "04": "NB", "05": "NL", "07": "NS",
+		"08": "ON", "09": "PE", "10": "QC", "11": "SK", "12": "YT", "13": "NT", "14": "NU",
+	},
+	"AU": {
+		"01": "ACT", "02": "NSW", "03": "NT", "04": "QLD", "05": "SA", "06": "TAS",
+		"07": "VIC", "08": "WA",
+	},
+}
+
+type row struct {
+	name, country, admin1 string
+	lat, long             string
+	population            int
+	alt                   []string
+}
+
+func (r row) key() string {
+	return strings.ToLower(r.name) + "\t" + r.country + "\t" + r.admin1
+}
+
+func main() {
+	src := flag.String("src", "https://download.geonames.org/export/dump/cities15000.zip", "The GeoNames cities dump, as a URL or a file.")
+	min := flag.Int("min", 15000, "The smallest population taken from the dump.")
+	out := flag.String("out", "cities.tsv", "The table to update.")
+	flag.Parse()
+
+	current, err := readTable(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := fetch(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rows, err := readGeoNames(data, *min)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	seen := make(map[string]int, len(rows))
+	for i, r := range rows {
+		seen[r.key()] = i
+	}
+	for _, r := range current {
+		if i, ok := seen[r.key()]; ok {
+			rows[i].alt = merge(rows[i].alt, r.alt)
+			continue
+		}
+		if r.population < *min {
+			rows = append(rows, r)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].population > rows[j].population })
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# name\tcountry\tadmin1\tlat\tlong\tpopulation\talternate names")
+	fmt.Fprintf(&b, "# Generated by gen.go from GeoNames (CC BY 4.0) places of at least %d\n", *min)
+	fmt.Fprintln(&b, "# people, plus smaller places near the Pacific Crest Trail and elsewhere.")
+	for _, r := range rows {
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\t%d", r.name, r.country, r.admin1, r.lat, r.long, r.population)
+		if len(r.alt) > 0 {
+			fmt.Fprintf(&b, "\t%s", strings.Join(r.alt, ","))
+		}
+		b.WriteByte('\n')
+	}
+	if err := ioutil.WriteFile(*out, b.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d places to %s", len(rows), *out)
+}
+
+func fetch(src string) ([]byte, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		res, err := http.Get(src)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", src, res.Status)
+		}
+		data, err = ioutil.ReadAll(res.Body)
+	} else {
+		data, err = ioutil.ReadFile(src)
+	}
+	if err != nil || !strings.HasSuffix(src, ".zip") {
+		return data, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zr.File {
+		if strings.HasSuffix(f.Name, ".txt") {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return ioutil.ReadAll(rc)
+		}
+	}
+	return nil, fmt.Errorf("%s holds no .txt file", src)
+}
+
+// readGeoNames reads the places of at least min people from a dump in the
+// GeoNames main table format.
+func readGeoNames(data []byte, min int) ([]row, error) {
+	var rows []row
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		f := strings.Split(scanner.Text(), "\t")
+		if len(f) < 15 {
+			continue
+		}
+		pop, err := strconv.Atoi(f[14])
+		if err != nil || pop < min {
+			continue
+		}
+		r := row{name: f[1], country: f[8], lat: f[4], long: f[5], population: pop}
+		switch {
+		case r.country == "US":
+			r.admin1 = f[10]
+		case admin1[r.country] != nil:
+			r.admin1 = admin1[r.country][f[10]]
+		}
+		if f[2] != "" && f[2] != f[1] {
+			r.alt = []string{f[2]}
+		}
+		rows = append(rows, r)
+	}
+	return rows, scanner.Err()
+}
+
+// readTable reads the rows of the current table.
+func readTable(path string) ([]row, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rows []row
+	scanner := bufio.NewScanner(io.Reader(f))
+	for scanner.Scan() {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 6 {
+			continue
+		}
+		pop, _ := strconv.Atoi(fields[5])
+		r := row{name: fields[0], country: fields[1], admin1: fields[2], lat: fields[3], long: fields[4], population: pop}
+		if len(fields) > 6 && fields[6] != "" {
+			r.alt = strings.Split(fields[6], ",")
+		}
+		rows = append(rows, r)
+	}
+	return rows, scanner.Err()
+}
+
+func merge(a, b []string) []string {
+	for _, s := range b {
+		found := false
+		for _, t := range a {
+			if strings.EqualFold(s, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			a = append(a, s)
+		}
+	}
+	return a
+}
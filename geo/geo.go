@@ -0,0 +1,366 @@
+// Package geo resolves place names to coordinates and coordinates to the
+// nearest named place without network access, using a compact gazetteer
+// embedded in the package.
+package geo
+
+//go:generate go run gen.go
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// EarthRadius is the mean radius of the Earth in kilometres.
+const EarthRadius = 6371.0
+
+var (
+	// ErrNotFound is returned when no place matches a query.
+	ErrNotFound = errors.New("Place Not Found")
+
+	//go:embed cities.tsv
+	embedded string
+
+	defaultOnce sync.Once
+	defaultGaz  *Gazetteer
+)
+
+// Place is a named location in a gazetteer.
+type Place struct {
+	Name       string
+	Country    string // ISO 3166-1 alpha-2 code, e.g. US
+	Admin1     string // first-level division code, e.g. CA for California
+	Lat        float64
+	Long       float64
+	Population int
+	AltNames   []string
+}
+
+// String returns the place as "Name, Admin1, Country".
+func (p Place) String() string {
+	parts := []string{p.Name}
+	if p.Admin1 != "" {
+		parts = append(parts, p.Admin1)
+	}
+	return strings.Join(append(parts, p.Country), ", ")
+}
+
+// Gazetteer is a searchable set of places.
+type Gazetteer struct {
+	places []Place
+	names  [][]string // normalised names of each place, primary first
+}
+
+// Default returns the embedded gazetteer: major cities around the world,
+// cities of the United States, Canada and Australia with their state or
+// province, and small towns along the Pacific Crest Trail.
+func Default() *Gazetteer {
+	defaultOnce.Do(func() {
+		g, err := Load(strings.NewReader(embedded))
+		if err != nil {
+			panic(fmt.Sprintf("geo: embedded gazetteer is invalid: %v", err))
+		}
+		defaultGaz = g
+	})
+	return defaultGaz
+}
+
+// Load reads a gazetteer in the format of the embedded one: one place per
+// line with tab-separated name, country code, admin1 code, latitude,
+// longitude, population and optional comma-separated alternate names. Blank
+// lines and lines starting with # are ignored.
+func Load(r io.Reader) (*Gazetteer, error) {
+	g := &Gazetteer{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("line %d: want at least 6 tab-separated fields, got %d", line, len(fields))
+		}
+		lat, err := strconv.ParseFloat(fields[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude: %v", line, err)
+		}
+		long, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude: %v", line, err)
+		}
+		pop, err := strconv.Atoi(fields[5])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid population: %v", line, err)
+		}
+		p := Place{
+			Name:       fields[0],
+			Country:    fields[1],
+			Admin1:     fields[2],
+			Lat:        lat,
+			Long:       long,
+			Population: pop,
+		}
+		if len(fields) > 6 && fields[6] != "" {
+			p.AltNames = strings.Split(fields[6], ",")
+		}
+		g.add(p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *Gazetteer) add(p Place) {
+	names := []string{normalize(p.Name)}
+	for _, alt := range p.AltNames {
+		names = append(names, normalize(alt))
+	}
+	g.places = append(g.places, p)
+	g.names = append(g.names, names)
+}
+
+// Len returns the number of places in the gazetteer.
+func (g *Gazetteer) Len() int {
+	return len(g.places)
+}
+
+// Filter restricts the places a search may return.
+type Filter struct {
+	Country string // if set, only places in this country
+	Admin1  string // if set, only places in this first-level division
+	Limit   int    // maximum number of matches; zero means no limit
+}
+
+// Match is a place found by a search. Distance is the edit distance between
+// the query and the closest of the place's names; zero is an exact match
+// and one also covers names that start with the query's words.
+type Match struct {
+	Place    Place
+	Distance int
+}
+
+// Search returns the places whose names match name, best first: closer
+// names before more distant ones, then more populous places before smaller
+// ones. A name matches if it equals the query or starts with its words, so
+// that "Jacumba" finds "Jacumba Hot Springs" but "San" doesn't find
+// "Santiago". Only if no name matches that way are typos of up to about
+// one in four letters tolerated.
+func (g *Gazetteer) Search(name string, f Filter) []Match {
+	hits, _ := g.search(name, func(p Place) bool {
+		return (f.Country == "" || strings.EqualFold(p.Country, f.Country)) &&
+			(f.Admin1 == "" || strings.EqualFold(p.Admin1, f.Admin1))
+	})
+	if f.Limit > 0 && len(hits) > f.Limit {
+		hits = hits[:f.Limit]
+	}
+	matches := make([]Match, len(hits))
+	for i, h := range hits {
+		matches[i] = h.Match
+	}
+	return matches
+}
+
+// hit is a match and the normalised name it matched by.
+type hit struct {
+	Match
+	name string
+}
+
+// search returns the matches for name among the places kept, best first.
+// If they are typos rather than exact or whole-word matches, fuzzyMax is
+// the highest edit distance that was tolerated; otherwise it is zero.
+func (g *Gazetteer) search(name string, keep func(Place) bool) (hits []hit, fuzzyMax int) {
+	query := normalize(name)
+	if query == "" {
+		return nil, 0
+	}
+	maxDist := len([]rune(query)) / 4
+	if maxDist < 1 {
+		maxDist = 1
+	}
+	var fuzzy []hit
+	for i, p := range g.places {
+		if !keep(p) {
+			continue
+		}
+		best, bestFuzzy := hit{Match: Match{Place: p, Distance: -1}}, hit{Match: Match{Place: p, Distance: -1}}
+		for _, n := range g.names[i] {
+			switch {
+			case n == query:
+				best.Distance, best.name = 0, n
+			case strings.HasPrefix(n, query+" "):
+				if best.Distance < 0 {
+					best.Distance, best.name = 1, n
+				}
+			default:
+				if d := levenshtein(query, n); bestFuzzy.Distance < 0 || d < bestFuzzy.Distance {
+					bestFuzzy.Distance, bestFuzzy.name = d, n
+				}
+			}
+		}
+		if best.Distance >= 0 {
+			hits = append(hits, best)
+		} else if bestFuzzy.Distance >= 0 && bestFuzzy.Distance <= maxDist {
+			fuzzy = append(fuzzy, bestFuzzy)
+		}
+	}
+	if len(hits) == 0 && len(fuzzy) > 0 {
+		hits, fuzzyMax = fuzzy, maxDist
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Distance != hits[j].Distance {
+			return hits[i].Distance < hits[j].Distance
+		}
+		return hits[i].Place.Population > hits[j].Place.Population
+	})
+	return hits, fuzzyMax
+}
+
+// Lookup returns the best match for a query such as "Jacumba, CA",
+// "Portland, ME" or "Paris, FR". Anything after the first comma qualifies
+// the name: each qualifier must equal the place's admin1 or country code.
+//
+// Lookup is stricter about typos than Search, since a guess would go
+// unnoticed: a misspelt name must be within half the tolerated distance of
+// a single name in the gazetteer, so that "Reading", which is missing,
+// doesn't resolve to Redding.
+func (g *Gazetteer) Lookup(query string) (Place, error) {
+	parts := strings.Split(query, ",")
+	name := parts[0]
+	var qualifiers []string
+	for _, q := range parts[1:] {
+		if q = strings.TrimSpace(q); q != "" {
+			qualifiers = append(qualifiers, q)
+		}
+	}
+	hits, fuzzyMax := g.search(name, func(p Place) bool { return qualifies(p, qualifiers) })
+	if len(hits) == 0 {
+		return Place{}, ErrNotFound
+	}
+	if fuzzyMax > 0 {
+		if hits[0].Distance > fuzzyMax/2 {
+			return Place{}, ErrNotFound
+		}
+		for _, h := range hits[1:] {
+			if h.Distance == hits[0].Distance && h.name != hits[0].name {
+				return Place{}, ErrNotFound
+			}
+		}
+	}
+	return hits[0].Place, nil
+}
+
+func qualifies(p Place, qualifiers []string) bool {
+	for _, q := range qualifiers {
+		if !strings.EqualFold(q, p.Admin1) && !strings.EqualFold(q, p.Country) {
+			return false
+		}
+	}
+	return true
+}
+
+// Nearest returns the place closest to the coordinates and its distance in
+// kilometres. It returns ErrNotFound if the gazetteer is empty.
+func (g *Gazetteer) Nearest(lat, long float64) (Place, float64, error) {
+	if len(g.places) == 0 {
+		return Place{}, 0, ErrNotFound
+	}
+	best, bestDist := 0, math.Inf(1)
+	for i, p := range g.places {
+		if d := Distance(lat, long, p.Lat, p.Long); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return g.places[best], bestDist, nil
+}
+
+// Distance returns the great-circle distance in kilometres between two
+// points given in decimal degrees.
+func Distance(lat1, long1, lat2, long2 float64) float64 {
+	const rad = math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLong := (long2 - long1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// folds maps accented letters to their unaccented forms.
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y",
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ș': "s", 'ş': "s", 'ț': "t", 'ł': "l",
+	'ộ': "o",
+}
+
+// normalize lower-cases s, removes accents and punctuation and collapses
+// whitespace, so that "Saint-Étienne" and "saint etienne" compare equal.
+func normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if f, ok := folds[r]; ok {
+			b.WriteString(f)
+			space = false
+			continue
+		}
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case r == '.' || r == '\'':
+			// St. Louis and St Louis are the same place.
+		case !space && b.Len() > 0:
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,174 @@
+package geo_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sophiaehlen/darksky-client/geo"
+)
+
+func TestDefault(t *testing.T) {
+	if n := geo.Default().Len(); n < 250 {
+		t.Errorf("Len() = %d; want the embedded gazetteer to hold at least 250 places", n)
+	}
+}
+
+func TestGazetteer_Lookup(t *testing.T) {
+	tests := map[string]struct {
+		query   string
+		want    string
+		wantErr error
+	}{
+		"alternate name with state": {query: "Jacumba, CA", want: "Jacumba Hot Springs, CA, US"},
+		"prefix":                    {query: "Jacumba Hot", want: "Jacumba Hot Springs, CA, US"},
+		"state disambiguates":       {query: "Portland, ME", want: "Portland, ME, US"},
+		"largest without qualifier": {query: "Portland", want: "Portland, OR, US"},
+		"country qualifier":         {query: "Manchester, GB", want: "Manchester, GB"},
+		"state and country":         {query: "Birmingham, AL, US", want: "Birmingham, AL, US"},
+		"typo":                      {query: "San Fransisco", want: "San Francisco, CA, US"},
+		"accents folded":            {query: "zurich", want: "Zürich, CH"},
+		"accents in query":          {query: "Montréal", want: "Montreal, QC, CA"},
+		"abbreviation":              {query: "St Louis, MO", want: "St. Louis, MO, US"},
+		"case and spacing":          {query: "  new   YORK ", want: "New York City, NY, US"},
+		"common name":               {query: "Mesa", want: "Mesa, AZ, US"},
+		"largest of several":        {query: "Springfield", want: "Springfield, MO, US"},
+		"province":                  {query: "Laval", want: "Laval, QC, CA"},
+		"qualified smaller place":   {query: "Paris, TX", want: "Paris, TX, US"},
+		"qualified province":        {query: "London, ON", want: "London, ON, CA"},
+		"qualified country":         {query: "Dover, GB", want: "Dover, GB"},
+		"whole words first":         {query: "San", want: "San Antonio, TX, US"},
+		"unknown":                   {query: "Xyzzyville", wantErr: geo.ErrNotFound},
+		"missing near another name": {query: "Reading", wantErr: geo.ErrNotFound},
+		"wrong qualifier":           {query: "Jacumba, NY", wantErr: geo.ErrNotFound},
+		"empty":                     {query: "", wantErr: geo.ErrNotFound},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := geo.Default().Lookup(tc.query)
+			if err != tc.wantErr {
+				t.Fatalf("err = %v; want %v", err, tc.wantErr)
+			}
+			if tc.wantErr == nil && p.String() != tc.want {
+				t.Errorf("Lookup(%q) = %q; want %q", tc.query, p.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestGazetteer_LookupTypos(t *testing.T) {
+	g, err := geo.Load(strings.NewReader("Westfield\tUS\tMA\t42.13\t-72.75\t41000\n" +
+		"Eastfield\tGB\t\t54.25\t-0.41\t1000\n" +
+		"Fairbanks\tUS\tAK\t64.84\t-147.72\t32000\n" +
+		"Fairbanks\tUS\tME\t44.33\t-70.09\t600\n"))
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	tests := map[string]struct {
+		query   string
+		want    string
+		wantErr error
+	}{
+		"one name":           {query: "Fairbank", want: "Fairbanks, AK, US"},
+		"two names as close": {query: "Wastfield", wantErr: geo.ErrNotFound},
+		"qualified":          {query: "Wastfield, MA", want: "Westfield, MA, US"},
+		"too far":            {query: "Fayrbanx", wantErr: geo.ErrNotFound},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := g.Lookup(tc.query)
+			if err != tc.wantErr {
+				t.Fatalf("err = %v; want %v", err, tc.wantErr)
+			}
+			if tc.wantErr == nil && p.String() != tc.want {
+				t.Errorf("Lookup(%q) = %q; want %q", tc.query, p.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestGazetteer_Search(t *testing.T) {
+	matches := geo.Default().Search("San", geo.Filter{Country: "US", Admin1: "CA", Limit: 3})
+	if len(matches) != 3 {
+		t.Fatalf("len(matches) = %d; want 3", len(matches))
+	}
+	for i, m := range matches {
+		if m.Place.Country != "US" || m.Place.Admin1 != "CA" {
+			t.Errorf("matches[%d] = %v; want a place in CA, US", i, m.Place)
+		}
+		if i > 0 && m.Distance == matches[i-1].Distance && m.Place.Population > matches[i-1].Place.Population {
+			t.Errorf("matches[%d] is more populous than matches[%d] at the same distance", i, i-1)
+		}
+	}
+	if matches[0].Place.Name != "San Diego" {
+		t.Errorf("matches[0] = %v; want San Diego, the most populous close match", matches[0].Place)
+	}
+}
+
+func TestGazetteer_Nearest(t *testing.T) {
+	tests := map[string]struct {
+		lat, long float64
+		want      string
+		maxKm     float64
+	}{
+		"southern terminus":       {lat: 32.589720, long: -116.466988, want: "Campo", maxKm: 5},
+		"boston common":           {lat: 42.3550, long: -71.0656, want: "Boston", maxKm: 2},
+		"across the antimeridian": {lat: -18.0, long: -179.9, want: "Suva", maxKm: 200},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, km, err := geo.Default().Nearest(tc.lat, tc.long)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if p.Name != tc.want {
+				t.Errorf("Nearest() = %v; want %s", p, tc.want)
+			}
+			if km > tc.maxKm {
+				t.Errorf("distance = %f km; want at most %f", km, tc.maxKm)
+			}
+		})
+	}
+
+	empty, err := geo.Load(strings.NewReader("# nothing\n"))
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if _, _, err := empty.Nearest(0, 0); err != geo.ErrNotFound {
+		t.Errorf("err = %v; want %v", err, geo.ErrNotFound)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	g, err := geo.Load(strings.NewReader("# comment\n\nSouthern Terminus\tUS\tCA\t32.58972\t-116.466988\t0\tPCT Southern Terminus,Mile 0\n"))
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	p, err := g.Lookup("Mile 0")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if p.Lat != 32.58972 || p.Long != -116.466988 {
+		t.Errorf("Lookup() = %v; want the loaded coordinates", p)
+	}
+
+	for name, input := range map[string]string{
+		"too few fields":     "Nowhere\tUS\t\t1\n",
+		"invalid latitude":   "Nowhere\tUS\t\tnorth\t1\t0\n",
+		"invalid population": "Nowhere\tUS\t\t1\t1\tmany\n",
+	} {
+		if _, err := geo.Load(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: err = nil; want non-nil", name)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	// London to Paris is about 344 km.
+	if d := geo.Distance(51.5074, -0.1278, 48.8566, 2.3522); math.Abs(d-344) > 2 {
+		t.Errorf("Distance(London, Paris) = %f; want about 344", d)
+	}
+	if d := geo.Distance(10, 20, 10, 20); d != 0 {
+		t.Errorf("Distance to self = %f; want 0", d)
+	}
+}
@@ -0,0 +1,109 @@
+package darksky
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// FeatureCollection is a GeoJSON (RFC 7946) FeatureCollection of forecasts.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON Feature locating a single forecast.
+type Feature struct {
+	Type       string             `json:"type"`
+	Geometry   Point              `json:"geometry"`
+	Properties ForecastProperties `json:"properties"`
+}
+
+// Point is a GeoJSON Point geometry. Per RFC 7946 the coordinates are in
+// longitude, latitude order.
+type Point struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// ForecastProperties are the properties of a forecast Feature: the current
+// conditions plus a summary of each day of the forecast.
+type ForecastProperties struct {
+	Timezone            string         `json:"timezone"`
+	Time                int            `json:"time"`
+	Summary             string         `json:"summary"`
+	Icon                string         `json:"icon"`
+	Temperature         float64        `json:"temperature"`
+	ApparentTemperature float64        `json:"apparentTemperature"`
+	Humidity            float64        `json:"humidity"`
+	WindSpeed           float64        `json:"windSpeed"`
+	WindBearing         int            `json:"windBearing"`
+	PrecipProbability   float64        `json:"precipProbability"`
+	DailySummary        string         `json:"dailySummary"`
+	Daily               []DailySummary `json:"daily"`
+}
+
+// DailySummary is the condensed form of a day of the forecast used in
+// ForecastProperties.
+type DailySummary struct {
+	Time              int     `json:"time"`
+	Summary           string  `json:"summary"`
+	Icon              string  `json:"icon"`
+	TemperatureHigh   float64 `json:"temperatureHigh"`
+	TemperatureLow    float64 `json:"temperatureLow"`
+	PrecipProbability float64 `json:"precipProbability"`
+}
+
+// GeoJSON returns a FeatureCollection with a Point Feature for each of the
+// forecasts, in order.
+func GeoJSON(forecasts ...*Forecast) *FeatureCollection {
+	fcol := &FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]Feature, 0, len(forecasts)),
+	}
+	for _, f := range forecasts {
+		fcol.Features = append(fcol.Features, f.Feature())
+	}
+	return fcol
+}
+
+// EncodeGeoJSON writes the GeoJSON FeatureCollection of the forecasts to w.
+func EncodeGeoJSON(w io.Writer, forecasts ...*Forecast) error {
+	return json.NewEncoder(w).Encode(GeoJSON(forecasts...))
+}
+
+// Feature returns the forecast as a GeoJSON Point Feature located at its
+// Latitude and Longitude.
+func (f *Forecast) Feature() Feature {
+	props := ForecastProperties{
+		Timezone:            f.Timezone,
+		Time:                f.Currently.Time,
+		Summary:             f.Currently.Summary,
+		Icon:                f.Currently.Icon,
+		Temperature:         f.Currently.Temperature,
+		ApparentTemperature: f.Currently.ApparentTemperature,
+		Humidity:            f.Currently.Humidity,
+		WindSpeed:           f.Currently.WindSpeed,
+		WindBearing:         f.Currently.WindBearing,
+		PrecipProbability:   f.Currently.PrecipProbability,
+		DailySummary:        f.Daily.Summary,
+		Daily:               make([]DailySummary, 0, len(f.Daily.Data)),
+	}
+	for _, d := range f.Daily.Data {
+		props.Daily = append(props.Daily, DailySummary{
+			Time:              d.Time,
+			Summary:           d.Summary,
+			Icon:              d.Icon,
+			TemperatureHigh:   d.TemperatureHigh,
+			TemperatureLow:    d.TemperatureLow,
+			PrecipProbability: d.PrecipProbability,
+		})
+	}
+	return Feature{
+		Type: "Feature",
+		Geometry: Point{
+			Type:        "Point",
+			Coordinates: []float64{f.Longitude, f.Latitude},
+		},
+		Properties: props,
+	}
+}
@@ -0,0 +1,90 @@
+package darksky_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+func TestEncodeGeoJSON(t *testing.T) {
+	st := loadForecast(t, "SouthernTerminus.json")
+	boston := loadForecast(t, "Untitled-1.json")
+
+	var buf bytes.Buffer
+	err := darksky.EncodeGeoJSON(&buf, st, boston)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		var got darksky.FeatureCollection
+		err := json.Unmarshal(buf.Bytes(), &got)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		want := darksky.GeoJSON(st, boston)
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("decoded = %+v; want %+v", got, want)
+		}
+	})
+
+	t.Run("rfc 7946 structure", func(t *testing.T) {
+		var doc map[string]interface{}
+		err := json.Unmarshal(buf.Bytes(), &doc)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if doc["type"] != "FeatureCollection" {
+			t.Errorf("type = %v; want FeatureCollection", doc["type"])
+		}
+		features, ok := doc["features"].([]interface{})
+		if !ok || len(features) != 2 {
+			t.Fatalf("features = %v; want an array of 2 features", doc["features"])
+		}
+		wantCoords := [][]float64{
+			{st.Longitude, st.Latitude},
+			{boston.Longitude, boston.Latitude},
+		}
+		for i, raw := range features {
+			feature, ok := raw.(map[string]interface{})
+			if !ok {
+				t.Fatalf("features[%d] = %v; want an object", i, raw)
+			}
+			if feature["type"] != "Feature" {
+				t.Errorf("features[%d].type = %v; want Feature", i, feature["type"])
+			}
+			if _, ok := feature["properties"].(map[string]interface{}); !ok {
+				t.Errorf("features[%d].properties = %v; want an object", i, feature["properties"])
+			}
+			geometry, ok := feature["geometry"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("features[%d].geometry = %v; want an object", i, feature["geometry"])
+			}
+			if geometry["type"] != "Point" {
+				t.Errorf("features[%d].geometry.type = %v; want Point", i, geometry["type"])
+			}
+			coords, ok := geometry["coordinates"].([]interface{})
+			if !ok || len(coords) != 2 {
+				t.Fatalf("features[%d].geometry.coordinates = %v; want [long, lat]", i, geometry["coordinates"])
+			}
+			for j, c := range coords {
+				if c != wantCoords[i][j] {
+					t.Errorf("features[%d].geometry.coordinates[%d] = %v; want %v", i, j, c, wantCoords[i][j])
+				}
+			}
+		}
+	})
+
+	t.Run("daily summaries", func(t *testing.T) {
+		props := st.Feature().Properties
+		if len(props.Daily) != len(st.Daily.Data) {
+			t.Fatalf("len(Daily) = %d; want %d", len(props.Daily), len(st.Daily.Data))
+		}
+		if props.Daily[0].TemperatureHigh != st.Daily.Data[0].TemperatureHigh {
+			t.Errorf("Daily[0].TemperatureHigh = %f; want %f", props.Daily[0].TemperatureHigh, st.Daily.Data[0].TemperatureHigh)
+		}
+	})
+}
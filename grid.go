@@ -0,0 +1,358 @@
+package darksky
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// DefaultGridStep is the spacing in degrees between the points of a grid
+// when GridOptions.Step is not set.
+const DefaultGridStep = 0.25
+
+// DefaultMaxGridPoints is the largest grid fetched when GridOptions.MaxPoints
+// is not set. Every point is a separate API call.
+const DefaultMaxGridPoints = 100
+
+// Variable is a numeric field of an hourly data point that can be gridded.
+type Variable string
+
+const (
+	Temperature         Variable = "temperature"
+	ApparentTemperature Variable = "apparentTemperature"
+	DewPoint            Variable = "dewPoint"
+	Humidity            Variable = "humidity"
+	Pressure            Variable = "pressure"
+	WindSpeed           Variable = "windSpeed"
+	WindGust            Variable = "windGust"
+	PrecipIntensity     Variable = "precipIntensity"
+	PrecipProbability   Variable = "precipProbability"
+	CloudCover          Variable = "cloudCover"
+	Visibility          Variable = "visibility"
+)
+
+// Variables are all the variables a grid holds.
+var Variables = []Variable{
+	Temperature, ApparentTemperature, DewPoint, Humidity, Pressure,
+	WindSpeed, WindGust, PrecipIntensity, PrecipProbability, CloudCover, Visibility,
+}
+
+func (v Variable) value(p HourlyDataPoint) float64 {
+	switch v {
+	case Temperature:
+		return p.Temperature
+	case ApparentTemperature:
+		return p.ApparentTemperature
+	case DewPoint:
+		return p.DewPoint
+	case Humidity:
+		return p.Humidity
+	case Pressure:
+		return p.Pressure
+	case WindSpeed:
+		return p.WindSpeed
+	case WindGust:
+		return p.WindGust
+	case PrecipIntensity:
+		return p.PrecipIntensity
+	case PrecipProbability:
+		return p.PrecipProbability
+	case CloudCover:
+		return p.CloudCover
+	case Visibility:
+		return p.Visibility
+	}
+	return math.NaN()
+}
+
+// BBox is a bounding box in decimal degrees. Boxes crossing the
+// antimeridian are not supported.
+type BBox struct {
+	South, West, North, East float64
+}
+
+// Validate returns a *ValidationError if a corner of the box is invalid or
+// the box is inside out.
+func (b BBox) Validate() error {
+	for _, l := range []Location{{Lat: b.South, Long: b.West}, {Lat: b.North, Long: b.East}} {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+	}
+	if b.North < b.South {
+		return &ValidationError{Field: "North", Value: b.North, Reason: "must not be south of South"}
+	}
+	if b.East < b.West {
+		return &ValidationError{Field: "East", Value: b.East, Reason: "must not be west of West"}
+	}
+	return nil
+}
+
+// GridOptions configure Client.Grid.
+type GridOptions struct {
+	// Step is the spacing in degrees between points. It defaults to
+	// DefaultGridStep.
+	Step float64
+
+	// Hours is the number of hourly time steps to keep. Zero keeps every
+	// hour of the forecast.
+	Hours int
+
+	// MaxPoints guards against unexpectedly large grids. It defaults to
+	// DefaultMaxGridPoints.
+	MaxPoints int
+}
+
+// Grid is the hourly forecast on a regular lattice of points.
+type Grid struct {
+	Lats  []float64 // ascending
+	Longs []float64 // ascending
+	Times []time.Time
+
+	fields map[Variable][]*Field
+}
+
+// Field returns the values of v at the given time step.
+func (g *Grid) Field(v Variable, step int) (*Field, error) {
+	fields, ok := g.fields[v]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q", v)
+	}
+	if step < 0 || step >= len(fields) {
+		return nil, ErrOutOfRange
+	}
+	return fields[step], nil
+}
+
+// Field is the value of a variable over a grid at one time. Values are
+// indexed by latitude then longitude and are NaN where the forecast had no
+// data.
+type Field struct {
+	Variable Variable
+	Time     time.Time
+	Lats     []float64
+	Longs    []float64
+	Values   [][]float64
+}
+
+// Grid fetches a forecast for every point of a lattice spanning box, starting
+// at its south-west corner, and arranges the hourly data into fields.
+func (c *Client) Grid(ctx context.Context, box BBox, opts GridOptions) (*Grid, error) {
+	if err := box.Validate(); err != nil {
+		return nil, err
+	}
+	step := opts.Step
+	if step == 0 {
+		step = DefaultGridStep
+	}
+	if !(step > 0) || math.IsInf(step, 0) {
+		return nil, &ValidationError{Field: "Step", Value: step, Reason: "must be positive"}
+	}
+	maxPoints := opts.MaxPoints
+	if maxPoints <= 0 {
+		maxPoints = DefaultMaxGridPoints
+	}
+	// Count the points in floating point before building the lattices, so
+	// that a tiny step is rejected rather than allocated.
+	if n := latticeSize(box.South, box.North, step) * latticeSize(box.West, box.East, step); n > float64(maxPoints) {
+		return nil, &ValidationError{Field: "Step", Value: step, Reason: fmt.Sprintf("gives %.0f points, more than %d", n, maxPoints)}
+	}
+	g := &Grid{
+		Lats:   lattice(box.South, box.North, step),
+		Longs:  lattice(box.West, box.East, step),
+		fields: make(map[Variable][]*Field),
+	}
+
+	forecasts := make([][]*Forecast, len(g.Lats))
+	for i, lat := range g.Lats {
+		forecasts[i] = make([]*Forecast, len(g.Longs))
+		for j, long := range g.Longs {
+			location, err := c.latlong(lat, long)
+			if err != nil {
+				return nil, err
+			}
+			fc, err := c.forecast(ctx, location)
+			if err != nil {
+				return nil, err
+			}
+			forecasts[i][j] = fc
+		}
+	}
+
+	for _, p := range forecasts[0][0].Hourly.Data {
+		if opts.Hours > 0 && len(g.Times) == opts.Hours {
+			break
+		}
+		g.Times = append(g.Times, time.Unix(int64(p.Time), 0))
+	}
+	for _, v := range Variables {
+		for _, t := range g.Times {
+			f := &Field{Variable: v, Time: t, Lats: g.Lats, Longs: g.Longs, Values: make([][]float64, len(g.Lats))}
+			for i := range g.Lats {
+				f.Values[i] = make([]float64, len(g.Longs))
+				for j := range g.Longs {
+					p, err := forecasts[i][j].At(t)
+					if err != nil {
+						f.Values[i][j] = math.NaN()
+						continue
+					}
+					f.Values[i][j] = v.value(p)
+				}
+			}
+			g.fields[v] = append(g.fields[v], f)
+		}
+	}
+	return g, nil
+}
+
+// lattice returns the points from min to max every step, including max if
+// it falls on the lattice.
+func lattice(min, max, step float64) []float64 {
+	points := make([]float64, int(latticeSize(min, max, step)))
+	for i := range points {
+		points[i] = min + float64(i)*step
+	}
+	return points
+}
+
+// latticeSize returns the number of points lattice returns.
+func latticeSize(min, max, step float64) float64 {
+	return math.Floor((max-min)/step+1e-9) + 1
+}
+
+// At returns the value at the coordinates by bilinear interpolation between
+// the four surrounding grid points. It returns ErrOutsideGrid for
+// coordinates outside the grid.
+func (f *Field) At(lat, long float64) (float64, error) {
+	i, fi, ok := bracket(f.Lats, lat)
+	if !ok {
+		return 0, ErrOutsideGrid
+	}
+	j, fj, ok := bracket(f.Longs, long)
+	if !ok {
+		return 0, ErrOutsideGrid
+	}
+	i1, j1 := i, j
+	if i+1 < len(f.Lats) {
+		i1 = i + 1
+	}
+	if j+1 < len(f.Longs) {
+		j1 = j + 1
+	}
+	v := f.Values
+	south := v[i][j] + fj*(v[i][j1]-v[i][j])
+	north := v[i1][j] + fj*(v[i1][j1]-v[i1][j])
+	return south + fi*(north-south), nil
+}
+
+// bracket returns the index of the last point at or below x and how far x
+// is towards the next point, as a fraction.
+func bracket(points []float64, x float64) (int, float64, bool) {
+	n := len(points)
+	if n == 0 || x < points[0] || x > points[n-1] {
+		return 0, 0, false
+	}
+	i := 0
+	for i < n-2 && points[i+1] <= x {
+		i++
+	}
+	if n == 1 {
+		return 0, 0, true
+	}
+	return i, (x - points[i]) / (points[i+1] - points[i]), true
+}
+
+// WriteCSV writes the field as lat,long,value rows with a header.
+func (f *Field) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"lat", "long", string(f.Variable)})
+	for i, lat := range f.Lats {
+		for j, long := range f.Longs {
+			cw.Write([]string{
+				strconv.FormatFloat(lat, 'f', -1, 64),
+				strconv.FormatFloat(long, 'f', -1, 64),
+				formatValue(f.Values[i][j]),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// NoData is the value written for missing data in ASCII rasters.
+const NoData = -9999
+
+// WriteASCII writes the field as an Esri ASCII raster, north row first.
+func (f *Field) WriteASCII(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	cellSize := 0.0
+	if len(f.Lats) > 1 {
+		cellSize = f.Lats[1] - f.Lats[0]
+	} else if len(f.Longs) > 1 {
+		cellSize = f.Longs[1] - f.Longs[0]
+	}
+	fmt.Fprintf(bw, "ncols %d\nnrows %d\n", len(f.Longs), len(f.Lats))
+	fmt.Fprintf(bw, "xllcenter %s\nyllcenter %s\n", formatValue(f.Longs[0]), formatValue(f.Lats[0]))
+	fmt.Fprintf(bw, "cellsize %s\nNODATA_value %d\n", formatValue(cellSize), NoData)
+	for i := len(f.Lats) - 1; i >= 0; i-- {
+		for j, v := range f.Values[i] {
+			if j > 0 {
+				bw.WriteByte(' ')
+			}
+			if math.IsNaN(v) {
+				v = NoData
+			}
+			bw.WriteString(formatValue(v))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// Image renders the field one pixel per grid point, north at the top,
+// shading from blue at the lowest value to red at the highest. Missing
+// values are transparent.
+func (f *Field) Image() image.Image {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, row := range f.Values {
+		for _, v := range row {
+			if !math.IsNaN(v) {
+				min, max = math.Min(min, v), math.Max(max, v)
+			}
+		}
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, len(f.Longs), len(f.Lats)))
+	for i, row := range f.Values {
+		for j, v := range row {
+			if math.IsNaN(v) {
+				continue
+			}
+			x := 0.5
+			if max > min {
+				x = (v - min) / (max - min)
+			}
+			img.SetNRGBA(j, len(f.Lats)-1-i, color.NRGBA{R: uint8(255 * x), G: uint8(255 * (1 - math.Abs(2*x-1))), B: uint8(255 * (1 - x)), A: 255})
+		}
+	}
+	return img
+}
+
+// WritePNG writes the image of the field as a PNG.
+func (f *Field) WritePNG(w io.Writer) error {
+	return png.Encode(w, f.Image())
+}
+
+func formatValue(v float64) string {
+	if math.IsNaN(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
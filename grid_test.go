@@ -0,0 +1,193 @@
+package darksky_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/darkskytest"
+)
+
+func TestClient_Grid(t *testing.T) {
+	now := time.Date(2019, 12, 17, 8, 0, 0, 0, time.UTC)
+	server := darkskytest.NewServer(1)
+	defer server.Close()
+	server.Handler().Now = func() time.Time { return now }
+	c := darksky.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
+	}
+	box := darksky.BBox{South: 32.5, West: -117, North: 33, East: -116.5}
+
+	g, err := c.Grid(context.Background(), box, darksky.GridOptions{Step: 0.25, Hours: 6})
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(g.Lats) != 3 || len(g.Longs) != 3 {
+		t.Fatalf("grid is %dx%d; want 3x3", len(g.Lats), len(g.Longs))
+	}
+	if len(g.Times) != 6 {
+		t.Fatalf("len(Times) = %d; want 6", len(g.Times))
+	}
+	if server.Handler().Requests() != 9 {
+		t.Errorf("requests = %d; want 9", server.Handler().Requests())
+	}
+
+	f, err := g.Field(darksky.Temperature, 2)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	fc, err := c.Forecast(32.75, -116.75)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	want, err := fc.At(g.Times[2])
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if f.Values[1][1] != want.Temperature {
+		t.Errorf("Values[1][1] = %f; want the forecast temperature %f", f.Values[1][1], want.Temperature)
+	}
+	if got, _ := f.At(32.75, -116.75); got != want.Temperature {
+		t.Errorf("At(grid point) = %f; want %f", got, want.Temperature)
+	}
+	mid := (f.Values[0][0] + f.Values[0][1] + f.Values[1][0] + f.Values[1][1]) / 4
+	if got, _ := f.At(32.625, -116.875); math.Abs(got-mid) > 1e-9 {
+		t.Errorf("At(cell centre) = %f; want the mean of its corners %f", got, mid)
+	}
+	if _, err := f.At(34, -116.75); err != darksky.ErrOutsideGrid {
+		t.Errorf("err = %v; want %v", err, darksky.ErrOutsideGrid)
+	}
+
+	if _, err := g.Field(darksky.Temperature, 6); err != darksky.ErrOutOfRange {
+		t.Errorf("err = %v; want %v", err, darksky.ErrOutOfRange)
+	}
+	if _, err := g.Field("sunshine", 0); err == nil {
+		t.Errorf("err = nil; want an error for an unknown variable")
+	}
+}
+
+func TestClient_Grid_invalid(t *testing.T) {
+	c := darksky.Client{Key: "gibberish-key", BaseURL: "http://127.0.0.1:0"}
+	tests := map[string]struct {
+		box  darksky.BBox
+		opts darksky.GridOptions
+	}{
+		"inside out":     {box: darksky.BBox{South: 33, West: -117, North: 32, East: -116}},
+		"invalid corner": {box: darksky.BBox{South: 32, West: -117, North: 91, East: -116}},
+		"negative step":  {box: darksky.BBox{South: 32, West: -117, North: 33, East: -116}, opts: darksky.GridOptions{Step: -1}},
+		"too many":       {box: darksky.BBox{South: 32, West: -117, North: 33, East: -116}, opts: darksky.GridOptions{Step: 0.01}},
+		"tiny step":      {box: darksky.BBox{South: -90, West: -180, North: 90, East: 180}, opts: darksky.GridOptions{Step: 1e-12}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := c.Grid(context.Background(), tc.box, tc.opts)
+			if !isValidationError(err) {
+				t.Errorf("err = %v; want *ValidationError", err)
+			}
+		})
+	}
+}
+
+// plane is a field whose values are linear in latitude and longitude, which
+// bilinear interpolation reproduces exactly.
+func plane() *darksky.Field {
+	f := &darksky.Field{
+		Variable: darksky.Temperature,
+		Lats:     []float64{10, 11, 12},
+		Longs:    []float64{20, 21},
+	}
+	for _, lat := range f.Lats {
+		var row []float64
+		for _, long := range f.Longs {
+			row = append(row, 2*lat+3*long)
+		}
+		f.Values = append(f.Values, row)
+	}
+	return f
+}
+
+func TestField_At(t *testing.T) {
+	f := plane()
+	for _, p := range [][2]float64{{10, 20}, {12, 21}, {10.5, 20.5}, {11.9, 20.1}, {11, 21}} {
+		got, err := f.At(p[0], p[1])
+		if err != nil {
+			t.Fatalf("At(%v) err = %v; want nil", p, err)
+		}
+		if want := 2*p[0] + 3*p[1]; math.Abs(got-want) > 1e-9 {
+			t.Errorf("At(%v) = %f; want %f", p, got, want)
+		}
+	}
+	for _, p := range [][2]float64{{9.9, 20}, {12.1, 20}, {11, 19.9}, {11, 21.1}} {
+		if _, err := f.At(p[0], p[1]); err != darksky.ErrOutsideGrid {
+			t.Errorf("At(%v) err = %v; want %v", p, err, darksky.ErrOutsideGrid)
+		}
+	}
+}
+
+func TestField_WriteCSV(t *testing.T) {
+	f := plane()
+	f.Values[0][1] = math.NaN()
+	var buf bytes.Buffer
+	if err := f.WriteCSV(&buf); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(records) != 7 {
+		t.Fatalf("len(records) = %d; want a header and 6 rows", len(records))
+	}
+	if strings.Join(records[0], ",") != "lat,long,temperature" {
+		t.Errorf("header = %v; want lat,long,temperature", records[0])
+	}
+	if strings.Join(records[1], ",") != "10,20,80" {
+		t.Errorf("records[1] = %v; want 10,20,80", records[1])
+	}
+	if records[2][2] != "" {
+		t.Errorf("missing value = %q; want empty", records[2][2])
+	}
+}
+
+func TestField_WriteASCII(t *testing.T) {
+	f := plane()
+	f.Values[0][1] = math.NaN()
+	var buf bytes.Buffer
+	if err := f.WriteASCII(&buf); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	want := "ncols 2\nnrows 3\nxllcenter 20\nyllcenter 10\ncellsize 1\nNODATA_value -9999\n" +
+		"84 87\n82 85\n80 -9999\n"
+	if buf.String() != want {
+		t.Errorf("WriteASCII() = %q; want %q", buf.String(), want)
+	}
+}
+
+func TestField_WritePNG(t *testing.T) {
+	f := plane()
+	var buf bytes.Buffer
+	if err := f.WritePNG(&buf); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 3 {
+		t.Fatalf("image is %dx%d; want 2x3", b.Dx(), b.Dy())
+	}
+	// The warmest point is in the north-east, at the top right.
+	if r, _, b, _ := img.At(1, 0).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("top right = %v; want red", img.At(1, 0))
+	}
+	if r, _, b, _ := img.At(0, 2).RGBA(); r != 0 || b>>8 != 255 {
+		t.Errorf("bottom left = %v; want blue", img.At(0, 2))
+	}
+}
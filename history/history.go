@@ -0,0 +1,133 @@
+// Package history archives fetched forecasts and scores them against what
+// was later observed.
+//
+// A Store keeps each forecast as a JSON file under a directory per
+// location. Wrap a provider with Store.Archive to save everything it
+// fetches:
+//
+//	store, err := history.Open("forecasts")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	p := store.Archive(&darksky.Client{Key: key}, func(err error) { log.Print(err) })
+//
+// A Verifier later compares the archived hourly forecasts with Time Machine
+// observations and reports the error of each variable by lead time.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// Entry is an archived forecast.
+type Entry struct {
+	Location darksky.Location  `json:"location"`
+	Fetched  time.Time         `json:"fetched"`
+	Forecast *darksky.Forecast `json:"forecast"`
+}
+
+// Store archives forecasts in a directory.
+type Store struct {
+	dir string
+
+	// Now returns the fetch time of forecasts saved by Archive. It
+	// defaults to time.Now.
+	Now func() time.Time
+}
+
+// Open returns a store in dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Save archives e. Entries for the same location fetched at the same
+// instant replace each other.
+func (s *Store) Save(e Entry) error {
+	dir := filepath.Join(s.dir, e.Location.Format(darksky.DefaultPrecision))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so that readers never see half an
+	// entry.
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	name := strconv.FormatInt(e.Fetched.UnixNano(), 10) + ".json"
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// Entries returns every archived forecast, oldest first.
+func (s *Store) Entries() ([]Entry, error) {
+	var entries []Entry
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Fetched.Before(entries[j].Fetched) })
+	return entries, nil
+}
+
+// Archive wraps p so that every forecast it returns is saved. A forecast
+// that can't be saved is still returned, and the error is passed to
+// onError unless it is nil.
+func (s *Store) Archive(p darksky.Provider, onError func(error)) darksky.Provider {
+	return darksky.ProviderFunc(func(ctx context.Context, lat, long float64) (*darksky.Forecast, error) {
+		f, err := p.ForecastContext(ctx, lat, long)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now
+		if s.Now != nil {
+			now = s.Now
+		}
+		e := Entry{Location: darksky.Location{Lat: lat, Long: long}, Fetched: now(), Forecast: f}
+		if err := s.Save(e); err != nil && onError != nil {
+			onError(err)
+		}
+		return f, nil
+	})
+}
@@ -0,0 +1,99 @@
+package history_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/history"
+)
+
+var (
+	stLat  = 32.589720
+	stLong = -116.466988
+	start  = time.Date(2019, time.December, 17, 8, 0, 0, 0, time.UTC)
+)
+
+func TestStore(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "forecasts"))
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	loc := darksky.Location{Lat: stLat, Long: stLong}
+	for _, fetched := range []time.Time{start.Add(time.Hour), start, start.Add(time.Hour)} {
+		e := history.Entry{Location: loc, Fetched: fetched, Forecast: &darksky.Forecast{Timezone: fetched.String()}}
+		if err := store.Save(e); err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+	}
+	if err := store.Save(history.Entry{Location: darksky.Location{Lat: 1, Long: 2}, Fetched: start.Add(-time.Hour)}); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+
+	entries, err := store.Entries()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	want := []time.Time{start.Add(-time.Hour), start, start.Add(time.Hour)}
+	if len(entries) != len(want) {
+		t.Fatalf("len(Entries()) = %d; want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if !e.Fetched.Equal(want[i]) {
+			t.Errorf("Entries()[%d].Fetched = %v; want %v", i, e.Fetched, want[i])
+		}
+	}
+	if e := entries[2]; e.Location != loc || e.Forecast == nil || e.Forecast.Timezone != start.Add(time.Hour).String() {
+		t.Errorf("Entries()[2] = %+v; want the forecast saved last for the location", e)
+	}
+}
+
+func TestStore_Archive(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "forecasts")
+	store, err := history.Open(dir)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	store.Now = func() time.Time { return start }
+	fc := &darksky.Forecast{Timezone: "America/Los_Angeles"}
+	p := darksky.ProviderFunc(func(ctx context.Context, lat, long float64) (*darksky.Forecast, error) {
+		if lat > 90 {
+			return nil, errors.New("bad latitude")
+		}
+		return fc, nil
+	})
+	var saveErr error
+	archived := store.Archive(p, func(err error) { saveErr = err })
+
+	if _, err := archived.Forecast(stLat, stLong); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if _, err := archived.Forecast(91, stLong); err == nil {
+		t.Errorf("err = nil; want the provider's error")
+	}
+	entries, err := store.Entries()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(entries) != 1 || !entries[0].Fetched.Equal(start) || entries[0].Location.Lat != stLat {
+		t.Errorf("Entries() = %+v; want the one successful forecast", entries)
+	}
+
+	// Forecasts are still returned when they can't be saved.
+	os.RemoveAll(dir)
+	if err := ioutil.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	got, err := archived.Forecast(stLat, stLong)
+	if err != nil || got != fc {
+		t.Errorf("Forecast() = %v, %v; want the forecast, nil", got, err)
+	}
+	if saveErr == nil {
+		t.Errorf("onError was not called")
+	}
+}
@@ -0,0 +1,243 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"text/tabwriter"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// Observer returns the observed conditions on the day of t. *darksky.Client
+// is an Observer.
+type Observer interface {
+	TimeMachine(lat, long float64, t time.Time) (*darksky.Forecast, error)
+}
+
+// DefaultVariables are the hourly fields a Verifier scores by default, by
+// JSON name.
+var DefaultVariables = []string{
+	"temperature", "apparentTemperature", "dewPoint", "humidity",
+	"pressure", "windSpeed", "cloudCover", "precipIntensity",
+}
+
+// DefaultLeadTimes are the upper bounds, in hours, of the lead time groups
+// a Verifier uses by default.
+var DefaultLeadTimes = []int{6, 12, 24, 48, 168}
+
+// Verifier scores archived forecasts against observations.
+type Verifier struct {
+	Observer Observer
+
+	// Variables are the hourly fields scored, by JSON name. They default
+	// to DefaultVariables.
+	Variables []string
+
+	// LeadTimes are the upper bounds, in hours, of the lead time groups,
+	// in increasing order. A forecast point's lead time is the time from
+	// the fetch to the start of its hour; points with longer lead times
+	// than the last bound are not scored. They default to
+	// DefaultLeadTimes.
+	LeadTimes []int
+
+	// Now returns the current time. Only hours that have ended by then
+	// are scored. It defaults to time.Now.
+	Now func() time.Time
+
+	// OnError, if set, is called with the error of each failed Time
+	// Machine request. The forecasts for the day it was for are skipped.
+	OnError func(error)
+}
+
+// Report holds the scores of a verification.
+type Report struct {
+	Generated time.Time `json:"generated"`
+	Forecasts int       `json:"forecasts"` // archived forecasts read
+	Requests  int       `json:"requests"`  // Time Machine requests made
+	Skipped   int       `json:"skipped"`   // forecast hours not scored for want of an observation
+	Scores    []Score   `json:"scores"`
+}
+
+// Score is the error of a variable for the forecast points whose lead time
+// is in [From, To) hours. Errors are forecast minus observation, in the
+// units of the forecasts.
+type Score struct {
+	Variable string  `json:"variable"`
+	From     int     `json:"from"`
+	To       int     `json:"to"`
+	N        int     `json:"n"`
+	MAE      float64 `json:"mae"`
+	RMSE     float64 `json:"rmse"`
+	Bias     float64 `json:"bias"`
+}
+
+// Verify scores the hourly forecasts of entries. Observations are fetched
+// once per location and local day, whether or not the request succeeds;
+// forecast hours without an observation are counted in Report.Skipped
+// instead of being scored.
+func (v *Verifier) Verify(entries []Entry) (*Report, error) {
+	variables := v.Variables
+	if len(variables) == 0 {
+		variables = DefaultVariables
+	}
+	leads := v.LeadTimes
+	if len(leads) == 0 {
+		leads = DefaultLeadTimes
+	}
+	for i, to := range leads {
+		if to <= 0 || i > 0 && to <= leads[i-1] {
+			return nil, fmt.Errorf("history: lead times %v are not positive and increasing", leads)
+		}
+	}
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	fields := hourlyFields()
+	for _, name := range variables {
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("history: %q is not a numeric hourly field", name)
+		}
+	}
+
+	report := &Report{Generated: now(), Forecasts: len(entries)}
+	type sums struct{ n, abs, sq, sum float64 }
+	totals := make([]sums, len(variables)*len(leads))
+	obs := make(map[string]map[int]darksky.HourlyDataPoint)
+	type day struct {
+		loc  string
+		date string
+	}
+	requested := make(map[day]bool)
+
+	for _, e := range entries {
+		if e.Forecast == nil {
+			continue
+		}
+		loc := e.Location.String()
+		if obs[loc] == nil {
+			obs[loc] = make(map[int]darksky.HourlyDataPoint)
+		}
+		// Time Machine requests return the day of the location's
+		// timezone.
+		tz, err := time.LoadLocation(e.Forecast.Timezone)
+		if err != nil {
+			tz = time.UTC
+		}
+		for _, p := range e.Forecast.Hourly.Data {
+			start := time.Unix(int64(p.Time), 0)
+			if start.Add(time.Hour).After(report.Generated) {
+				continue
+			}
+			bucket := leadBucket(start.Sub(e.Fetched), leads)
+			if bucket < 0 {
+				continue
+			}
+			d := day{loc, start.In(tz).Format("2006-01-02")}
+			o, ok := obs[loc][p.Time]
+			if !ok && !requested[d] {
+				report.Requests++
+				requested[d] = true
+				f, err := v.Observer.TimeMachine(e.Location.Lat, e.Location.Long, start)
+				if err != nil {
+					if v.OnError != nil {
+						v.OnError(err)
+					}
+				} else {
+					for _, h := range f.Hourly.Data {
+						obs[loc][h.Time] = h
+					}
+					o, ok = obs[loc][p.Time]
+				}
+			}
+			if !ok {
+				report.Skipped++
+				continue
+			}
+			fv, ov := reflect.ValueOf(p), reflect.ValueOf(o)
+			for i, name := range variables {
+				d := value(fv, fields[name]) - value(ov, fields[name])
+				t := &totals[i*len(leads)+bucket]
+				t.n++
+				t.abs += math.Abs(d)
+				t.sq += d * d
+				t.sum += d
+			}
+		}
+	}
+
+	for i, name := range variables {
+		for j, to := range leads {
+			from := 0
+			if j > 0 {
+				from = leads[j-1]
+			}
+			t := totals[i*len(leads)+j]
+			if t.n == 0 {
+				continue
+			}
+			report.Scores = append(report.Scores, Score{
+				Variable: name,
+				From:     from,
+				To:       to,
+				N:        int(t.n),
+				MAE:      t.abs / t.n,
+				RMSE:     math.Sqrt(t.sq / t.n),
+				Bias:     t.sum / t.n,
+			})
+		}
+	}
+	return report, nil
+}
+
+// leadBucket returns the index of the group lead falls in, or -1 if it is
+// beyond the last. Hours already under way at the fetch count as lead
+// time zero.
+func leadBucket(lead time.Duration, leads []int) int {
+	for i, to := range leads {
+		if lead < time.Duration(to)*time.Hour {
+			return i
+		}
+	}
+	return -1
+}
+
+// hourlyFields maps the JSON names of the numeric fields of
+// darksky.HourlyDataPoint to their index.
+func hourlyFields() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(darksky.HourlyDataPoint{})
+	for i := 0; i < t.NumField(); i++ {
+		switch t.Field(i).Type.Kind() {
+		case reflect.Float64, reflect.Int:
+			fields[t.Field(i).Tag.Get("json")] = i
+		}
+	}
+	delete(fields, "time")
+	return fields
+}
+
+func value(v reflect.Value, i int) float64 {
+	if f := v.Field(i); f.Kind() == reflect.Int {
+		return float64(f.Int())
+	}
+	return v.Field(i).Float()
+}
+
+// WriteText writes the report as a table.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "variable\tlead\tn\tMAE\tRMSE\tbias\n")
+	for _, s := range r.Scores {
+		fmt.Fprintf(tw, "%s\t%d-%dh\t%d\t%.2f\t%.2f\t%+.2f\n", s.Variable, s.From, s.To, s.N, s.MAE, s.RMSE, s.Bias)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d forecasts, %d Time Machine requests, %d hours skipped, generated %s\n",
+		r.Forecasts, r.Requests, r.Skipped, r.Generated.UTC().Format(time.RFC3339))
+	return err
+}
@@ -0,0 +1,174 @@
+package history_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/darkskytest"
+	"github.com/sophiaehlen/darksky-client/history"
+)
+
+// observerFunc adapts a function to history.Observer.
+type observerFunc func(lat, long float64, t time.Time) (*darksky.Forecast, error)
+
+func (f observerFunc) TimeMachine(lat, long float64, t time.Time) (*darksky.Forecast, error) {
+	return f(lat, long, t)
+}
+
+func hourly(temps map[time.Time]float64) *darksky.Forecast {
+	var fc darksky.Forecast
+	for t, temp := range temps {
+		fc.Hourly.Data = append(fc.Hourly.Data, darksky.HourlyDataPoint{Time: int(t.Unix()), Temperature: temp})
+	}
+	sort.Slice(fc.Hourly.Data, func(i, j int) bool { return fc.Hourly.Data[i].Time < fc.Hourly.Data[j].Time })
+	return &fc
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	loc := darksky.Location{Lat: stLat, Long: stLong}
+	h := func(n int) time.Time { return start.Add(time.Duration(n) * time.Hour) }
+	entries := []history.Entry{
+		{Location: loc, Fetched: h(0), Forecast: hourly(map[time.Time]float64{h(1): 60, h(7): 70, h(20): 50})},
+		{Location: loc, Fetched: h(4), Forecast: hourly(map[time.Time]float64{h(7): 71, h(13): 40})},
+	}
+	requests := 0
+	v := &history.Verifier{
+		Observer: observerFunc(func(lat, long float64, at time.Time) (*darksky.Forecast, error) {
+			requests++
+			return hourly(map[time.Time]float64{h(1): 58, h(7): 72}), nil
+		}),
+		Variables: []string{"temperature"},
+		LeadTimes: []int{6, 12},
+		Now:       func() time.Time { return h(10) },
+	}
+	report, err := v.Verify(entries)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+
+	// h(1) and h(7) are scored from the first forecast, and h(7) again
+	// from the second; h(13) and h(20) are still in the future.
+	want := []history.Score{
+		{Variable: "temperature", From: 0, To: 6, N: 2, MAE: 1.5, RMSE: math.Sqrt(2.5), Bias: 0.5},
+		{Variable: "temperature", From: 6, To: 12, N: 1, MAE: 2, RMSE: 2, Bias: -2},
+	}
+	if len(report.Scores) != len(want) {
+		t.Fatalf("Scores = %+v; want %+v", report.Scores, want)
+	}
+	for i, s := range report.Scores {
+		w := want[i]
+		if s.Variable != w.Variable || s.From != w.From || s.To != w.To || s.N != w.N ||
+			math.Abs(s.MAE-w.MAE) > 1e-9 || math.Abs(s.RMSE-w.RMSE) > 1e-9 || math.Abs(s.Bias-w.Bias) > 1e-9 {
+			t.Errorf("Scores[%d] = %+v; want %+v", i, s, w)
+		}
+	}
+	if report.Forecasts != 2 || report.Requests != 1 || requests != 1 {
+		t.Errorf("Forecasts, Requests = %d, %d (made %d); want 2, 1 (made 1)", report.Forecasts, report.Requests, requests)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) < 3 || strings.Join(strings.Fields(lines[2]), " ") != "temperature 6-12h 1 2.00 2.00 -2.00" {
+		t.Errorf("WriteText() = %q; want the 6-12h row third", buf.String())
+	}
+
+	v.Variables = []string{"summary"}
+	if _, err := v.Verify(entries); err == nil {
+		t.Errorf("err = nil; want an error for a non-numeric variable")
+	}
+	v.Variables = []string{"temperature"}
+	for _, leads := range [][]int{{12, 6}, {6, 6}, {0, 6}} {
+		v.LeadTimes = leads
+		if _, err := v.Verify(entries); err == nil {
+			t.Errorf("LeadTimes %v: err = nil; want an error", leads)
+		}
+	}
+}
+
+func TestVerifier_failedRequests(t *testing.T) {
+	loc := darksky.Location{Lat: stLat, Long: stLong}
+	h := func(n int) time.Time { return start.Add(time.Duration(n) * time.Hour) }
+	entries := []history.Entry{
+		{Location: loc, Fetched: h(0), Forecast: hourly(map[time.Time]float64{h(1): 60, h(2): 61, h(20): 50})},
+		{Location: loc, Fetched: h(1), Forecast: hourly(map[time.Time]float64{h(2): 62, h(20): 51, h(21): 52})},
+	}
+	var errs []error
+	v := &history.Verifier{
+		// The 17th is unavailable; the 18th lacks h(21).
+		Observer: observerFunc(func(lat, long float64, at time.Time) (*darksky.Forecast, error) {
+			if at.Day() == 17 {
+				return nil, errors.New("unavailable")
+			}
+			return hourly(map[time.Time]float64{h(20): 50}), nil
+		}),
+		Variables: []string{"temperature"},
+		LeadTimes: []int{48},
+		Now:       func() time.Time { return h(30) },
+		OnError:   func(err error) { errs = append(errs, err) },
+	}
+	report, err := v.Verify(entries)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	// One request per day, the failed one included.
+	if len(errs) != 1 || report.Requests != 2 || report.Skipped != 4 {
+		t.Errorf("errors, Requests, Skipped = %d, %d, %d; want 1, 2, 4", len(errs), report.Requests, report.Skipped)
+	}
+	if len(report.Scores) != 1 || report.Scores[0].N != 2 || math.Abs(report.Scores[0].Bias-0.5) > 1e-9 {
+		t.Errorf("Scores = %+v; want h(20) scored twice", report.Scores)
+	}
+}
+
+func TestVerifier_fakeServer(t *testing.T) {
+	server := darkskytest.NewServer(1)
+	defer server.Close()
+	server.Handler().Now = func() time.Time { return start }
+	c := &darksky.Client{Key: "key", BaseURL: server.URL}
+
+	store, err := history.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	store.Now = func() time.Time { return start }
+	p := store.Archive(c, func(err error) { t.Errorf("err = %v; want nil", err) })
+	if _, err := p.Forecast(stLat, stLong); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	entries, err := store.Entries()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+
+	now := func() time.Time { return start.Add(30 * time.Hour) }
+	report, err := (&history.Verifier{Observer: c, Now: now}).Verify(entries)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(report.Scores) == 0 || report.Requests == 0 || report.Requests > 3 {
+		t.Fatalf("Scores, Requests = %d, %d; want scores from at most 3 requests", len(report.Scores), report.Requests)
+	}
+	for _, s := range report.Scores {
+		if s.MAE > 1e-9 {
+			t.Errorf("%s %d-%dh MAE = %v; want 0 against the same synthetic weather", s.Variable, s.From, s.To, s.MAE)
+		}
+	}
+
+	other := darkskytest.NewServer(2)
+	defer other.Close()
+	report, err = (&history.Verifier{Observer: &darksky.Client{Key: "key", BaseURL: other.URL}, Now: now}).Verify(entries)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(report.Scores) == 0 || report.Scores[0].Variable != "temperature" || report.Scores[0].MAE == 0 {
+		t.Errorf("Scores = %+v; want temperature errors against other weather", report.Scores)
+	}
+}
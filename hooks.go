@@ -0,0 +1,149 @@
+package darksky
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error classes a RequestInfo may have.
+const (
+	ErrorClassCanceled    = "canceled"
+	ErrorClassTimeout     = "timeout"
+	ErrorClassCircuitOpen = "circuit_open"
+	ErrorClassNetwork     = "network"
+	ErrorClassClient      = "client" // the API answered with a 4xx status
+	ErrorClassServer      = "server" // the API answered with a 5xx status
+	ErrorClassDecode      = "decode"
+)
+
+// RequestInfo describes a request made by a Client to its hooks.
+type RequestInfo struct {
+	Endpoint string // "forecast" or "timemachine"
+	Method   string
+	URL      string // with the key redacted
+	Lat      float64
+	Long     float64
+	Start    time.Time
+
+	// Set once the request is done.
+	Elapsed    time.Duration
+	Status     int    // 0 if there was no response
+	ErrorClass string // one of the ErrorClass constants if it failed
+}
+
+// Hooks observe the requests of a Client. Any of them may be nil. For each
+// request RequestStart is called first, then either Response or Error.
+type Hooks struct {
+	// RequestStart is called before the request is sent. The context it
+	// returns is passed to the later hooks; returning nil keeps ctx.
+	RequestStart func(ctx context.Context, info *RequestInfo) context.Context
+
+	// Response is called once a forecast has been received and decoded.
+	// The response body has been read and closed, and its Request is a
+	// copy with the key redacted from the URL and no Authorization header.
+	Response func(ctx context.Context, info *RequestInfo, res *http.Response)
+
+	// Error is called when the request fails for any reason. The key is
+	// redacted from the URLs of transport errors.
+	Error func(ctx context.Context, info *RequestInfo, err error)
+}
+
+// redactedKey replaces the key in the URLs given to hooks.
+const redactedKey = "REDACTED"
+
+func (c *Client) requestInfo(req *http.Request, location string) *RequestInfo {
+	info := &RequestInfo{
+		Endpoint: "forecast",
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Start:    time.Now(),
+	}
+	if c.Key != "" {
+		info.URL = strings.Replace(info.URL, "/"+c.Key+"/", "/"+redactedKey+"/", 1)
+	}
+	parts := strings.Split(location, ",")
+	if len(parts) == 3 {
+		info.Endpoint = "timemachine"
+	}
+	if len(parts) >= 2 {
+		info.Lat, _ = strconv.ParseFloat(parts[0], 64)
+		info.Long, _ = strconv.ParseFloat(parts[1], 64)
+	}
+	return info
+}
+
+func (c *Client) hookStart(ctx context.Context, info *RequestInfo) context.Context {
+	for _, h := range c.Hooks {
+		if h.RequestStart == nil {
+			continue
+		}
+		if next := h.RequestStart(ctx, info); next != nil {
+			ctx = next
+		}
+	}
+	return ctx
+}
+
+func (c *Client) hookResponse(ctx context.Context, info *RequestInfo, res *http.Response) {
+	info.Elapsed = time.Since(info.Start)
+	info.Status = res.StatusCode
+	if res.Request != nil {
+		redacted := *res
+		redacted.Request = res.Request.Clone(res.Request.Context())
+		redacted.Request.Header.Del("Authorization")
+		if u, err := url.Parse(info.URL); err == nil {
+			redacted.Request.URL = u
+		}
+		res = &redacted
+	}
+	for _, h := range c.Hooks {
+		if h.Response != nil {
+			h.Response(ctx, info, res)
+		}
+	}
+}
+
+func (c *Client) hookError(ctx context.Context, info *RequestInfo, res *http.Response, err error) {
+	info.Elapsed = time.Since(info.Start)
+	if res != nil {
+		info.Status = res.StatusCode
+	}
+	info.ErrorClass = classify(err, info.Status)
+	// Transport errors quote the URL, key and all.
+	if urlErr, ok := err.(*url.Error); ok {
+		err = &url.Error{Op: urlErr.Op, URL: info.URL, Err: urlErr.Err}
+	}
+	for _, h := range c.Hooks {
+		if h.Error != nil {
+			h.Error(ctx, info, err)
+		}
+	}
+}
+
+// classify returns the class of an error of a request that ended with the
+// HTTP status code status, or 0 if there was no response.
+func classify(err error, status int) string {
+	var netErr net.Error
+	switch {
+	case status >= 500:
+		return ErrorClassServer
+	case status >= 400:
+		return ErrorClassClient
+	case status != 0:
+		return ErrorClassDecode
+	case err == ErrCircuitOpen:
+		return ErrorClassCircuitOpen
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	}
+	return ErrorClassNetwork
+}
@@ -0,0 +1,276 @@
+package darksky
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405"
+)
+
+// iconNames are the short names used in the daily event summaries.
+var iconNames = map[string]string{
+	"clear-day":           "Clear",
+	"clear-night":         "Clear",
+	"rain":                "Rain",
+	"snow":                "Snow",
+	"sleet":               "Sleet",
+	"wind":                "Windy",
+	"fog":                 "Fog",
+	"cloudy":              "Cloudy",
+	"partly-cloudy-day":   "Partly Cloudy",
+	"partly-cloudy-night": "Partly Cloudy",
+	"hail":                "Hail",
+	"thunderstorm":        "Thunderstorms",
+	"tornado":             "Tornado",
+}
+
+// EncodeICalendar writes the forecast to w as an iCalendar (RFC 5545)
+// calendar named name. Each day of Daily.Data becomes an all-day event and
+// each of the Alerts becomes an event spanning its Time to its Expires. Times
+// are given in the forecast's Timezone, which is included as a VTIMEZONE, or
+// in UTC if it has none.
+//
+// The UIDs of the events only depend on the location and the day or alert,
+// so subscribed calendars update the existing events when the feed is
+// refreshed instead of duplicating them.
+func EncodeICalendar(w io.Writer, f *Forecast, name string) error {
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return ErrUnableToLoadTimezone
+	}
+	bw := bufio.NewWriter(w)
+	cw := &icalWriter{w: bw}
+	stamp := time.Unix(int64(f.Currently.Time), 0).UTC().Format(icalDateTime) + "Z"
+	where := fmt.Sprintf("%f,%f", f.Latitude, f.Longitude)
+
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//sophiaehlen//darksky-client//EN")
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	if name != "" {
+		cw.line("X-WR-CALNAME:" + icalText(name))
+	}
+	if f.Timezone != "" {
+		cw.line("X-WR-TIMEZONE:" + f.Timezone)
+		start, end := f.icalSpan()
+		writeVTimezone(cw, f.Timezone, loc, start, end)
+	}
+	dateTime := func(name string, t int) string {
+		if f.Timezone == "" {
+			return name + ":" + time.Unix(int64(t), 0).UTC().Format(icalDateTime) + "Z"
+		}
+		return name + ";TZID=" + f.Timezone + ":" + time.Unix(int64(t), 0).In(loc).Format(icalDateTime)
+	}
+
+	for _, d := range f.Daily.Data {
+		day := time.Unix(int64(d.Time), 0).In(loc)
+		summary := iconNames[d.Icon]
+		if summary == "" {
+			summary = d.Summary
+		}
+		cw.line("BEGIN:VEVENT")
+		cw.line(fmt.Sprintf("UID:daily-%s@%s.darksky", day.Format(icalDate), where))
+		cw.line("DTSTAMP:" + stamp)
+		cw.line("DTSTART;VALUE=DATE:" + day.Format(icalDate))
+		cw.line("DTEND;VALUE=DATE:" + day.AddDate(0, 0, 1).Format(icalDate))
+		cw.line("SUMMARY:" + icalText(fmt.Sprintf("%s, %.0f°/%.0f°", summary, d.TemperatureHigh, d.TemperatureLow)))
+		cw.line("DESCRIPTION:" + icalText(d.Summary))
+		cw.line("GEO:" + fmt.Sprintf("%f;%f", f.Latitude, f.Longitude))
+		cw.line("TRANSP:TRANSPARENT")
+		cw.line("END:VEVENT")
+	}
+	for _, a := range f.Alerts {
+		id := a.URI
+		if id == "" {
+			id = fmt.Sprintf("%s %d", a.Title, a.Time)
+		}
+		cw.line("BEGIN:VEVENT")
+		cw.line(fmt.Sprintf("UID:alert-%x@%s.darksky", sha1.Sum([]byte(id)), where))
+		cw.line("DTSTAMP:" + stamp)
+		cw.line(dateTime("DTSTART", a.Time))
+		cw.line(dateTime("DTEND", a.Expires))
+		cw.line("SUMMARY:" + icalText(a.Title))
+		cw.line("DESCRIPTION:" + icalText(strings.TrimSpace(a.Description)))
+		if a.URI != "" {
+			cw.line("URL:" + a.URI)
+		}
+		cw.line("GEO:" + fmt.Sprintf("%f;%f", f.Latitude, f.Longitude))
+		cw.line("END:VEVENT")
+	}
+	cw.line("END:VCALENDAR")
+	if cw.err != nil {
+		return cw.err
+	}
+	return bw.Flush()
+}
+
+// icalSpan returns the earliest and latest times used by the events of the
+// forecast.
+func (f *Forecast) icalSpan() (time.Time, time.Time) {
+	min, max := int64(f.Currently.Time), int64(f.Currently.Time)
+	span := func(ts ...int) {
+		for _, t := range ts {
+			if int64(t) < min {
+				min = int64(t)
+			}
+			if int64(t) > max {
+				max = int64(t)
+			}
+		}
+	}
+	for _, d := range f.Daily.Data {
+		span(d.Time, d.Time+48*60*60)
+	}
+	for _, a := range f.Alerts {
+		span(a.Time, a.Expires)
+	}
+	return time.Unix(min, 0), time.Unix(max, 0)
+}
+
+// writeVTimezone writes a VTIMEZONE describing loc from start to end: the
+// observance in effect at start followed by one per offset transition.
+func writeVTimezone(cw *icalWriter, tzid string, loc *time.Location, start, end time.Time) {
+	observance := func(at time.Time, from int) {
+		name, to := at.In(loc).Zone()
+		kind := "STANDARD"
+		if at.In(loc).IsDST() {
+			kind = "DAYLIGHT"
+		}
+		cw.line("BEGIN:" + kind)
+		cw.line("DTSTART:" + at.In(time.FixedZone("", from)).Format(icalDateTime))
+		cw.line("TZOFFSETFROM:" + icalOffset(from))
+		cw.line("TZOFFSETTO:" + icalOffset(to))
+		cw.line("TZNAME:" + name)
+		cw.line("END:" + kind)
+	}
+
+	cw.line("BEGIN:VTIMEZONE")
+	cw.line("TZID:" + tzid)
+	// Start the first observance on the local midnight before any event.
+	first := start.In(loc)
+	first = time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	_, offset := first.Zone()
+	observance(first, offset)
+	for t := first; t.Before(end); {
+		next := nextTransition(t, end, loc)
+		if next.IsZero() {
+			break
+		}
+		observance(next, offset)
+		_, offset = next.In(loc).Zone()
+		t = next
+	}
+	cw.line("END:VTIMEZONE")
+}
+
+// nextTransition returns the first instant after t and before end at which
+// the UTC offset of loc changes, or the zero time if there is none.
+func nextTransition(t, end time.Time, loc *time.Location) time.Time {
+	_, offset := t.In(loc).Zone()
+	// Step by hours to find the hour containing the change, then narrow it
+	// down to the second.
+	lo := t
+	for hi := t.Add(time.Hour); ; hi = hi.Add(time.Hour) {
+		if _, o := hi.In(loc).Zone(); o != offset {
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, o := mid.In(loc).Zone(); o != offset {
+					hi = mid
+				} else {
+					lo = mid
+				}
+			}
+			return hi.Truncate(time.Second)
+		}
+		if !hi.Before(end) {
+			return time.Time{}
+		}
+		lo = hi
+	}
+}
+
+// icalOffset formats a UTC offset in seconds as +HHMM.
+func icalOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+}
+
+// icalText escapes a TEXT property value.
+func icalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icalWriter writes content lines, folding them at 75 octets and terminating
+// them with CRLF as RFC 5545 requires. The first write error is kept in err.
+type icalWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (cw *icalWriter) line(s string) {
+	if cw.err != nil {
+		return
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := utf8.RuneLen(r)
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+	_, cw.err = io.WriteString(cw.w, b.String())
+}
+
+// ICalendarHandler returns an http.Handler that serves an iCalendar feed of
+// the forecast for a named location at any path ending in /{name}.ics. The
+// coordinates of a name are found with lookup; unknown names are not found.
+func ICalendarHandler(c Provider, lookup func(name string) (lat, long float64, ok bool)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := path.Base(r.URL.Path)
+		if !strings.HasSuffix(base, ".ics") {
+			http.NotFound(w, r)
+			return
+		}
+		name := strings.TrimSuffix(base, ".ics")
+		lat, long, ok := lookup(name)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fc, err := c.ForecastContext(r.Context(), lat, long)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		var buf bytes.Buffer
+		err = EncodeICalendar(&buf, fc, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", base))
+		buf.WriteTo(w)
+	})
+}
@@ -0,0 +1,225 @@
+package darksky_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// icalLines unfolds an iCalendar document into its content lines.
+func icalLines(t *testing.T, doc string) []string {
+	if !strings.HasSuffix(doc, "\r\n") {
+		t.Fatalf("document does not end with CRLF")
+	}
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSuffix(doc, "\r\n"), "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("line %q is longer than 75 octets", l)
+		}
+		if strings.HasPrefix(l, " ") && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func countLines(lines []string, prefix string) int {
+	n := 0
+	for _, l := range lines {
+		if strings.HasPrefix(l, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestEncodeICalendar(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	var buf bytes.Buffer
+	err := darksky.EncodeICalendar(&buf, fc, "Southern Terminus")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	lines := icalLines(t, buf.String())
+
+	if lines[0] != "BEGIN:VCALENDAR" || lines[len(lines)-1] != "END:VCALENDAR" {
+		t.Errorf("document is not wrapped in a VCALENDAR")
+	}
+	if got := countLines(lines, "BEGIN:VEVENT"); got != len(fc.Daily.Data)+len(fc.Alerts) {
+		t.Errorf("VEVENT count = %d; want %d", got, len(fc.Daily.Data)+len(fc.Alerts))
+	}
+	for _, want := range []string{
+		"X-WR-CALNAME:Southern Terminus",
+		"TZID:America/Los_Angeles",
+		"TZOFFSETTO:-0800",
+		"DTSTART;VALUE=DATE:20191217",
+		"DTEND;VALUE=DATE:20191218",
+		`SUMMARY:Windy\, 51°/36°`,
+		"SUMMARY:High Wind Warning",
+		"DTSTART;TZID=America/Los_Angeles:20191217T020200",
+		"DTEND;TZID=America/Los_Angeles:20191217T220000",
+	} {
+		if countLines(lines, want) == 0 {
+			t.Errorf("document is missing %q", want)
+		}
+	}
+	if got := countLines(lines, "BEGIN:DAYLIGHT"); got != 0 {
+		t.Errorf("DAYLIGHT observances = %d; want 0 for a December forecast", got)
+	}
+}
+
+func TestEncodeICalendar_StableUIDs(t *testing.T) {
+	uids := func(fc *darksky.Forecast) []string {
+		var buf bytes.Buffer
+		err := darksky.EncodeICalendar(&buf, fc, "")
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		var got []string
+		for _, l := range icalLines(t, buf.String()) {
+			if strings.HasPrefix(l, "UID:") {
+				got = append(got, l)
+			}
+		}
+		return got
+	}
+	first := loadForecast(t, "SouthernTerminus.json")
+	refreshed := loadForecast(t, "SouthernTerminus.json")
+	refreshed.Currently.Time += 3600
+	refreshed.Daily.Data[0].TemperatureHigh++
+
+	a, b := uids(first), uids(refreshed)
+	if len(a) != len(b) {
+		t.Fatalf("len(UIDs) = %d, %d; want equal", len(a), len(b))
+	}
+	seen := make(map[string]bool)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("UID[%d] = %q after a refresh; want %q", i, b[i], a[i])
+		}
+		if seen[a[i]] {
+			t.Errorf("UID %q is not unique", a[i])
+		}
+		seen[a[i]] = true
+	}
+}
+
+func TestEncodeICalendar_DaylightSaving(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	// Move the forecast to the week daylight saving time starts in 2020.
+	shift := 1583625600 - fc.Daily.Data[0].Time
+	fc.Currently.Time += shift
+	for i := range fc.Daily.Data {
+		fc.Daily.Data[i].Time += shift
+	}
+	fc.Alerts = nil
+
+	var buf bytes.Buffer
+	err := darksky.EncodeICalendar(&buf, fc, "")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	lines := icalLines(t, buf.String())
+	for _, want := range []string{
+		"BEGIN:DAYLIGHT",
+		"DTSTART:20200308T020000",
+		"TZOFFSETFROM:-0800",
+		"TZOFFSETTO:-0700",
+		"TZNAME:PDT",
+	} {
+		if countLines(lines, want) == 0 {
+			t.Errorf("document is missing %q", want)
+		}
+	}
+}
+
+func TestEncodeICalendar_UnknownTimezone(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	fc.Timezone = "Not/AZone"
+	err := darksky.EncodeICalendar(ioutil.Discard, fc, "")
+	if err != darksky.ErrUnableToLoadTimezone {
+		t.Errorf("err = %v; want %v", err, darksky.ErrUnableToLoadTimezone)
+	}
+}
+
+func TestEncodeICalendar_NoTimezone(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	fc.Timezone = ""
+	fc.Alerts = []darksky.Alert{{Title: "Wind Advisory", Time: 1576605600, Expires: 1576648800}}
+	var buf bytes.Buffer
+	if err := darksky.EncodeICalendar(&buf, fc, ""); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	lines := icalLines(t, buf.String())
+	if n := countLines(lines, "BEGIN:VTIMEZONE") + countLines(lines, "X-WR-TIMEZONE") + countLines(lines, "DTSTART;TZID"); n != 0 {
+		t.Errorf("document has %d timezone lines; want 0", n)
+	}
+	for _, want := range []string{"DTSTART:20191217T180000Z", "DTEND:20191218T060000Z"} {
+		if countLines(lines, want) != 1 {
+			t.Errorf("document has no %q line", want)
+		}
+	}
+}
+
+func TestICalendarHandler(t *testing.T) {
+	body, err := ioutil.ReadFile("SouthernTerminus.json")
+	if err != nil {
+		t.Fatalf("failed to read the sample forecast. err = %v", err)
+	}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer api.Close()
+	c := &darksky.Client{Key: "key", BaseURL: api.URL}
+	lookup := func(name string) (float64, float64, bool) {
+		if name == "jacumba" {
+			return stLat, stLong, true
+		}
+		return 0, 0, false
+	}
+	handler := darksky.ICalendarHandler(c, lookup)
+
+	tests := map[string]struct {
+		path       string
+		wantStatus int
+	}{
+		"known location":   {path: "/calendars/jacumba.ics", wantStatus: http.StatusOK},
+		"unknown location": {path: "/calendars/boston.ics", wantStatus: http.StatusNotFound},
+		"not a feed":       {path: "/calendars/jacumba", wantStatus: http.StatusNotFound},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d; want %d", rec.Code, tc.wantStatus)
+			}
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+				t.Errorf("Content-Type = %q; want text/calendar", ct)
+			}
+			if !strings.HasPrefix(rec.Body.String(), "BEGIN:VCALENDAR\r\n") {
+				t.Errorf("body is not an iCalendar document")
+			}
+		})
+	}
+
+	// The forecast is fetched with the request's context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/calendars/jacumba.ics", nil).WithContext(ctx))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status = %d; want %d for a cancelled request", rec.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,84 @@
+package darksky
+
+import (
+	"math"
+	"time"
+)
+
+// At returns the conditions at t, interpolated from the hourly data points
+// either side of it. Numeric fields are interpolated linearly, WindBearing
+// the shorter way around the compass, and Summary, Icon and PrecipType are
+// taken from the nearer point. Where the minutely data covers t, the
+// precipitation fields are interpolated from it instead. It returns
+// ErrOutOfRange if t is outside the hourly data.
+func (f *Forecast) At(t time.Time) (HourlyDataPoint, error) {
+	data := f.Hourly.Data
+	unix := float64(t.UnixNano()) / float64(time.Second)
+	if len(data) == 0 || unix < float64(data[0].Time) || unix > float64(data[len(data)-1].Time) {
+		return HourlyDataPoint{}, ErrOutOfRange
+	}
+	i := 0
+	for i < len(data)-1 && float64(data[i+1].Time) < unix {
+		i++
+	}
+	if i == len(data)-1 {
+		return data[i], nil
+	}
+	a, b := data[i], data[i+1]
+	frac := (unix - float64(a.Time)) / float64(b.Time-a.Time)
+	lerp := func(x, y float64) float64 { return x + frac*(y-x) }
+
+	p := a
+	if frac >= 0.5 {
+		p = b
+	}
+	p.Time = int(t.Unix())
+	p.PrecipIntensity = lerp(a.PrecipIntensity, b.PrecipIntensity)
+	p.PrecipProbability = lerp(a.PrecipProbability, b.PrecipProbability)
+	p.Temperature = lerp(a.Temperature, b.Temperature)
+	p.ApparentTemperature = lerp(a.ApparentTemperature, b.ApparentTemperature)
+	p.DewPoint = lerp(a.DewPoint, b.DewPoint)
+	p.Humidity = lerp(a.Humidity, b.Humidity)
+	p.Pressure = lerp(a.Pressure, b.Pressure)
+	p.WindSpeed = lerp(a.WindSpeed, b.WindSpeed)
+	p.WindGust = lerp(a.WindGust, b.WindGust)
+	p.WindBearing = bearingLerp(a.WindBearing, b.WindBearing, frac)
+	p.CloudCover = lerp(a.CloudCover, b.CloudCover)
+	p.UvIndex = int(math.Round(lerp(float64(a.UvIndex), float64(b.UvIndex))))
+	p.Visibility = lerp(a.Visibility, b.Visibility)
+	p.Ozone = lerp(a.Ozone, b.Ozone)
+	f.Minutely.refine(&p, unix)
+	return p, nil
+}
+
+// refine replaces the precipitation fields of p with those interpolated from
+// the minutely data, if it covers unix.
+func (m *MinutelyDataBlock) refine(p *HourlyDataPoint, unix float64) {
+	data := m.Data
+	if len(data) < 2 || unix < float64(data[0].Time) || unix > float64(data[len(data)-1].Time) {
+		return
+	}
+	i := 0
+	for i < len(data)-2 && float64(data[i+1].Time) < unix {
+		i++
+	}
+	a, b := data[i], data[i+1]
+	frac := (unix - float64(a.Time)) / float64(b.Time-a.Time)
+	p.PrecipIntensity = a.PrecipIntensity + frac*(b.PrecipIntensity-a.PrecipIntensity)
+	p.PrecipProbability = a.PrecipProbability + frac*(b.PrecipProbability-a.PrecipProbability)
+	nearest := a
+	if frac >= 0.5 {
+		nearest = b
+	}
+	if nearest.PrecipType != "" {
+		p.PrecipType = nearest.PrecipType
+	}
+}
+
+// bearingLerp interpolates between two bearings in degrees along the
+// shorter way around the compass.
+func bearingLerp(a, b int, f float64) int {
+	d := math.Mod(float64(b-a)+540, 360) - 180
+	bearing := math.Mod(float64(a)+f*d+360, 360)
+	return int(math.Round(bearing)) % 360
+}
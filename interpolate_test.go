@@ -0,0 +1,126 @@
+package darksky_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+func TestForecast_At(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	first := fc.Hourly.Data[0]
+	second := fc.Hourly.Data[1]
+	last := fc.Hourly.Data[len(fc.Hourly.Data)-1]
+
+	type checkFn func(t *testing.T, p darksky.HourlyDataPoint, err error)
+	check := func(fns ...checkFn) []checkFn { return fns }
+
+	hasNoErr := func() checkFn {
+		return func(t *testing.T, p darksky.HourlyDataPoint, err error) {
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+		}
+	}
+	isOutOfRange := func() checkFn {
+		return func(t *testing.T, p darksky.HourlyDataPoint, err error) {
+			if err != darksky.ErrOutOfRange {
+				t.Fatalf("err = %v; want %v", err, darksky.ErrOutOfRange)
+			}
+		}
+	}
+	hasTemperature := func(want float64) checkFn {
+		return func(t *testing.T, p darksky.HourlyDataPoint, err error) {
+			if math.Abs(p.Temperature-want) > 1e-9 {
+				t.Errorf("Temperature = %f; want %f", p.Temperature, want)
+			}
+		}
+	}
+	hasIcon := func(want string) checkFn {
+		return func(t *testing.T, p darksky.HourlyDataPoint, err error) {
+			if p.Icon != want {
+				t.Errorf("Icon = %q; want %q", p.Icon, want)
+			}
+		}
+	}
+	hasTime := func(want int64) checkFn {
+		return func(t *testing.T, p darksky.HourlyDataPoint, err error) {
+			if int64(p.Time) != want {
+				t.Errorf("Time = %d; want %d", p.Time, want)
+			}
+		}
+	}
+
+	tests := map[string]struct {
+		at     time.Time
+		checks []checkFn
+	}{
+		"on the hour": {
+			at:     time.Unix(int64(first.Time), 0),
+			checks: check(hasNoErr(), hasTemperature(first.Temperature), hasIcon(first.Icon)),
+		},
+		"a quarter past": {
+			at: time.Unix(int64(first.Time)+15*60, 0),
+			checks: check(hasNoErr(), hasTime(int64(first.Time)+15*60),
+				hasTemperature(first.Temperature+(second.Temperature-first.Temperature)/4), hasIcon(first.Icon)),
+		},
+		"three quarters past": {
+			at:     time.Unix(int64(first.Time)+45*60, 0),
+			checks: check(hasNoErr(), hasTemperature(first.Temperature+(second.Temperature-first.Temperature)*3/4), hasIcon(second.Icon)),
+		},
+		"last point": {
+			at:     time.Unix(int64(last.Time), 0),
+			checks: check(hasNoErr(), hasTemperature(last.Temperature)),
+		},
+		"before the forecast": {
+			at:     time.Unix(int64(first.Time)-1, 0),
+			checks: check(isOutOfRange()),
+		},
+		"after the forecast": {
+			at:     time.Unix(int64(last.Time)+1, 0),
+			checks: check(isOutOfRange()),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := fc.At(tc.at)
+			for _, check := range tc.checks {
+				check(t, p, err)
+			}
+		})
+	}
+
+	if _, err := (&darksky.Forecast{}).At(time.Now()); err != darksky.ErrOutOfRange {
+		t.Errorf("empty forecast err = %v; want %v", err, darksky.ErrOutOfRange)
+	}
+}
+
+func TestForecast_At_windBearing(t *testing.T) {
+	tests := map[string]struct {
+		from, to int
+		want     int
+	}{
+		"across north":       {from: 350, to: 10, want: 0},
+		"across north again": {from: 20, to: 320, want: 350},
+		"no wrap":            {from: 90, to: 180, want: 135},
+		"unchanged":          {from: 51, to: 51, want: 51},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var fc darksky.Forecast
+			fc.Hourly.Data = []darksky.HourlyDataPoint{
+				{Time: 0, WindBearing: tc.from},
+				{Time: 3600, WindBearing: tc.to},
+			}
+			p, err := fc.At(time.Unix(1800, 0))
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if p.WindBearing != tc.want {
+				t.Errorf("WindBearing = %d; want %d", p.WindBearing, tc.want)
+			}
+		})
+	}
+}
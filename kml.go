@@ -0,0 +1,103 @@
+package darksky
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// KMLIconBaseURL is the default location of the icon images used by the KML
+// styles, for a KMLEncoder without an IconBaseURL.
+const KMLIconBaseURL = "https://darksky.net/images/weather-icons/"
+
+// KMLEncoder builds KML documents of forecasts. The zero value uses the
+// defaults and is ready to use.
+type KMLEncoder struct {
+	// IconBaseURL is the location of the icon images, KMLIconBaseURL if
+	// empty. The image for an icon is found at IconBaseURL + icon + ".png".
+	IconBaseURL string
+}
+
+// KML is a KML 2.2 document with a Placemark for each forecast.
+type KML struct {
+	XMLName  xml.Name    `xml:"http://www.opengis.net/kml/2.2 kml"`
+	Document KMLDocument `xml:"Document"`
+}
+
+// KMLDocument holds the shared icon styles and the placemarks.
+type KMLDocument struct {
+	Styles     []KMLStyle     `xml:"Style"`
+	Placemarks []KMLPlacemark `xml:"Placemark"`
+}
+
+// KMLStyle is the icon style for placemarks of a single Dark Sky icon.
+type KMLStyle struct {
+	ID      string `xml:"id,attr"`
+	IconURL string `xml:"IconStyle>Icon>href"`
+}
+
+// KMLPlacemark locates a single forecast.
+type KMLPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	StyleURL    string `xml:"styleUrl"`
+	Coordinates string `xml:"Point>coordinates"`
+}
+
+// NewKML returns a KML document with a Placemark for each of the forecasts, in
+// order, styled by their current Icon. The icon images are loaded from
+// KMLIconBaseURL.
+func NewKML(forecasts ...*Forecast) *KML {
+	return (&KMLEncoder{}).KML(forecasts...)
+}
+
+// EncodeKML writes the KML document of the forecasts to w.
+func EncodeKML(w io.Writer, forecasts ...*Forecast) error {
+	return (&KMLEncoder{}).Encode(w, forecasts...)
+}
+
+// KML returns a KML document with a Placemark for each of the forecasts, in
+// order, styled by their current Icon.
+func (e *KMLEncoder) KML(forecasts ...*Forecast) *KML {
+	base := e.IconBaseURL
+	if base == "" {
+		base = KMLIconBaseURL
+	}
+	k := &KML{}
+	styled := make(map[string]bool)
+	for _, f := range forecasts {
+		icon := f.Currently.Icon
+		if icon == "" {
+			icon = "default"
+		}
+		if !styled[icon] {
+			styled[icon] = true
+			k.Document.Styles = append(k.Document.Styles, KMLStyle{
+				ID:      kmlStyleID(icon),
+				IconURL: base + icon + ".png",
+			})
+		}
+		k.Document.Placemarks = append(k.Document.Placemarks, KMLPlacemark{
+			Name:        fmt.Sprintf("%.0f°F %s", f.Currently.Temperature, f.Currently.Summary),
+			Description: f.Daily.Summary,
+			StyleURL:    "#" + kmlStyleID(icon),
+			Coordinates: strconv.FormatFloat(f.Longitude, 'f', -1, 64) + "," + strconv.FormatFloat(f.Latitude, 'f', -1, 64) + ",0",
+		})
+	}
+	return k
+}
+
+// Encode writes the KML document of the forecasts to w.
+func (e *KMLEncoder) Encode(w io.Writer, forecasts ...*Forecast) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(e.KML(forecasts...))
+}
+
+func kmlStyleID(icon string) string {
+	return "icon-" + icon
+}
@@ -0,0 +1,73 @@
+package darksky_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+func TestEncodeKML(t *testing.T) {
+	st := loadForecast(t, "SouthernTerminus.json")
+	boston := loadForecast(t, "Untitled-1.json")
+	other := loadForecast(t, "SouthernTerminus.json")
+	other.Latitude, other.Longitude = 32.7157, -117.1611
+
+	var buf bytes.Buffer
+	err := darksky.EncodeKML(&buf, st, boston, other)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("document does not start with the XML header")
+	}
+
+	var got darksky.KML
+	err = xml.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	want := darksky.NewKML(st, boston, other)
+	want.XMLName = got.XMLName
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("decoded = %+v; want %+v", got, want)
+	}
+	if got.XMLName.Space != "http://www.opengis.net/kml/2.2" {
+		t.Errorf("namespace = %q; want the KML 2.2 namespace", got.XMLName.Space)
+	}
+	if len(got.Document.Styles) != 2 {
+		t.Errorf("len(Styles) = %d; want 2, one per distinct icon", len(got.Document.Styles))
+	}
+	if len(got.Document.Placemarks) != 3 {
+		t.Fatalf("len(Placemarks) = %d; want 3", len(got.Document.Placemarks))
+	}
+	pm := got.Document.Placemarks[0]
+	if pm.Coordinates != "-116.466988,32.58972,0" {
+		t.Errorf("Coordinates = %q; want long,lat,alt", pm.Coordinates)
+	}
+	if pm.StyleURL != "#icon-wind" {
+		t.Errorf("StyleURL = %q; want #icon-wind", pm.StyleURL)
+	}
+}
+
+func TestKMLEncoder_IconBaseURL(t *testing.T) {
+	st := loadForecast(t, "SouthernTerminus.json")
+	tests := map[string]struct {
+		base string
+		want string
+	}{
+		"default": {want: darksky.KMLIconBaseURL + "wind.png"},
+		"custom":  {base: "https://example.com/icons/", want: "https://example.com/icons/wind.png"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			k := (&darksky.KMLEncoder{IconBaseURL: tc.base}).KML(st)
+			if got := k.Document.Styles[0].IconURL; got != tc.want {
+				t.Errorf("IconURL = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package darksky
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// DefaultPrecision is the number of decimal places coordinates are sent to
+// the API with when Client.Precision is not set.
+const DefaultPrecision = 6
+
+// Location is a point on the Earth's surface in decimal degrees.
+type Location struct {
+	Lat  float64
+	Long float64
+}
+
+// ValidationError is returned when a request is invalid and was therefore
+// not sent to the API.
+type ValidationError struct {
+	Field  string
+	Value  float64
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("Invalid %s %v: %s", e.Field, e.Value, e.Reason)
+}
+
+// Validate returns a *ValidationError if the latitude is not within
+// [-90, 90], the longitude is not within [-180, 180] or either is NaN or
+// infinite.
+func (l Location) Validate() error {
+	if err := validateCoord("Latitude", l.Lat, 90); err != nil {
+		return err
+	}
+	return validateCoord("Longitude", l.Long, 180)
+}
+
+func validateCoord(field string, v, max float64) error {
+	switch {
+	case math.IsNaN(v):
+		return &ValidationError{Field: field, Value: v, Reason: "must be a number"}
+	case math.IsInf(v, 0):
+		return &ValidationError{Field: field, Value: v, Reason: "must be finite"}
+	case v < -max || v > max:
+		return &ValidationError{Field: field, Value: v, Reason: fmt.Sprintf("must be within [%v, %v]", -max, max)}
+	}
+	return nil
+}
+
+// Normalize returns the location with its longitude wrapped into
+// [-180, 180), so that for example 181 becomes -179. The latitude is left
+// as is. Clients reject longitudes outside [-180, 180], so normalize
+// locations that may wrap before requesting their forecasts.
+func (l Location) Normalize() Location {
+	if math.IsNaN(l.Long) || math.IsInf(l.Long, 0) {
+		return l
+	}
+	long := math.Mod(l.Long+180, 360)
+	if long < 0 {
+		long += 360
+	}
+	l.Long = long - 180
+	return l
+}
+
+// Format returns the location in the lat,long form used in API paths, with
+// precision decimal places.
+func (l Location) Format(precision int) string {
+	return strconv.FormatFloat(l.Lat, 'f', precision, 64) + "," + strconv.FormatFloat(l.Long, 'f', precision, 64)
+}
+
+// String returns the location with DefaultPrecision decimal places.
+func (l Location) String() string {
+	return l.Format(DefaultPrecision)
+}
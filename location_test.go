@@ -0,0 +1,127 @@
+package darksky_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// coord generates coordinates spread well beyond the valid ranges.
+type coord float64
+
+func (coord) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(coord((r.Float64()*2 - 1) * 720))
+}
+
+func TestLocation_Validate(t *testing.T) {
+	t.Run("in range is valid", func(t *testing.T) {
+		f := func(lat, long coord) bool {
+			l := darksky.Location{Lat: math.Mod(float64(lat), 90), Long: math.Mod(float64(long), 180)}
+			return l.Validate() == nil
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("out of range is invalid", func(t *testing.T) {
+		f := func(lat, long coord) bool {
+			l := darksky.Location{Lat: float64(lat), Long: float64(long)}
+			inRange := math.Abs(l.Lat) <= 90 && math.Abs(l.Long) <= 180
+			err := l.Validate()
+			if inRange {
+				return err == nil
+			}
+			_, ok := err.(*darksky.ValidationError)
+			return ok
+		}
+		if err := quick.Check(f, nil); err != nil {
+			t.Error(err)
+		}
+	})
+
+	tests := map[string]struct {
+		loc       darksky.Location
+		wantField string
+	}{
+		"north pole":         {loc: darksky.Location{Lat: 90, Long: 0}},
+		"antimeridian":       {loc: darksky.Location{Lat: 0, Long: -180}},
+		"NaN latitude":       {loc: darksky.Location{Lat: math.NaN(), Long: 0}, wantField: "Latitude"},
+		"NaN longitude":      {loc: darksky.Location{Lat: 0, Long: math.NaN()}, wantField: "Longitude"},
+		"infinite latitude":  {loc: darksky.Location{Lat: math.Inf(-1), Long: 0}, wantField: "Latitude"},
+		"infinite longitude": {loc: darksky.Location{Lat: 0, Long: math.Inf(1)}, wantField: "Longitude"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.loc.Validate()
+			if tc.wantField == "" {
+				if err != nil {
+					t.Fatalf("err = %v; want nil", err)
+				}
+				return
+			}
+			verr, ok := err.(*darksky.ValidationError)
+			if !ok {
+				t.Fatalf("err = %v; want *ValidationError", err)
+			}
+			if verr.Field != tc.wantField {
+				t.Errorf("Field = %q; want %q", verr.Field, tc.wantField)
+			}
+		})
+	}
+}
+
+func TestLocation_Normalize(t *testing.T) {
+	f := func(lat, long coord) bool {
+		l := darksky.Location{Lat: math.Mod(float64(lat), 90), Long: float64(long)}
+		n := l.Normalize()
+		if n.Lat != l.Lat || n.Long < -180 || n.Long >= 180 {
+			return false
+		}
+		if n.Validate() != nil {
+			return false
+		}
+		// Wrapping must not move the point.
+		return math.Abs(math.Sin(n.Long*math.Pi/180)-math.Sin(l.Long*math.Pi/180)) < 1e-9 &&
+			math.Abs(math.Cos(n.Long*math.Pi/180)-math.Cos(l.Long*math.Pi/180)) < 1e-9
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+
+	for long, want := range map[float64]float64{181: -179, -181: 179, 180: -180, 540: -180, -116.5: -116.5} {
+		if got := (darksky.Location{Long: long}).Normalize().Long; got != want {
+			t.Errorf("Normalize(%v).Long = %v; want %v", long, got, want)
+		}
+	}
+}
+
+func TestLocation_Format(t *testing.T) {
+	f := func(lat, long coord, p uint8) bool {
+		precision := int(p % 10)
+		l := darksky.Location{Lat: float64(lat), Long: float64(long)}
+		parts := strings.Split(l.Format(precision), ",")
+		if len(parts) != 2 {
+			return false
+		}
+		tolerance := math.Pow(10, -float64(precision)) / 2 * 1.000001
+		for i, want := range []float64{l.Lat, l.Long} {
+			got, err := strconv.ParseFloat(parts[i], 64)
+			if err != nil || math.Abs(got-want) > tolerance {
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+	if got := (darksky.Location{Lat: 32.58972, Long: -116.466988}).String(); got != "32.589720,-116.466988" {
+		t.Errorf("String() = %q; want %q", got, "32.589720,-116.466988")
+	}
+}
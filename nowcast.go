@@ -0,0 +1,138 @@
+package darksky
+
+import (
+	"math"
+	"time"
+)
+
+// IntensityCategory describes precipitation intensity the way Dark Sky's
+// summaries do.
+type IntensityCategory int
+
+const (
+	IntensityNone IntensityCategory = iota
+	IntensityVeryLight
+	IntensityLight
+	IntensityModerate
+	IntensityHeavy
+)
+
+// The lower bounds of each intensity category in inches of liquid water per
+// hour. Multiply by 25.4 for forecasts in SI units.
+const (
+	VeryLightIntensity = 0.002
+	LightIntensity     = 0.017
+	ModerateIntensity  = 0.1
+	HeavyIntensity     = 0.4
+)
+
+var intensityNames = []string{"none", "very light", "light", "moderate", "heavy"}
+
+func (c IntensityCategory) String() string {
+	if c < 0 || int(c) >= len(intensityNames) {
+		return "unknown"
+	}
+	return intensityNames[c]
+}
+
+// Category returns the category of an intensity in inches per hour.
+func Category(intensity float64) IntensityCategory {
+	switch {
+	case intensity >= HeavyIntensity:
+		return IntensityHeavy
+	case intensity >= ModerateIntensity:
+		return IntensityModerate
+	case intensity >= LightIntensity:
+		return IntensityLight
+	case intensity >= VeryLightIntensity:
+		return IntensityVeryLight
+	}
+	return IntensityNone
+}
+
+// Category returns the category of the point's intensity.
+func (p MinutelyDataPoint) Category() IntensityCategory {
+	return Category(p.PrecipIntensity)
+}
+
+// Wet reports whether precipitation is more likely than not during the
+// minute and at least very light.
+func (p MinutelyDataPoint) Wet() bool {
+	return p.PrecipProbability >= 0.5 && p.Category() != IntensityNone
+}
+
+// StartsIn returns how long after now precipitation starts. It is zero if it
+// is already precipitating at now, and ok is false if no precipitation is
+// expected during the rest of the hour.
+func (m *MinutelyDataBlock) StartsIn(now time.Time) (d time.Duration, ok bool) {
+	for _, p := range m.from(now) {
+		if p.Wet() {
+			return clampDuration(time.Unix(int64(p.Time), 0).Sub(now)), true
+		}
+	}
+	return 0, false
+}
+
+// StopsIn returns how long after now the next spell of precipitation stops,
+// including one under way at now. ok is false if there is no such spell or
+// it lasts beyond the hour.
+func (m *MinutelyDataBlock) StopsIn(now time.Time) (d time.Duration, ok bool) {
+	wet := false
+	for _, p := range m.from(now) {
+		if p.Wet() {
+			wet = true
+			continue
+		}
+		if wet {
+			return clampDuration(time.Unix(int64(p.Time), 0).Sub(now)), true
+		}
+	}
+	return 0, false
+}
+
+// Peak returns the minute with the highest intensity. ok is false if no
+// precipitation is expected during the hour.
+func (m *MinutelyDataBlock) Peak() (p MinutelyDataPoint, ok bool) {
+	for _, d := range m.Data {
+		if d.PrecipIntensity > p.PrecipIntensity {
+			p, ok = d, true
+		}
+	}
+	return p, ok
+}
+
+// Accumulation returns the expected amount of precipitation during the hour,
+// weighting each minute's intensity by its probability, and its standard
+// deviation, which accounts for both the probability and each minute's
+// PrecipIntensityError. Both are in inches of liquid water, or millimetres
+// for forecasts in SI units.
+func (m *MinutelyDataBlock) Accumulation() (expected, stddev float64) {
+	variance := 0.0
+	for _, p := range m.Data {
+		// A minute's intensity is zero with probability 1-P and otherwise
+		// has a mean of PrecipIntensity and a standard deviation of
+		// PrecipIntensityError.
+		prob, mean, err := p.PrecipProbability, p.PrecipIntensity, p.PrecipIntensityError
+		expected += prob * mean / 60
+		variance += (prob*(err*err+mean*mean) - prob*prob*mean*mean) / 3600
+	}
+	return expected, math.Sqrt(variance)
+}
+
+// from returns the minutes at and after the one containing now.
+func (m *MinutelyDataBlock) from(now time.Time) []MinutelyDataPoint {
+	unix := now.Unix()
+	for i, p := range m.Data {
+		if int64(p.Time)+60 > unix {
+			return m.Data[i:]
+		}
+	}
+	return nil
+}
+
+func clampDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
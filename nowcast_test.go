@@ -0,0 +1,169 @@
+package darksky_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+// shower is an hour in which a shower starts after 10 minutes, peaks at
+// heavy intensity after 20 and stops after 30.
+func shower(start time.Time) *darksky.MinutelyDataBlock {
+	m := &darksky.MinutelyDataBlock{}
+	for i := 0; i <= 60; i++ {
+		p := darksky.MinutelyDataPoint{Time: int(start.Add(time.Duration(i) * time.Minute).Unix())}
+		if i >= 10 && i < 30 {
+			p.PrecipType, p.PrecipProbability = "rain", 0.8
+			p.PrecipIntensity = 0.05
+			p.PrecipIntensityError = 0.01
+		}
+		if i == 20 {
+			p.PrecipIntensity = 0.45
+		}
+		m.Data = append(m.Data, p)
+	}
+	return m
+}
+
+func TestMinutelyDataBlock_decode(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	if len(fc.Minutely.Data) != 61 {
+		t.Fatalf("len(Minutely.Data) = %d; want 61", len(fc.Minutely.Data))
+	}
+	if _, ok := fc.Minutely.Peak(); ok {
+		t.Errorf("Peak() ok = true; want false for a dry hour")
+	}
+	if _, ok := fc.Minutely.StartsIn(time.Unix(int64(fc.Currently.Time), 0)); ok {
+		t.Errorf("StartsIn() ok = true; want false for a dry hour")
+	}
+	if e, sd := fc.Minutely.Accumulation(); e != 0 || sd != 0 {
+		t.Errorf("Accumulation() = %f, %f; want 0, 0", e, sd)
+	}
+}
+
+func TestMinutelyDataBlock_StartsIn(t *testing.T) {
+	start := time.Unix(1576605840, 0)
+	m := shower(start)
+	tests := map[string]struct {
+		now    time.Time
+		want   time.Duration
+		wantOk bool
+	}{
+		"before":          {now: start, want: 10 * time.Minute, wantOk: true},
+		"between minutes": {now: start.Add(90 * time.Second), want: 510 * time.Second, wantOk: true},
+		"during":          {now: start.Add(15 * time.Minute), want: 0, wantOk: true},
+		"after":           {now: start.Add(45 * time.Minute), wantOk: false},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := m.StartsIn(tc.now)
+			if ok != tc.wantOk || got != tc.want {
+				t.Errorf("StartsIn() = %v, %t; want %v, %t", got, ok, tc.want, tc.wantOk)
+			}
+		})
+	}
+}
+
+func TestMinutelyDataBlock_StopsIn(t *testing.T) {
+	start := time.Unix(1576605840, 0)
+	m := shower(start)
+	tests := map[string]struct {
+		now    time.Time
+		want   time.Duration
+		wantOk bool
+	}{
+		"before":     {now: start, want: 30 * time.Minute, wantOk: true},
+		"during":     {now: start.Add(25 * time.Minute), want: 5 * time.Minute, wantOk: true},
+		"after":      {now: start.Add(45 * time.Minute), wantOk: false},
+		"mid-shower": {now: start.Add(15 * time.Minute), want: 15 * time.Minute, wantOk: true},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := m.StopsIn(tc.now)
+			if ok != tc.wantOk || got != tc.want {
+				t.Errorf("StopsIn() = %v, %t; want %v, %t", got, ok, tc.want, tc.wantOk)
+			}
+		})
+	}
+
+	m.Data = m.Data[10:30]
+	if _, ok := m.StopsIn(start); ok {
+		t.Errorf("StopsIn() ok = true; want false when it rains past the end of the data")
+	}
+}
+
+func TestMinutelyDataBlock_Peak(t *testing.T) {
+	start := time.Unix(1576605840, 0)
+	p, ok := shower(start).Peak()
+	if !ok {
+		t.Fatalf("Peak() ok = false; want true")
+	}
+	if want := start.Add(20 * time.Minute).Unix(); int64(p.Time) != want {
+		t.Errorf("Peak().Time = %d; want %d", p.Time, want)
+	}
+	if p.Category() != darksky.IntensityHeavy {
+		t.Errorf("Peak().Category() = %v; want %v", p.Category(), darksky.IntensityHeavy)
+	}
+}
+
+func TestMinutelyDataBlock_Accumulation(t *testing.T) {
+	m := shower(time.Unix(1576605840, 0))
+	expected, sd := m.Accumulation()
+	// 19 minutes at 0.05 in/h and one at 0.45 in/h, each 80% likely.
+	want := 0.8 * (19*0.05 + 0.45) / 60
+	if math.Abs(expected-want) > 1e-12 {
+		t.Errorf("expected = %f; want %f", expected, want)
+	}
+	if sd <= 0 {
+		t.Errorf("stddev = %f; want positive", sd)
+	}
+
+	// Certain precipitation with no error has no spread.
+	for i := range m.Data {
+		m.Data[i].PrecipIntensityError = 0
+		if m.Data[i].PrecipProbability > 0 {
+			m.Data[i].PrecipProbability = 1
+		}
+	}
+	if _, sd := m.Accumulation(); sd > 1e-12 {
+		t.Errorf("stddev = %f; want 0 for certain precipitation", sd)
+	}
+}
+
+func TestCategory(t *testing.T) {
+	tests := map[float64]darksky.IntensityCategory{
+		0:     darksky.IntensityNone,
+		0.001: darksky.IntensityNone,
+		0.002: darksky.IntensityVeryLight,
+		0.017: darksky.IntensityLight,
+		0.1:   darksky.IntensityModerate,
+		0.39:  darksky.IntensityModerate,
+		0.4:   darksky.IntensityHeavy,
+	}
+	for intensity, want := range tests {
+		if got := darksky.Category(intensity); got != want {
+			t.Errorf("Category(%v) = %v; want %v", intensity, got, want)
+		}
+	}
+	if darksky.IntensityModerate.String() != "moderate" {
+		t.Errorf("String() = %q; want %q", darksky.IntensityModerate.String(), "moderate")
+	}
+}
+
+func TestForecast_At_minutely(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	start := time.Unix(int64(fc.Minutely.Data[0].Time), 0)
+	fc.Minutely = *shower(start)
+	p, err := fc.At(start.Add(20*time.Minute + 30*time.Second))
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if want := (0.45 + 0.05) / 2; math.Abs(p.PrecipIntensity-want) > 1e-9 {
+		t.Errorf("PrecipIntensity = %f; want %f from the minutely data", p.PrecipIntensity, want)
+	}
+	if p.PrecipType != "rain" || p.PrecipProbability != 0.8 {
+		t.Errorf("precipitation = %q, %f; want rain, 0.8", p.PrecipType, p.PrecipProbability)
+	}
+}
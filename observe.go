@@ -0,0 +1,120 @@
+package darksky
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// SlogHooks logs each request to logger: its start at debug level, a
+// forecast received at info level and a failure at error level. URLs are
+// logged with the key redacted.
+func SlogHooks(logger *slog.Logger) Hooks {
+	attrs := func(info *RequestInfo) []slog.Attr {
+		return []slog.Attr{
+			slog.String("endpoint", info.Endpoint),
+			slog.String("method", info.Method),
+			slog.String("url", info.URL),
+			slog.Float64("lat", info.Lat),
+			slog.Float64("long", info.Long),
+		}
+	}
+	return Hooks{
+		RequestStart: func(ctx context.Context, info *RequestInfo) context.Context {
+			logger.LogAttrs(ctx, slog.LevelDebug, "darksky request", attrs(info)...)
+			return ctx
+		},
+		Response: func(ctx context.Context, info *RequestInfo, res *http.Response) {
+			logger.LogAttrs(ctx, slog.LevelInfo, "darksky response", append(attrs(info),
+				slog.Int("status", info.Status),
+				slog.Duration("elapsed", info.Elapsed))...)
+		},
+		Error: func(ctx context.Context, info *RequestInfo, err error) {
+			logger.LogAttrs(ctx, slog.LevelError, "darksky request failed", append(attrs(info),
+				slog.Int("status", info.Status),
+				slog.Duration("elapsed", info.Elapsed),
+				slog.String("class", info.ErrorClass),
+				slog.String("error", err.Error()))...)
+		},
+	}
+}
+
+// Tracer starts spans. Its methods mirror those of OpenTelemetry, so a
+// trace.Tracer from go.opentelemetry.io/otel can be adapted in a few lines.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+type spanKey struct{}
+
+// TraceHooks wraps each request in a span named after its endpoint, e.g.
+// "darksky.forecast", with the coordinates, method and status as
+// attributes.
+func TraceHooks(tracer Tracer) Hooks {
+	end := func(ctx context.Context, info *RequestInfo, err error) {
+		span, ok := ctx.Value(spanKey{}).(Span)
+		if !ok {
+			return
+		}
+		if info.Status != 0 {
+			span.SetAttribute("http.status_code", info.Status)
+		}
+		if err != nil {
+			span.SetAttribute("darksky.error_class", info.ErrorClass)
+			span.RecordError(err)
+		}
+		span.End()
+	}
+	return Hooks{
+		RequestStart: func(ctx context.Context, info *RequestInfo) context.Context {
+			ctx, span := tracer.Start(ctx, "darksky."+info.Endpoint)
+			span.SetAttribute("darksky.latitude", info.Lat)
+			span.SetAttribute("darksky.longitude", info.Long)
+			span.SetAttribute("http.method", info.Method)
+			span.SetAttribute("http.url", info.URL)
+			return context.WithValue(ctx, spanKey{}, span)
+		},
+		Response: func(ctx context.Context, info *RequestInfo, res *http.Response) {
+			end(ctx, info, nil)
+		},
+		Error: end,
+	}
+}
+
+// Metrics records the requests of a Client. Implementations typically wrap
+// Prometheus or OpenTelemetry instruments.
+type Metrics interface {
+	// IncCalls counts a request to the endpoint.
+	IncCalls(endpoint string)
+
+	// ObserveLatency records how long a request to the endpoint took.
+	ObserveLatency(endpoint string, d time.Duration)
+
+	// IncErrors counts a failed request by its error class.
+	IncErrors(endpoint, class string)
+}
+
+// MetricsHooks reports each request to m.
+func MetricsHooks(m Metrics) Hooks {
+	return Hooks{
+		RequestStart: func(ctx context.Context, info *RequestInfo) context.Context {
+			m.IncCalls(info.Endpoint)
+			return ctx
+		},
+		Response: func(ctx context.Context, info *RequestInfo, res *http.Response) {
+			m.ObserveLatency(info.Endpoint, info.Elapsed)
+		},
+		Error: func(ctx context.Context, info *RequestInfo, err error) {
+			m.ObserveLatency(info.Endpoint, info.Elapsed)
+			m.IncErrors(info.Endpoint, info.ErrorClass)
+		},
+	}
+}
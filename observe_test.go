@@ -0,0 +1,137 @@
+package darksky_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/darkskytest"
+)
+
+func TestClient_Hooks(t *testing.T) {
+	server := darkskytest.NewServer(1)
+	defer server.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	tracer := &darkskytest.Tracer{}
+	metrics := &darkskytest.Metrics{}
+	var order []string
+	c := &darksky.Client{
+		Key:     "secret-key",
+		BaseURL: server.URL,
+		Hooks: []darksky.Hooks{
+			darksky.SlogHooks(logger),
+			darksky.TraceHooks(tracer),
+			darksky.MetricsHooks(metrics),
+			{
+				RequestStart: func(ctx context.Context, info *darksky.RequestInfo) context.Context {
+					order = append(order, "start")
+					return nil
+				},
+				Response: func(ctx context.Context, info *darksky.RequestInfo, res *http.Response) {
+					order = append(order, "response")
+					if strings.Contains(res.Request.URL.String(), "secret-key") || res.Request.Header.Get("Authorization") != "" {
+						t.Errorf("Response hook got the key in %v %v", res.Request.URL, res.Request.Header)
+					}
+				},
+				Error: func(ctx context.Context, info *darksky.RequestInfo, err error) {
+					order = append(order, "error:"+info.ErrorClass)
+				},
+			},
+		},
+	}
+
+	if _, err := c.Forecast(stLat, stLong); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	server.Handler().FailNext(1, http.StatusServiceUnavailable)
+	if _, err := c.TimeMachine(stLat, stLong, time.Unix(1576605600, 0)); err != darksky.ErrBadRequest {
+		t.Fatalf("err = %v; want %v", err, darksky.ErrBadRequest)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.ForecastContext(ctx, stLat, stLong); err == nil {
+		t.Fatalf("err = nil; want the context's error")
+	}
+
+	want := []string{"start", "response", "start", "error:server", "start", "error:canceled"}
+	if strings.Join(order, " ") != strings.Join(want, " ") {
+		t.Errorf("hooks called = %v; want %v", order, want)
+	}
+
+	if strings.Contains(logs.String(), "secret-key") {
+		t.Errorf("logs contain the key:\n%s", logs.String())
+	}
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var r map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 6 {
+		t.Fatalf("len(records) = %d; want 6", len(records))
+	}
+	if r := records[1]; r["level"] != "INFO" || r["status"] != float64(200) || r["lat"] != stLat {
+		t.Errorf("records[1] = %v; want an INFO record with status 200", r)
+	}
+	if r := records[3]; r["level"] != "ERROR" || r["class"] != "server" || r["endpoint"] != "timemachine" {
+		t.Errorf("records[3] = %v; want an ERROR record of class server", r)
+	}
+
+	spans := tracer.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("len(spans) = %d; want 3", len(spans))
+	}
+	for _, s := range spans {
+		if !s.Ended {
+			t.Errorf("span %s did not end", s.Name)
+		}
+	}
+	if s := spans[0]; s.Name != "darksky.forecast" || s.Attributes["http.status_code"] != 200 ||
+		s.Attributes["darksky.longitude"] != stLong || len(s.Errors) != 0 {
+		t.Errorf("spans[0] = %+v", s)
+	}
+	if s := spans[1]; s.Name != "darksky.timemachine" || s.Attributes["http.status_code"] != 503 || len(s.Errors) != 1 {
+		t.Errorf("spans[1] = %+v", s)
+	}
+
+	if got := metrics.Calls("forecast"); got != 2 {
+		t.Errorf("Calls(forecast) = %d; want 2", got)
+	}
+	if got := metrics.Errors("timemachine", darksky.ErrorClassServer); got != 1 {
+		t.Errorf("Errors(timemachine, server) = %d; want 1", got)
+	}
+	if got := metrics.Errors("forecast", darksky.ErrorClassCanceled); got != 1 {
+		t.Errorf("Errors(forecast, canceled) = %d; want 1", got)
+	}
+	if h := metrics.Histogram("forecast", time.Minute); h[0] != 2 || h[1] != 2 {
+		t.Errorf("Histogram(forecast) = %v; want [2 2]", h)
+	}
+}
+
+func TestClient_Hooks_circuitOpen(t *testing.T) {
+	metrics := &darkskytest.Metrics{}
+	c := &darksky.Client{
+		Key:     "key",
+		BaseURL: "http://127.0.0.1:1",
+		Breaker: &darksky.Breaker{MinRequests: 1},
+		Hooks:   []darksky.Hooks{darksky.MetricsHooks(metrics)},
+	}
+	c.Forecast(stLat, stLong)
+	if _, err := c.Forecast(stLat, stLong); err != darksky.ErrCircuitOpen {
+		t.Fatalf("err = %v; want %v", err, darksky.ErrCircuitOpen)
+	}
+	if metrics.Errors("forecast", darksky.ErrorClassNetwork) != 1 || metrics.Errors("forecast", darksky.ErrorClassCircuitOpen) != 1 {
+		t.Errorf("network, circuit open errors = %d, %d; want 1, 1",
+			metrics.Errors("forecast", darksky.ErrorClassNetwork), metrics.Errors("forecast", darksky.ErrorClassCircuitOpen))
+	}
+}
@@ -0,0 +1,342 @@
+// Package openmeteo adapts the Open-Meteo forecast API to darksky.Provider,
+// mapping its responses into the darksky.Forecast model in US units.
+package openmeteo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/astro"
+)
+
+const DefaultBaseURL = "https://api.open-meteo.com"
+
+var (
+	currentFields = []string{
+		"temperature_2m", "relative_humidity_2m", "apparent_temperature", "is_day",
+		"precipitation", "weather_code", "cloud_cover", "pressure_msl",
+		"wind_speed_10m", "wind_direction_10m", "wind_gusts_10m",
+	}
+	hourlyFields = []string{
+		"temperature_2m", "relative_humidity_2m", "dew_point_2m", "apparent_temperature",
+		"precipitation_probability", "precipitation", "weather_code", "pressure_msl",
+		"cloud_cover", "visibility", "wind_speed_10m", "wind_direction_10m",
+		"wind_gusts_10m", "uv_index", "is_day",
+	}
+	dailyFields = []string{
+		"weather_code", "temperature_2m_max", "temperature_2m_min",
+		"apparent_temperature_max", "apparent_temperature_min", "sunrise", "sunset",
+		"precipitation_probability_max", "wind_speed_10m_max", "wind_gusts_10m_max",
+		"uv_index_max",
+	}
+)
+
+// Client fetches forecasts from Open-Meteo. It needs no key.
+type Client struct {
+	BaseURL    string
+	HttpClient darksky.Doer
+}
+
+var _ darksky.Provider = (*Client)(nil)
+
+// Forecast returns the current conditions and the forecast for the next week
+// at the given coordinates.
+func (c *Client) Forecast(lat, long float64) (*darksky.Forecast, error) {
+	return c.ForecastContext(context.Background(), lat, long)
+}
+
+// ForecastContext is like Forecast but makes the request with ctx.
+func (c *Client) ForecastContext(ctx context.Context, lat, long float64) (*darksky.Forecast, error) {
+	if err := (darksky.Location{Lat: lat, Long: long}).Validate(); err != nil {
+		return nil, err
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(lat, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(long, 'f', -1, 64))
+	q.Set("current", strings.Join(currentFields, ","))
+	q.Set("hourly", strings.Join(hourlyFields, ","))
+	q.Set("daily", strings.Join(dailyFields, ","))
+	q.Set("timezone", "auto")
+	q.Set("timeformat", "unixtime")
+	q.Set("forecast_days", "8")
+	q.Set("temperature_unit", "fahrenheit")
+	q.Set("wind_speed_unit", "mph")
+	q.Set("precipitation_unit", "inch")
+	req, err := http.NewRequest(http.MethodGet, base+"/v1/forecast?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		return nil, darksky.ErrBadRequest
+	}
+	return Decode(res.Body)
+}
+
+// Response is the part of an Open-Meteo forecast response that maps into
+// darksky.Forecast. Times are UNIX times.
+type Response struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+	Current   struct {
+		Time                int64   `json:"time"`
+		Temperature         float64 `json:"temperature_2m"`
+		RelativeHumidity    float64 `json:"relative_humidity_2m"`
+		ApparentTemperature float64 `json:"apparent_temperature"`
+		IsDay               int     `json:"is_day"`
+		Precipitation       float64 `json:"precipitation"`
+		WeatherCode         int     `json:"weather_code"`
+		CloudCover          float64 `json:"cloud_cover"`
+		PressureMSL         float64 `json:"pressure_msl"`
+		WindSpeed           float64 `json:"wind_speed_10m"`
+		WindDirection       float64 `json:"wind_direction_10m"`
+		WindGusts           float64 `json:"wind_gusts_10m"`
+	} `json:"current"`
+	HourlyUnits map[string]string `json:"hourly_units"`
+	Hourly      struct {
+		Time                     []int64   `json:"time"`
+		Temperature              []float64 `json:"temperature_2m"`
+		RelativeHumidity         []float64 `json:"relative_humidity_2m"`
+		DewPoint                 []float64 `json:"dew_point_2m"`
+		ApparentTemperature      []float64 `json:"apparent_temperature"`
+		PrecipitationProbability []float64 `json:"precipitation_probability"`
+		Precipitation            []float64 `json:"precipitation"`
+		WeatherCode              []int     `json:"weather_code"`
+		PressureMSL              []float64 `json:"pressure_msl"`
+		CloudCover               []float64 `json:"cloud_cover"`
+		Visibility               []float64 `json:"visibility"`
+		WindSpeed                []float64 `json:"wind_speed_10m"`
+		WindDirection            []float64 `json:"wind_direction_10m"`
+		WindGusts                []float64 `json:"wind_gusts_10m"`
+		UVIndex                  []float64 `json:"uv_index"`
+		IsDay                    []int     `json:"is_day"`
+	} `json:"hourly"`
+	Daily struct {
+		Time                        []int64   `json:"time"`
+		WeatherCode                 []int     `json:"weather_code"`
+		TemperatureMax              []float64 `json:"temperature_2m_max"`
+		TemperatureMin              []float64 `json:"temperature_2m_min"`
+		ApparentTemperatureMax      []float64 `json:"apparent_temperature_max"`
+		ApparentTemperatureMin      []float64 `json:"apparent_temperature_min"`
+		Sunrise                     []int64   `json:"sunrise"`
+		Sunset                      []int64   `json:"sunset"`
+		PrecipitationProbabilityMax []float64 `json:"precipitation_probability_max"`
+		WindSpeedMax                []float64 `json:"wind_speed_10m_max"`
+		WindGustsMax                []float64 `json:"wind_gusts_10m_max"`
+		UVIndexMax                  []float64 `json:"uv_index_max"`
+	} `json:"daily"`
+}
+
+// Decode reads an Open-Meteo response requested in US units and with UNIX
+// times, as Client requests it, and converts it.
+func Decode(r io.Reader) (*darksky.Forecast, error) {
+	var res Response
+	if err := json.NewDecoder(r).Decode(&res); err != nil {
+		return nil, err
+	}
+	return Convert(&res), nil
+}
+
+// Convert maps an Open-Meteo response into a darksky.Forecast. Fields Open-
+// Meteo lacks, such as ozone and alerts, are left empty, and the moon phase
+// is computed with the astro package.
+func Convert(r *Response) *darksky.Forecast {
+	f := &darksky.Forecast{
+		Latitude:  r.Latitude,
+		Longitude: r.Longitude,
+		Timezone:  r.Timezone,
+	}
+	milesPerVisibilityUnit := 1 / 1609.344
+	if r.HourlyUnits["visibility"] == "ft" {
+		milesPerVisibilityUnit = 1.0 / 5280
+	}
+
+	h := r.Hourly
+	for i, t := range h.Time {
+		code := at(h.WeatherCode, i)
+		p := darksky.HourlyDataPoint{
+			Time:                int(t),
+			Summary:             summaries[code],
+			Icon:                icon(code, at(h.IsDay, i) == 1),
+			PrecipIntensity:     atf(h.Precipitation, i),
+			PrecipProbability:   atf(h.PrecipitationProbability, i) / 100,
+			PrecipType:          precipType(code, atf(h.Precipitation, i)),
+			Temperature:         atf(h.Temperature, i),
+			ApparentTemperature: atf(h.ApparentTemperature, i),
+			DewPoint:            atf(h.DewPoint, i),
+			Humidity:            atf(h.RelativeHumidity, i) / 100,
+			Pressure:            atf(h.PressureMSL, i),
+			WindSpeed:           atf(h.WindSpeed, i),
+			WindGust:            atf(h.WindGusts, i),
+			WindBearing:         int(math.Round(atf(h.WindDirection, i))) % 360,
+			CloudCover:          atf(h.CloudCover, i) / 100,
+			UvIndex:             int(math.Round(atf(h.UVIndex, i))),
+			Visibility:          atf(h.Visibility, i) * milesPerVisibilityUnit,
+		}
+		f.Hourly.Data = append(f.Hourly.Data, p)
+	}
+
+	cur := r.Current
+	f.Currently = darksky.CurrentDataPoint{
+		Time:                int(cur.Time),
+		Summary:             summaries[cur.WeatherCode],
+		Icon:                icon(cur.WeatherCode, cur.IsDay == 1),
+		PrecipIntensity:     cur.Precipitation,
+		PrecipType:          precipType(cur.WeatherCode, cur.Precipitation),
+		Temperature:         cur.Temperature,
+		ApparentTemperature: cur.ApparentTemperature,
+		Humidity:            cur.RelativeHumidity / 100,
+		Pressure:            cur.PressureMSL,
+		WindSpeed:           cur.WindSpeed,
+		WindGust:            cur.WindGusts,
+		WindBearing:         int(math.Round(cur.WindDirection)) % 360,
+		CloudCover:          cur.CloudCover / 100,
+	}
+	// The fields the current block lacks come from the hour it falls in.
+	for _, p := range f.Hourly.Data {
+		if p.Time > f.Currently.Time {
+			break
+		}
+		f.Currently.PrecipProbability = p.PrecipProbability
+		f.Currently.DewPoint = p.DewPoint
+		f.Currently.UvIndex = p.UvIndex
+		f.Currently.Visibility = p.Visibility
+	}
+
+	d := r.Daily
+	for i, t := range d.Time {
+		code := at(d.WeatherCode, i)
+		noon := time.Unix(t, 0).Add(12 * time.Hour)
+		f.Daily.Data = append(f.Daily.Data, darksky.DailyDataPoint{
+			Time:                    int(t),
+			Summary:                 summaries[code],
+			Icon:                    icon(code, true),
+			SunriseTime:             int(at64(d.Sunrise, i)),
+			SunsetTime:              int(at64(d.Sunset, i)),
+			MoonPhase:               math.Round(astro.MoonPhase(noon)*100) / 100,
+			PrecipProbability:       atf(d.PrecipitationProbabilityMax, i) / 100,
+			PrecipType:              precipType(code, 0),
+			TemperatureHigh:         atf(d.TemperatureMax, i),
+			TemperatureLow:          atf(d.TemperatureMin, i),
+			ApparentTemperatureHigh: atf(d.ApparentTemperatureMax, i),
+			ApparentTemperatureLow:  atf(d.ApparentTemperatureMin, i),
+			WindSpeed:               atf(d.WindSpeedMax, i),
+			WindGust:                atf(d.WindGustsMax, i),
+			UvIndex:                 int(math.Round(atf(d.UVIndexMax, i))),
+			TemperatureMax:          atf(d.TemperatureMax, i),
+			TemperatureMin:          atf(d.TemperatureMin, i),
+			ApparentTemperatureMax:  atf(d.ApparentTemperatureMax, i),
+			ApparentTemperatureMin:  atf(d.ApparentTemperatureMin, i),
+		})
+	}
+	if len(f.Hourly.Data) > 0 {
+		f.Hourly.Summary, f.Hourly.Icon = f.Hourly.Data[0].Summary, f.Hourly.Data[0].Icon
+	}
+	if len(f.Daily.Data) > 0 {
+		f.Daily.Summary, f.Daily.Icon = f.Daily.Data[0].Summary, f.Daily.Data[0].Icon
+	}
+	return f
+}
+
+// summaries name the WMO weather interpretation codes Open-Meteo uses.
+var summaries = map[int]string{
+	0: "Clear", 1: "Mostly Clear", 2: "Partly Cloudy", 3: "Overcast",
+	45: "Foggy", 48: "Foggy",
+	51: "Light Drizzle", 53: "Drizzle", 55: "Heavy Drizzle",
+	56: "Freezing Drizzle", 57: "Freezing Drizzle",
+	61: "Light Rain", 63: "Rain", 65: "Heavy Rain",
+	66: "Freezing Rain", 67: "Freezing Rain",
+	71: "Light Snow", 73: "Snow", 75: "Heavy Snow", 77: "Snow Grains",
+	80: "Rain Showers", 81: "Rain Showers", 82: "Heavy Rain Showers",
+	85: "Snow Showers", 86: "Heavy Snow Showers",
+	95: "Thunderstorm", 96: "Thunderstorm with Hail", 99: "Thunderstorm with Hail",
+}
+
+// icon maps a WMO weather code to a Dark Sky icon.
+func icon(code int, day bool) string {
+	switch {
+	case code == 0:
+		return dayNight("clear", day)
+	case code <= 2:
+		return dayNight("partly-cloudy", day)
+	case code == 3:
+		return "cloudy"
+	case code == 45 || code == 48:
+		return "fog"
+	case code == 56 || code == 57 || code == 66 || code == 67:
+		return "sleet"
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return "snow"
+	case code >= 95:
+		return "thunderstorm"
+	case code >= 51:
+		return "rain"
+	}
+	return ""
+}
+
+func dayNight(icon string, day bool) string {
+	if day {
+		return icon + "-day"
+	}
+	return icon + "-night"
+}
+
+// precipType maps a WMO weather code to a Dark Sky precipitation type,
+// falling back to rain if the code does not describe any but some fell.
+func precipType(code int, amount float64) string {
+	switch i := icon(code, true); i {
+	case "rain", "snow", "sleet":
+		return i
+	case "thunderstorm":
+		return "rain"
+	}
+	if amount > 0 {
+		return "rain"
+	}
+	return ""
+}
+
+func at(s []int, i int) int {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+func at64(s []int64, i int) int64 {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+func atf(s []float64, i int) float64 {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package openmeteo_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/openmeteo"
+)
+
+func TestDecode(t *testing.T) {
+	f, err := os.Open("testdata/forecast.json")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	defer f.Close()
+	fc, err := openmeteo.Decode(f)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+
+	if fc.Timezone != "America/Los_Angeles" || fc.Latitude != 32.58 {
+		t.Errorf("Timezone, Latitude = %q, %v; want America/Los_Angeles, 32.58", fc.Timezone, fc.Latitude)
+	}
+	cur := fc.Currently
+	if cur.Time != 1576606500 || cur.Temperature != 45.3 || cur.WindBearing != 51 {
+		t.Errorf("Currently = %+v; want the current block", cur)
+	}
+	if cur.Icon != "partly-cloudy-day" || cur.Summary != "Partly Cloudy" {
+		t.Errorf("Currently icon, summary = %q, %q; want partly-cloudy-day, Partly Cloudy", cur.Icon, cur.Summary)
+	}
+	if cur.Humidity != 0.41 || cur.CloudCover != 0.38 {
+		t.Errorf("Currently humidity, cloud cover = %v, %v; want fractions", cur.Humidity, cur.CloudCover)
+	}
+	if cur.UvIndex != 3 || cur.DewPoint != 23.4 || cur.Visibility != 10 {
+		t.Errorf("Currently = %+v; want UV, dew point and visibility from the first hour", cur)
+	}
+
+	if len(fc.Hourly.Data) != 4 {
+		t.Fatalf("len(Hourly.Data) = %d; want 4", len(fc.Hourly.Data))
+	}
+	h := fc.Hourly.Data[3]
+	if h.Time != 1576616400 || h.Icon != "clear-day" || h.WindBearing != 0 || h.PrecipProbability != 0 {
+		t.Errorf("Hourly.Data[3] = %+v", h)
+	}
+
+	if len(fc.Daily.Data) != 2 {
+		t.Fatalf("len(Daily.Data) = %d; want 2", len(fc.Daily.Data))
+	}
+	d := fc.Daily.Data[1]
+	if d.Icon != "rain" || d.PrecipType != "rain" || d.PrecipProbability != 0.45 {
+		t.Errorf("Daily.Data[1] precipitation = %q, %q, %v; want rain, rain, 0.45", d.Icon, d.PrecipType, d.PrecipProbability)
+	}
+	if d.TemperatureHigh != 55.8 || d.TemperatureLow != 34.8 || d.SunriseTime != 1576680240 {
+		t.Errorf("Daily.Data[1] = %+v", d)
+	}
+	// Dark Sky reported 0.75 for the same day.
+	if math.Abs(d.MoonPhase-0.75) > 0.03 {
+		t.Errorf("MoonPhase = %v; want about 0.75", d.MoonPhase)
+	}
+}
+
+func TestClient_Forecast(t *testing.T) {
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/forecast" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.Query()
+		if query["latitude"][0] == "0" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": true, "reason": "Latitude must be in range of -90 to 90°."}`))
+			return
+		}
+		http.ServeFile(w, r, "testdata/forecast.json")
+	}))
+	defer server.Close()
+
+	var p darksky.Provider = &openmeteo.Client{BaseURL: server.URL}
+	fc, err := p.ForecastContext(context.Background(), 32.58972, -116.466988)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if fc.Currently.Temperature != 45.3 {
+		t.Errorf("Temperature = %v; want 45.3", fc.Currently.Temperature)
+	}
+	for name, want := range map[string]string{
+		"latitude":         "32.58972",
+		"longitude":        "-116.466988",
+		"timeformat":       "unixtime",
+		"temperature_unit": "fahrenheit",
+		"wind_speed_unit":  "mph",
+		"timezone":         "auto",
+	} {
+		if got := query[name]; len(got) != 1 || got[0] != want {
+			t.Errorf("%s = %v; want %s", name, got, want)
+		}
+	}
+
+	if _, err := p.Forecast(0, 0); err != darksky.ErrBadRequest {
+		t.Errorf("err = %v; want %v", err, darksky.ErrBadRequest)
+	}
+	if _, err := p.Forecast(91, 0); err == nil {
+		t.Errorf("err = nil; want a validation error")
+	}
+}
@@ -0,0 +1,362 @@
+// Package openweathermap adapts the OpenWeatherMap One Call API to
+// darksky.Provider, mapping its responses into the darksky.Forecast model in
+// US units.
+package openweathermap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+const DefaultBaseURL = "https://api.openweathermap.org"
+
+const (
+	mmPerInch     = 25.4
+	metresPerMile = 1609.344
+)
+
+// Client fetches forecasts from version 3.0 of the One Call API.
+type Client struct {
+	Key        string
+	BaseURL    string
+	HttpClient darksky.Doer
+}
+
+var _ darksky.Provider = (*Client)(nil)
+
+// Forecast returns the current conditions and the forecast for the next week
+// at the given coordinates.
+func (c *Client) Forecast(lat, long float64) (*darksky.Forecast, error) {
+	return c.ForecastContext(context.Background(), lat, long)
+}
+
+// ForecastContext is like Forecast but makes the request with ctx.
+func (c *Client) ForecastContext(ctx context.Context, lat, long float64) (*darksky.Forecast, error) {
+	if err := (darksky.Location{Lat: lat, Long: long}).Validate(); err != nil {
+		return nil, err
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	q.Set("lon", strconv.FormatFloat(long, 'f', -1, 64))
+	q.Set("appid", c.Key)
+	q.Set("units", "imperial")
+	req, err := http.NewRequest(http.MethodGet, base+"/data/3.0/onecall?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		return nil, darksky.ErrBadRequest
+	}
+	return Decode(res.Body)
+}
+
+// Weather is a weather condition of a One Call data point.
+type Weather struct {
+	ID          int    `json:"id"`
+	Main        string `json:"main"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+}
+
+// Volume is an amount of rain or snow in mm over the last hour.
+type Volume struct {
+	OneHour float64 `json:"1h"`
+}
+
+// Response is a One Call response requested in imperial units. Temperatures
+// are in °F, speeds in mph, precipitation in mm, visibility in metres,
+// humidity and cloud cover in percent and times are UNIX times.
+type Response struct {
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Timezone string  `json:"timezone"`
+	Current  struct {
+		Dt         int64     `json:"dt"`
+		Temp       float64   `json:"temp"`
+		FeelsLike  float64   `json:"feels_like"`
+		Pressure   float64   `json:"pressure"`
+		Humidity   float64   `json:"humidity"`
+		DewPoint   float64   `json:"dew_point"`
+		UVI        float64   `json:"uvi"`
+		Clouds     float64   `json:"clouds"`
+		Visibility float64   `json:"visibility"`
+		WindSpeed  float64   `json:"wind_speed"`
+		WindDeg    float64   `json:"wind_deg"`
+		WindGust   float64   `json:"wind_gust"`
+		Rain       *Volume   `json:"rain"`
+		Snow       *Volume   `json:"snow"`
+		Weather    []Weather `json:"weather"`
+	} `json:"current"`
+	Minutely []struct {
+		Dt            int64   `json:"dt"`
+		Precipitation float64 `json:"precipitation"` // mm/h
+	} `json:"minutely"`
+	Hourly []struct {
+		Dt         int64     `json:"dt"`
+		Temp       float64   `json:"temp"`
+		FeelsLike  float64   `json:"feels_like"`
+		Pressure   float64   `json:"pressure"`
+		Humidity   float64   `json:"humidity"`
+		DewPoint   float64   `json:"dew_point"`
+		UVI        float64   `json:"uvi"`
+		Clouds     float64   `json:"clouds"`
+		Visibility float64   `json:"visibility"`
+		WindSpeed  float64   `json:"wind_speed"`
+		WindDeg    float64   `json:"wind_deg"`
+		WindGust   float64   `json:"wind_gust"`
+		Pop        float64   `json:"pop"`
+		Rain       *Volume   `json:"rain"`
+		Snow       *Volume   `json:"snow"`
+		Weather    []Weather `json:"weather"`
+	} `json:"hourly"`
+	Daily []struct {
+		Dt        int64   `json:"dt"`
+		Sunrise   int64   `json:"sunrise"`
+		Sunset    int64   `json:"sunset"`
+		MoonPhase float64 `json:"moon_phase"`
+		Summary   string  `json:"summary"`
+		Temp      struct {
+			Min float64 `json:"min"`
+			Max float64 `json:"max"`
+		} `json:"temp"`
+		FeelsLike struct {
+			Day   float64 `json:"day"`
+			Night float64 `json:"night"`
+			Eve   float64 `json:"eve"`
+			Morn  float64 `json:"morn"`
+		} `json:"feels_like"`
+		Pressure  float64   `json:"pressure"`
+		Humidity  float64   `json:"humidity"`
+		DewPoint  float64   `json:"dew_point"`
+		WindSpeed float64   `json:"wind_speed"`
+		WindGust  float64   `json:"wind_gust"`
+		Clouds    float64   `json:"clouds"`
+		UVI       float64   `json:"uvi"`
+		Pop       float64   `json:"pop"`
+		Rain      float64   `json:"rain"` // mm over the day
+		Snow      float64   `json:"snow"` // mm over the day
+		Weather   []Weather `json:"weather"`
+	} `json:"daily"`
+	Alerts []struct {
+		SenderName  string `json:"sender_name"`
+		Event       string `json:"event"`
+		Start       int64  `json:"start"`
+		End         int64  `json:"end"`
+		Description string `json:"description"`
+	} `json:"alerts"`
+}
+
+// Decode reads a One Call response requested in imperial units, as Client
+// requests it, and converts it.
+func Decode(r io.Reader) (*darksky.Forecast, error) {
+	var res Response
+	if err := json.NewDecoder(r).Decode(&res); err != nil {
+		return nil, err
+	}
+	return Convert(&res), nil
+}
+
+// Convert maps a One Call response into a darksky.Forecast. Fields One Call
+// lacks, such as ozone and the times of the daily extremes, are left empty.
+func Convert(r *Response) *darksky.Forecast {
+	f := &darksky.Forecast{
+		Latitude:  r.Lat,
+		Longitude: r.Lon,
+		Timezone:  r.Timezone,
+	}
+
+	cur := r.Current
+	w := first(cur.Weather)
+	f.Currently = darksky.CurrentDataPoint{
+		Time:                int(cur.Dt),
+		Summary:             title(w.Description),
+		Icon:                icons[w.Icon],
+		PrecipIntensity:     (hourVolume(cur.Rain) + hourVolume(cur.Snow)) / mmPerInch,
+		PrecipType:          precipType(w, cur.Rain, cur.Snow),
+		Temperature:         cur.Temp,
+		ApparentTemperature: cur.FeelsLike,
+		DewPoint:            cur.DewPoint,
+		Humidity:            cur.Humidity / 100,
+		Pressure:            cur.Pressure,
+		WindSpeed:           cur.WindSpeed,
+		WindGust:            cur.WindGust,
+		WindBearing:         int(math.Round(cur.WindDeg)) % 360,
+		CloudCover:          cur.Clouds / 100,
+		UvIndex:             int(math.Round(cur.UVI)),
+		Visibility:          cur.Visibility / metresPerMile,
+	}
+
+	for _, m := range r.Minutely {
+		p := darksky.MinutelyDataPoint{
+			Time:            int(m.Dt),
+			PrecipIntensity: m.Precipitation / mmPerInch,
+		}
+		// One Call gives no probability for a minute, only whether any falls.
+		if m.Precipitation > 0 {
+			p.PrecipProbability = 1
+			p.PrecipType = "rain"
+			if f.Currently.PrecipType != "" {
+				p.PrecipType = f.Currently.PrecipType
+			}
+		}
+		f.Minutely.Data = append(f.Minutely.Data, p)
+	}
+
+	for i, h := range r.Hourly {
+		w := first(h.Weather)
+		f.Hourly.Data = append(f.Hourly.Data, darksky.HourlyDataPoint{
+			Time:                int(h.Dt),
+			Summary:             title(w.Description),
+			Icon:                icons[w.Icon],
+			PrecipIntensity:     (hourVolume(h.Rain) + hourVolume(h.Snow)) / mmPerInch,
+			PrecipProbability:   h.Pop,
+			PrecipType:          precipType(w, h.Rain, h.Snow),
+			Temperature:         h.Temp,
+			ApparentTemperature: h.FeelsLike,
+			DewPoint:            h.DewPoint,
+			Humidity:            h.Humidity / 100,
+			Pressure:            h.Pressure,
+			WindSpeed:           h.WindSpeed,
+			WindGust:            h.WindGust,
+			WindBearing:         int(math.Round(h.WindDeg)) % 360,
+			CloudCover:          h.Clouds / 100,
+			UvIndex:             int(math.Round(h.UVI)),
+			Visibility:          h.Visibility / metresPerMile,
+		})
+		// The current block has no probability of its own.
+		if i == 0 || h.Dt <= cur.Dt {
+			f.Currently.PrecipProbability = h.Pop
+		}
+	}
+
+	for _, d := range r.Daily {
+		w := first(d.Weather)
+		p := darksky.DailyDataPoint{
+			Time:                    int(d.Dt),
+			Summary:                 d.Summary,
+			Icon:                    icons[w.Icon],
+			SunriseTime:             int(d.Sunrise),
+			SunsetTime:              int(d.Sunset),
+			MoonPhase:               d.MoonPhase,
+			PrecipProbability:       d.Pop,
+			PrecipType:              precipType(w, &Volume{d.Rain}, &Volume{d.Snow}),
+			TemperatureHigh:         d.Temp.Max,
+			TemperatureLow:          d.Temp.Min,
+			ApparentTemperatureHigh: math.Max(math.Max(d.FeelsLike.Day, d.FeelsLike.Eve), d.FeelsLike.Morn),
+			ApparentTemperatureLow:  math.Min(math.Min(d.FeelsLike.Night, d.FeelsLike.Eve), d.FeelsLike.Morn),
+			DewPoint:                d.DewPoint,
+			Humidity:                d.Humidity / 100,
+			Pressure:                d.Pressure,
+			WindSpeed:               d.WindSpeed,
+			WindGust:                d.WindGust,
+			CloudCover:              d.Clouds / 100,
+			UvIndex:                 int(math.Round(d.UVI)),
+			TemperatureMin:          d.Temp.Min,
+			TemperatureMax:          d.Temp.Max,
+		}
+		if p.Summary == "" {
+			p.Summary = title(w.Description)
+		}
+		p.ApparentTemperatureMax, p.ApparentTemperatureMin = p.ApparentTemperatureHigh, p.ApparentTemperatureLow
+		f.Daily.Data = append(f.Daily.Data, p)
+	}
+
+	for _, a := range r.Alerts {
+		title := a.Event
+		if a.SenderName != "" {
+			title += " issued by " + a.SenderName
+		}
+		f.Alerts = append(f.Alerts, darksky.Alert{
+			Title:       title,
+			Time:        int(a.Start),
+			Expires:     int(a.End),
+			Description: a.Description,
+		})
+	}
+
+	if len(f.Hourly.Data) > 0 {
+		f.Hourly.Summary, f.Hourly.Icon = f.Hourly.Data[0].Summary, f.Hourly.Data[0].Icon
+	}
+	if len(f.Daily.Data) > 0 {
+		f.Daily.Summary, f.Daily.Icon = f.Daily.Data[0].Summary, f.Daily.Data[0].Icon
+	}
+	return f
+}
+
+// icons map One Call icon codes to Dark Sky icons.
+var icons = map[string]string{
+	"01d": "clear-day", "01n": "clear-night",
+	"02d": "partly-cloudy-day", "02n": "partly-cloudy-night",
+	"03d": "partly-cloudy-day", "03n": "partly-cloudy-night",
+	"04d": "cloudy", "04n": "cloudy",
+	"09d": "rain", "09n": "rain",
+	"10d": "rain", "10n": "rain",
+	"11d": "thunderstorm", "11n": "thunderstorm",
+	"13d": "snow", "13n": "snow",
+	"50d": "fog", "50n": "fog",
+}
+
+// precipType returns the Dark Sky precipitation type from the condition,
+// falling back to the volumes that fell if the condition does not say.
+func precipType(w Weather, rain, snow *Volume) string {
+	switch {
+	case w.ID == 611 || w.ID == 612 || w.ID == 613 || w.ID == 615 || w.ID == 616 || w.ID == 511:
+		return "sleet"
+	case w.ID >= 600 && w.ID < 700:
+		return "snow"
+	case w.ID >= 200 && w.ID < 600:
+		return "rain"
+	case hourVolume(snow) > 0:
+		return "snow"
+	case hourVolume(rain) > 0:
+		return "rain"
+	}
+	return ""
+}
+
+func hourVolume(v *Volume) float64 {
+	if v == nil {
+		return 0
+	}
+	return v.OneHour
+}
+
+func first(ws []Weather) Weather {
+	if len(ws) == 0 {
+		return Weather{}
+	}
+	return ws[0]
+}
+
+// title capitalises a One Call description, e.g. "light rain", the way
+// Dark Sky writes summaries.
+func title(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,118 @@
+package openweathermap_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/openweathermap"
+)
+
+func approx(got, want float64) bool {
+	return math.Abs(got-want) < 1e-3
+}
+
+func TestDecode(t *testing.T) {
+	f, err := os.Open("testdata/onecall.json")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	defer f.Close()
+	fc, err := openweathermap.Decode(f)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+
+	if fc.Timezone != "America/Los_Angeles" || fc.Latitude != 47.6062 {
+		t.Errorf("Timezone, Latitude = %q, %v; want America/Los_Angeles, 47.6062", fc.Timezone, fc.Latitude)
+	}
+	cur := fc.Currently
+	if cur.Icon != "rain" || cur.Summary != "Light Rain" || cur.PrecipType != "rain" {
+		t.Errorf("Currently icon, summary, type = %q, %q, %q; want rain, Light Rain, rain", cur.Icon, cur.Summary, cur.PrecipType)
+	}
+	if !approx(cur.PrecipIntensity, 0.04) || !approx(cur.Visibility, 5) || cur.PrecipProbability != 0.92 {
+		t.Errorf("Currently intensity, visibility, probability = %v, %v, %v; want 0.04, 5, 0.92", cur.PrecipIntensity, cur.Visibility, cur.PrecipProbability)
+	}
+	if cur.Humidity != 0.93 || cur.CloudCover != 0.9 || cur.WindBearing != 190 {
+		t.Errorf("Currently = %+v", cur)
+	}
+
+	if len(fc.Minutely.Data) != 3 {
+		t.Fatalf("len(Minutely.Data) = %d; want 3", len(fc.Minutely.Data))
+	}
+	if m := fc.Minutely.Data[1]; !approx(m.PrecipIntensity, 0.02) || m.PrecipType != "rain" {
+		t.Errorf("Minutely.Data[1] = %+v; want 0.02 in/h of rain", m)
+	}
+	if m := fc.Minutely.Data[2]; m.PrecipIntensity != 0 || m.PrecipProbability != 0 {
+		t.Errorf("Minutely.Data[2] = %+v; want no precipitation", m)
+	}
+
+	if len(fc.Hourly.Data) != 3 {
+		t.Fatalf("len(Hourly.Data) = %d; want 3", len(fc.Hourly.Data))
+	}
+	if h := fc.Hourly.Data[1]; h.Icon != "cloudy" || h.WindBearing != 0 || h.PrecipType != "" {
+		t.Errorf("Hourly.Data[1] = %+v", h)
+	}
+	if h := fc.Hourly.Data[2]; h.Icon != "snow" || h.PrecipType != "snow" || !approx(h.PrecipIntensity, 0.1) {
+		t.Errorf("Hourly.Data[2] = %+v", h)
+	}
+
+	if len(fc.Daily.Data) != 2 {
+		t.Fatalf("len(Daily.Data) = %d; want 2", len(fc.Daily.Data))
+	}
+	d := fc.Daily.Data[0]
+	if d.Summary != "Expect a day of rain turning to snow" || d.PrecipType != "sleet" || d.MoonPhase != 0.72 {
+		t.Errorf("Daily.Data[0] = %+v", d)
+	}
+	if d.TemperatureHigh != 43.3 || d.ApparentTemperatureLow != 29.8 || d.ApparentTemperatureHigh != 37 {
+		t.Errorf("Daily.Data[0] temperatures = %+v", d)
+	}
+	if d := fc.Daily.Data[1]; d.Summary != "Clear Sky" || d.Icon != "clear-day" || d.PrecipType != "" {
+		t.Errorf("Daily.Data[1] = %+v", d)
+	}
+
+	if len(fc.Alerts) != 1 {
+		t.Fatalf("len(Alerts) = %d; want 1", len(fc.Alerts))
+	}
+	if a := fc.Alerts[0]; a.Title != "Winter Weather Advisory issued by NWS Seattle" || a.Expires != 1576656000 {
+		t.Errorf("Alerts[0] = %+v", a)
+	}
+}
+
+func TestClient_Forecast(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/data/3.0/onecall" || q.Get("units") != "imperial" || q.Get("lat") != "47.6062" {
+			http.NotFound(w, r)
+			return
+		}
+		if q.Get("appid") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"cod": 401, "message": "Invalid API key."}`))
+			return
+		}
+		http.ServeFile(w, r, "testdata/onecall.json")
+	}))
+	defer server.Close()
+
+	var p darksky.Provider = &openweathermap.Client{Key: "key", BaseURL: server.URL}
+	fc, err := p.ForecastContext(context.Background(), 47.6062, -122.3321)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if fc.Currently.Temperature != 41.2 {
+		t.Errorf("Temperature = %v; want 41.2", fc.Currently.Temperature)
+	}
+
+	p = &openweathermap.Client{Key: "wrong", BaseURL: server.URL}
+	if _, err := p.Forecast(47.6062, -122.3321); err != darksky.ErrBadRequest {
+		t.Errorf("err = %v; want %v", err, darksky.ErrBadRequest)
+	}
+	if _, err := p.Forecast(0, 181); err == nil {
+		t.Errorf("err = nil; want a validation error")
+	}
+}
@@ -0,0 +1,235 @@
+package darksky
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// blocks are the parts of a response the API can be told to exclude.
+var blocks = []string{"currently", "minutely", "hourly", "daily", "alerts", "flags"}
+
+// excludeAllBut returns the exclude parameter that leaves only keep in the
+// response.
+func excludeAllBut(keep string) string {
+	exclude := make([]string, 0, len(blocks)-1)
+	for _, b := range blocks {
+		if b != keep {
+			exclude = append(exclude, b)
+		}
+	}
+	return strings.Join(exclude, ",")
+}
+
+// CurrentForecast is a forecast response with only the current conditions.
+type CurrentForecast struct {
+	Latitude  float64          `json:"latitude"`
+	Longitude float64          `json:"longitude"`
+	Timezone  string           `json:"timezone"`
+	Currently CurrentDataPoint `json:"currently"`
+}
+
+// MinutelyForecast is a forecast response with only the minutely block.
+type MinutelyForecast struct {
+	Latitude  float64           `json:"latitude"`
+	Longitude float64           `json:"longitude"`
+	Timezone  string            `json:"timezone"`
+	Minutely  MinutelyDataBlock `json:"minutely"`
+}
+
+// HourlyForecast is a forecast response with only the hourly block.
+type HourlyForecast struct {
+	Latitude  float64         `json:"latitude"`
+	Longitude float64         `json:"longitude"`
+	Timezone  string          `json:"timezone"`
+	Hourly    HourlyDataBlock `json:"hourly"`
+}
+
+// DailyForecast is a forecast response with only the daily block.
+type DailyForecast struct {
+	Latitude  float64        `json:"latitude"`
+	Longitude float64        `json:"longitude"`
+	Timezone  string         `json:"timezone"`
+	Daily     DailyDataBlock `json:"daily"`
+}
+
+// Current returns only the current conditions at loc. The other blocks are
+// excluded from the request, so the response is smaller and quicker to
+// decode than a full Forecast.
+func (c *Client) Current(ctx context.Context, loc Location) (*CurrentForecast, error) {
+	var fc CurrentForecast
+	if err := c.project(ctx, loc, "currently", &fc); err != nil {
+		return nil, err
+	}
+	return &fc, nil
+}
+
+// Minutely returns only the minute-by-minute forecast for the next hour at
+// loc.
+func (c *Client) Minutely(ctx context.Context, loc Location) (*MinutelyForecast, error) {
+	var fc MinutelyForecast
+	if err := c.project(ctx, loc, "minutely", &fc); err != nil {
+		return nil, err
+	}
+	return &fc, nil
+}
+
+// Hourly returns only the hour-by-hour forecast at loc.
+func (c *Client) Hourly(ctx context.Context, loc Location) (*HourlyForecast, error) {
+	var fc HourlyForecast
+	if err := c.project(ctx, loc, "hourly", &fc); err != nil {
+		return nil, err
+	}
+	return &fc, nil
+}
+
+// Daily returns only the day-by-day forecast for the next week at loc.
+func (c *Client) Daily(ctx context.Context, loc Location) (*DailyForecast, error) {
+	var fc DailyForecast
+	if err := c.project(ctx, loc, "daily", &fc); err != nil {
+		return nil, err
+	}
+	return &fc, nil
+}
+
+func (c *Client) project(ctx context.Context, loc Location, keep string, v interface{}) error {
+	location, err := c.latlong(loc.Lat, loc.Long)
+	if err != nil {
+		return err
+	}
+	return c.fetch(ctx, location, excludeAllBut(keep), v)
+}
+
+// Raw returns the full forecast at loc without decoding its blocks. Each
+// block is decoded the first time it is asked for, so callers that only
+// look at one or two blocks don't pay for the rest.
+func (c *Client) Raw(ctx context.Context, loc Location) (*RawForecast, error) {
+	location, err := c.latlong(loc.Lat, loc.Long)
+	if err != nil {
+		return nil, err
+	}
+	var raw RawForecast
+	if err := c.fetch(ctx, location, "", &raw); err != nil {
+		return nil, err
+	}
+	return &raw, nil
+}
+
+// RawForecast is a forecast response whose blocks are kept as JSON until
+// they are first accessed. It is safe for concurrent use. Blocks missing
+// from the response decode to their zero value.
+type RawForecast struct {
+	Latitude  float64
+	Longitude float64
+	Timezone  string
+
+	currently lazyBlock
+	minutely  lazyBlock
+	hourly    lazyBlock
+	daily     lazyBlock
+	alerts    lazyBlock
+
+	currentlyV CurrentDataPoint
+	minutelyV  MinutelyDataBlock
+	hourlyV    HourlyDataBlock
+	dailyV     DailyDataBlock
+	alertsV    []Alert
+}
+
+// lazyBlock decodes a block of JSON once.
+type lazyBlock struct {
+	raw  json.RawMessage
+	once sync.Once
+	err  error
+}
+
+func (b *lazyBlock) decode(v interface{}) error {
+	b.once.Do(func() {
+		if len(b.raw) > 0 {
+			b.err = json.Unmarshal(b.raw, v)
+		}
+		b.raw = nil
+	})
+	return b.err
+}
+
+// UnmarshalJSON splits data into its blocks without decoding them.
+func (f *RawForecast) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Latitude  float64         `json:"latitude"`
+		Longitude float64         `json:"longitude"`
+		Timezone  string          `json:"timezone"`
+		Currently json.RawMessage `json:"currently"`
+		Minutely  json.RawMessage `json:"minutely"`
+		Hourly    json.RawMessage `json:"hourly"`
+		Daily     json.RawMessage `json:"daily"`
+		Alerts    json.RawMessage `json:"alerts"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*f = RawForecast{
+		Latitude:  v.Latitude,
+		Longitude: v.Longitude,
+		Timezone:  v.Timezone,
+		currently: lazyBlock{raw: v.Currently},
+		minutely:  lazyBlock{raw: v.Minutely},
+		hourly:    lazyBlock{raw: v.Hourly},
+		daily:     lazyBlock{raw: v.Daily},
+		alerts:    lazyBlock{raw: v.Alerts},
+	}
+	return nil
+}
+
+// Currently decodes and returns the current conditions.
+func (f *RawForecast) Currently() (CurrentDataPoint, error) {
+	err := f.currently.decode(&f.currentlyV)
+	return f.currentlyV, err
+}
+
+// Minutely decodes and returns the minutely block.
+func (f *RawForecast) Minutely() (MinutelyDataBlock, error) {
+	err := f.minutely.decode(&f.minutelyV)
+	return f.minutelyV, err
+}
+
+// Hourly decodes and returns the hourly block.
+func (f *RawForecast) Hourly() (HourlyDataBlock, error) {
+	err := f.hourly.decode(&f.hourlyV)
+	return f.hourlyV, err
+}
+
+// Daily decodes and returns the daily block.
+func (f *RawForecast) Daily() (DailyDataBlock, error) {
+	err := f.daily.decode(&f.dailyV)
+	return f.dailyV, err
+}
+
+// Alerts decodes and returns the alerts.
+func (f *RawForecast) Alerts() ([]Alert, error) {
+	err := f.alerts.decode(&f.alertsV)
+	return f.alertsV, err
+}
+
+// Forecast decodes every block and returns the full forecast.
+func (f *RawForecast) Forecast() (*Forecast, error) {
+	fc := &Forecast{Latitude: f.Latitude, Longitude: f.Longitude, Timezone: f.Timezone}
+	var err error
+	if fc.Currently, err = f.Currently(); err != nil {
+		return nil, err
+	}
+	if fc.Minutely, err = f.Minutely(); err != nil {
+		return nil, err
+	}
+	if fc.Hourly, err = f.Hourly(); err != nil {
+		return nil, err
+	}
+	if fc.Daily, err = f.Daily(); err != nil {
+		return nil, err
+	}
+	if fc.Alerts, err = f.Alerts(); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
@@ -0,0 +1,158 @@
+package darksky_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+func TestClient_projections(t *testing.T) {
+	data := readFixture(t)
+	want := loadForecast(t, "SouthernTerminus.json")
+	var exclude string
+	c := &darksky.Client{
+		Key: "key",
+		HttpClient: darksky.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			exclude = req.URL.Query().Get("exclude")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(data)),
+			}, nil
+		}),
+	}
+	ctx := context.Background()
+	loc := darksky.Location{Lat: stLat, Long: stLong}
+
+	tests := map[string]struct {
+		get     func() (interface{}, error)
+		want    interface{}
+		exclude string
+	}{
+		"Current": {
+			get:     func() (interface{}, error) { fc, err := c.Current(ctx, loc); return fc.Currently, err },
+			want:    want.Currently,
+			exclude: "minutely,hourly,daily,alerts,flags",
+		},
+		"Minutely": {
+			get:     func() (interface{}, error) { fc, err := c.Minutely(ctx, loc); return fc.Minutely, err },
+			want:    want.Minutely,
+			exclude: "currently,hourly,daily,alerts,flags",
+		},
+		"Hourly": {
+			get:     func() (interface{}, error) { fc, err := c.Hourly(ctx, loc); return fc.Hourly, err },
+			want:    want.Hourly,
+			exclude: "currently,minutely,daily,alerts,flags",
+		},
+		"Daily": {
+			get:     func() (interface{}, error) { fc, err := c.Daily(ctx, loc); return fc.Daily, err },
+			want:    want.Daily,
+			exclude: "currently,minutely,hourly,alerts,flags",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.get()
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%s() = %+v; want %+v", name, got, tc.want)
+			}
+			if exclude != tc.exclude {
+				t.Errorf("exclude = %q; want %q", exclude, tc.exclude)
+			}
+		})
+	}
+
+	if _, err := c.Current(ctx, darksky.Location{Lat: 91}); !isValidationError(err) {
+		t.Errorf("err = %v; want a *ValidationError", err)
+	}
+}
+
+func TestClient_Raw(t *testing.T) {
+	want := loadForecast(t, "SouthernTerminus.json")
+	c := &darksky.Client{Key: "key", HttpClient: bodyDoer(t, readFixture(t), false)}
+	raw, err := c.Raw(context.Background(), darksky.Location{Lat: stLat, Long: stLong})
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if raw.Timezone != want.Timezone || raw.Latitude != want.Latitude {
+		t.Errorf("Timezone, Latitude = %q, %v; want %q, %v", raw.Timezone, raw.Latitude, want.Timezone, want.Latitude)
+	}
+	currently, err := raw.Currently()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if currently != want.Currently {
+		t.Errorf("Currently() = %+v; want %+v", currently, want.Currently)
+	}
+	got, err := raw.Forecast()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Forecast() = %+v; want %+v", got, want)
+	}
+}
+
+func TestRawForecast_badBlock(t *testing.T) {
+	var raw darksky.RawForecast
+	if err := json.Unmarshal([]byte(`{"timezone":"UTC","hourly":{"data":"nope"}}`), &raw); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if _, err := raw.Currently(); err != nil {
+		t.Errorf("Currently() err = %v; want nil for a missing block", err)
+	}
+	_, err := raw.Hourly()
+	if err == nil {
+		t.Fatalf("Hourly() err = nil; want an error")
+	}
+	if _, again := raw.Hourly(); again != err {
+		t.Errorf("second Hourly() err = %v; want %v", again, err)
+	}
+	if _, ferr := raw.Forecast(); ferr != err {
+		t.Errorf("Forecast() err = %v; want %v", ferr, err)
+	}
+}
+
+// BenchmarkProjection compares decoding the full fixture with decoding only
+// the current conditions, either into the lean type or lazily.
+func BenchmarkProjection(b *testing.B) {
+	data := readFixture(b)
+	b.Run("Forecast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var fc darksky.Forecast
+			if err := json.Unmarshal(data, &fc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("CurrentForecast", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var fc darksky.CurrentForecast
+			if err := json.Unmarshal(data, &fc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("RawForecast.Currently", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var raw darksky.RawForecast
+			if err := json.Unmarshal(data, &raw); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := raw.Currently(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
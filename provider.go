@@ -0,0 +1,17 @@
+package darksky
+
+import "context"
+
+// Provider is a source of forecasts in the model of this package. Client is
+// the Dark Sky implementation; the openmeteo and openweathermap packages
+// adapt other services to it.
+type Provider interface {
+	// Forecast returns the current conditions and the forecast at the
+	// given coordinates.
+	Forecast(lat, long float64) (*Forecast, error)
+
+	// ForecastContext is like Forecast but makes its requests with ctx.
+	ForecastContext(ctx context.Context, lat, long float64) (*Forecast, error)
+}
+
+var _ Provider = (*Client)(nil)
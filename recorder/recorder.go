@@ -0,0 +1,376 @@
+// Package recorder records HTTP interactions to cassette files and replays
+// them, so that tests can run against real API responses without network
+// access or an API key.
+//
+// A Recorder is a darksky.Doer, so it can be used as Client.HttpClient:
+//
+//	rec, err := recorder.New("testdata/forecast.json", recorder.ModeReplay)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	c := darksky.Client{Key: key, HttpClient: rec}
+//
+// Requests are matched to recorded interactions by method, path and query,
+// not by the order they are made in.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mode determines what a Recorder does with requests.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette without making them.
+	ModeReplay Mode = iota
+	// ModeRecord makes requests and adds them to the cassette.
+	ModeRecord
+	// ModePassthrough makes requests without recording them.
+	ModePassthrough
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+	case ModeRecord:
+		return "record"
+	case ModePassthrough:
+		return "passthrough"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Redacted replaces secrets in recorded interactions.
+const Redacted = "REDACTED"
+
+// sensitiveHeaders are never recorded with their values.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+var darkskyKey = regexp.MustCompile(`/forecast/[^/]*/`)
+
+// RedactDarkSkyKey replaces the API key in the path of Dark Sky forecast URLs.
+func RedactDarkSkyKey(s string) string {
+	return darkskyKey.ReplaceAllString(s, "/forecast/"+Redacted+"/")
+}
+
+// RedactStrings returns a redaction func replacing each of the secrets.
+func RedactStrings(secrets ...string) func(string) string {
+	return func(s string) string {
+		for _, secret := range secrets {
+			if secret != "" {
+				s = strings.Replace(s, secret, Redacted, -1)
+			}
+		}
+		return s
+	}
+}
+
+// Recorder is an HTTP client that records, replays or passes through
+// requests depending on its Mode.
+type Recorder struct {
+	Mode     Mode
+	Cassette *Cassette
+
+	// HttpClient makes the requests in ModeRecord and ModePassthrough. It
+	// defaults to an http.Client.
+	HttpClient interface {
+		Do(*http.Request) (*http.Response, error)
+	}
+
+	// Redact, if set, is applied to the URL, query values, header values and
+	// body of requests before they are recorded or matched, and to the
+	// headers and body of recorded responses. It defaults to
+	// RedactDarkSkyKey.
+	Redact func(string) string
+
+	mu   sync.Mutex
+	used map[string]int // times each match key has been replayed
+}
+
+// New returns a Recorder for the cassette at path. In ModeReplay the
+// cassette must exist; in ModeRecord a new cassette is started, replacing
+// the file when Save is called.
+func New(path string, mode Mode) (*Recorder, error) {
+	cassette := &Cassette{Path: path}
+	if mode == ModeReplay {
+		var err error
+		cassette, err = LoadCassette(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Recorder{
+		Mode:     mode,
+		Cassette: cassette,
+	}, nil
+}
+
+// Save writes the cassette to its file if the recorder is recording.
+func (r *Recorder) Save() error {
+	if r.Mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Cassette.Save()
+}
+
+// Do makes, records or replays the request depending on the mode.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	switch r.Mode {
+	case ModeReplay:
+		return r.replay(req)
+	case ModeRecord:
+		return r.record(req)
+	case ModePassthrough:
+		return r.httpClient().Do(req)
+	}
+	return nil, fmt.Errorf("recorder: unknown mode %v", r.Mode)
+}
+
+func (r *Recorder) httpClient() interface {
+	Do(*http.Request) (*http.Response, error)
+} {
+	if r.HttpClient == nil {
+		return &http.Client{}
+	}
+	return r.HttpClient
+}
+
+func (r *Recorder) redact(s string) string {
+	if r.Redact == nil {
+		return RedactDarkSkyKey(s)
+	}
+	return r.Redact(s)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	recorded, err := r.newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	r.Cassette.Interactions = append(r.Cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: Response{
+			StatusCode: res.StatusCode,
+			Header:     r.redactHeader(res.Header),
+			Body:       []byte(r.redact(string(body))),
+		},
+	})
+	r.mu.Unlock()
+	return res, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	incoming, err := r.newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	key := incoming.matchKey()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matches []Interaction
+	for _, in := range r.Cassette.Interactions {
+		if in.Request.matchKey() == key {
+			matches = append(matches, in)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, &UnmatchedRequestError{
+			Method:   incoming.Method,
+			Path:     incoming.Path,
+			Query:    incoming.Query,
+			Cassette: r.Cassette.Path,
+		}
+	}
+	if r.used == nil {
+		r.used = make(map[string]int)
+	}
+	// Matching interactions are replayed in the order they were recorded,
+	// repeating the last one once they run out.
+	i := r.used[key]
+	if i >= len(matches) {
+		i = len(matches) - 1
+	}
+	r.used[key]++
+	recorded := matches[i].Response
+
+	header := make(http.Header)
+	for k, v := range recorded.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// newRequest returns the redacted form of req, leaving req's body readable.
+func (r *Recorder) newRequest(req *http.Request) (Request, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			return Request{}, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	query := make(url.Values)
+	for k, values := range req.URL.Query() {
+		for _, v := range values {
+			query.Add(k, r.redact(v))
+		}
+	}
+	u := *req.URL
+	u.User = nil
+	return Request{
+		Method: req.Method,
+		URL:    r.redact(u.String()),
+		Path:   r.redact(req.URL.Path),
+		Query:  query,
+		Header: r.redactHeader(req.Header),
+		Body:   []byte(r.redact(string(body))),
+	}, nil
+}
+
+func (r *Recorder) redactHeader(h http.Header) http.Header {
+	redacted := make(http.Header, len(h))
+	for k, values := range h {
+		for _, v := range values {
+			redacted.Add(k, r.redact(v))
+		}
+	}
+	for _, k := range sensitiveHeaders {
+		if _, ok := redacted[k]; ok {
+			redacted.Set(k, Redacted)
+		}
+	}
+	return redacted
+}
+
+// UnmatchedRequestError is returned in ModeReplay for requests that do not
+// match any interaction in the cassette.
+type UnmatchedRequestError struct {
+	Method   string
+	Path     string
+	Query    url.Values
+	Cassette string
+}
+
+func (e *UnmatchedRequestError) Error() string {
+	target := e.Path
+	if len(e.Query) > 0 {
+		target += "?" + e.Query.Encode()
+	}
+	return fmt.Sprintf("recorder: no interaction in cassette %s matches %s %s", e.Cassette, e.Method, target)
+}
+
+// Cassette is a set of recorded interactions stored as JSON.
+type Cassette struct {
+	Path         string        `json:"-"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded, redacted request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Path   string      `json:"path"`
+	Query  url.Values  `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// matchKey identifies the requests a recorded request matches.
+func (req Request) matchKey() string {
+	keys := make([]string, 0, len(req.Query))
+	for k := range req.Query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(req.Method + " " + req.Path)
+	for _, k := range keys {
+		values := append([]string(nil), req.Query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			b.WriteString(" " + url.QueryEscape(k) + "=" + url.QueryEscape(v))
+		}
+	}
+	return b.String()
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body"`
+}
+
+// LoadCassette reads the cassette stored at path.
+func LoadCassette(path string) (*Cassette, error) {
+	jsonBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := Cassette{Path: path}
+	err = json.Unmarshal(jsonBytes, &c)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: failed to decode cassette %s: %v", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to its Path, creating directories as needed.
+func (c *Cassette) Save() error {
+	err := os.MkdirAll(filepath.Dir(c.Path), 0700)
+	if err != nil {
+		return err
+	}
+	jsonBytes, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.Path, jsonBytes, 0600)
+}
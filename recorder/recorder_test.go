@@ -0,0 +1,189 @@
+package recorder_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/darkskytest"
+	"github.com/sophiaehlen/darksky-client/recorder"
+)
+
+const secretKey = "secret-key-1234"
+
+// recordCassette records forecasts for two locations against a fake server
+// and returns the cassette's path.
+func recordCassette(t *testing.T) (string, *darkskytest.Server) {
+	srv := darkskytest.NewServer(1)
+	srv.Handler().Now = func() time.Time { return time.Unix(1576605879, 0) }
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := recorder.New(path, recorder.ModeRecord)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	c := darksky.Client{Key: secretKey, BaseURL: srv.URL, HttpClient: rec}
+	for _, coords := range [][2]float64{{32.58972, -116.466988}, {42.3601, -71.0589}} {
+		_, err := c.Forecast(coords[0], coords[1])
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+	}
+	err = rec.Save()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	return path, srv
+}
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	path, srv := recordCassette(t)
+
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if strings.Contains(string(saved), secretKey) {
+		t.Errorf("cassette contains the API key")
+	}
+
+	cassette, err := recorder.LoadCassette(path)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(cassette.Interactions) != 2 {
+		t.Fatalf("len(Interactions) = %d; want 2", len(cassette.Interactions))
+	}
+	in := cassette.Interactions[0]
+	if in.Request.Method != http.MethodGet {
+		t.Errorf("Request.Method = %q; want GET", in.Request.Method)
+	}
+	if in.Request.Path != "/forecast/REDACTED/32.589720,-116.466988" {
+		t.Errorf("Request.Path = %q; want the redacted path", in.Request.Path)
+	}
+	if got := in.Request.Header.Get("Authorization"); got != recorder.Redacted {
+		t.Errorf("Request.Header[Authorization] = %q; want %q", got, recorder.Redacted)
+	}
+	if in.Response.StatusCode != http.StatusOK || len(in.Response.Body) == 0 {
+		t.Errorf("Response = %d with %d bytes; want 200 with a body", in.Response.StatusCode, len(in.Response.Body))
+	}
+	if in.Response.Header.Get("Content-Type") == "" {
+		t.Errorf("Response.Header is missing Content-Type")
+	}
+
+	rec, err := recorder.New(path, recorder.ModeReplay)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	requestsBefore := srv.Handler().Requests()
+	// Replay in the opposite order, with a different key and no server.
+	c := darksky.Client{Key: "another-key", BaseURL: "http://127.0.0.1:1", HttpClient: rec}
+	boston, err := c.Forecast(42.3601, -71.0589)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if boston.Latitude != 42.3601 {
+		t.Errorf("Latitude = %f; want 42.3601", boston.Latitude)
+	}
+	st, err := c.Forecast(32.58972, -116.466988)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if st.Latitude != 32.58972 {
+		t.Errorf("Latitude = %f; want 32.58972", st.Latitude)
+	}
+	if srv.Handler().Requests() != requestsBefore {
+		t.Errorf("replay made %d requests to the server; want 0", srv.Handler().Requests()-requestsBefore)
+	}
+}
+
+func TestRecorder_Unmatched(t *testing.T) {
+	path, _ := recordCassette(t)
+	rec, err := recorder.New(path, recorder.ModeReplay)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://api.darksky.net/forecast/key/32.589720,-116.466988?units=si", nil)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	_, err = rec.Do(req)
+	unmatched, ok := err.(*recorder.UnmatchedRequestError)
+	if !ok {
+		t.Fatalf("err = %v; want *UnmatchedRequestError", err)
+	}
+	if unmatched.Path != "/forecast/REDACTED/32.589720,-116.466988" || unmatched.Query.Get("units") != "si" {
+		t.Errorf("err = %+v; want the redacted path and query", unmatched)
+	}
+	if !strings.Contains(err.Error(), "GET /forecast/REDACTED/32.589720,-116.466988?units=si") {
+		t.Errorf("err = %q; want it to name the request", err)
+	}
+}
+
+func TestRecorder_QueryMatching(t *testing.T) {
+	cassette := &recorder.Cassette{Interactions: []recorder.Interaction{
+		{
+			Request:  recorder.Request{Method: http.MethodGet, Path: "/forecast/REDACTED/1,2", Query: map[string][]string{"units": {"si"}, "exclude": {"minutely"}}},
+			Response: recorder.Response{StatusCode: http.StatusOK, Body: []byte("si")},
+		},
+		{
+			Request:  recorder.Request{Method: http.MethodGet, Path: "/forecast/REDACTED/1,2"},
+			Response: recorder.Response{StatusCode: http.StatusOK, Body: []byte("first")},
+		},
+		{
+			Request:  recorder.Request{Method: http.MethodGet, Path: "/forecast/REDACTED/1,2"},
+			Response: recorder.Response{StatusCode: http.StatusOK, Body: []byte("second")},
+		},
+	}}
+	rec := &recorder.Recorder{Mode: recorder.ModeReplay, Cassette: cassette}
+
+	for i, tc := range []struct {
+		url  string
+		want string
+	}{
+		{"https://api.darksky.net/forecast/key/1,2", "first"},
+		{"https://api.darksky.net/forecast/key/1,2?exclude=minutely&units=si", "si"},
+		{"https://api.darksky.net/forecast/key/1,2", "second"},
+		{"https://api.darksky.net/forecast/key/1,2", "second"},
+	} {
+		req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		res, err := rec.Do(req)
+		if err != nil {
+			t.Fatalf("request %d: err = %v; want nil", i, err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		if string(body) != tc.want {
+			t.Errorf("request %d: body = %q; want %q", i, body, tc.want)
+		}
+	}
+}
+
+func TestRecorder_Passthrough(t *testing.T) {
+	srv := darkskytest.NewServer(1)
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec := &recorder.Recorder{Mode: recorder.ModePassthrough, Cassette: &recorder.Cassette{Path: path}}
+	c := darksky.Client{Key: secretKey, BaseURL: srv.URL, HttpClient: rec}
+	_, err := c.Forecast(32.58972, -116.466988)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(rec.Cassette.Interactions) != 0 {
+		t.Errorf("len(Interactions) = %d; want 0", len(rec.Cassette.Interactions))
+	}
+	err = rec.Save()
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if _, err := recorder.LoadCassette(path); err == nil {
+		t.Errorf("Save wrote a cassette in passthrough mode")
+	}
+}
@@ -0,0 +1,291 @@
+package darksky
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sophiaehlen/darksky-client/geo"
+)
+
+// DefaultWaypointSpacing is the distance in kilometres between the
+// waypoints of a route when RouteOptions.Spacing is not set.
+const DefaultWaypointSpacing = 25.0
+
+// Hazard is a kind of dangerous driving condition.
+type Hazard string
+
+const (
+	HazardWind          Hazard = "wind"
+	HazardPrecipitation Hazard = "precipitation"
+	HazardIce           Hazard = "ice"
+	HazardVisibility    Hazard = "visibility"
+	HazardSevere        Hazard = "severe"
+)
+
+// HazardThresholds decide which conditions are hazardous. The values are in
+// the units of the forecast, which are US units unless requested otherwise.
+type HazardThresholds struct {
+	WindGust          float64  // gusts at or above this speed
+	PrecipProbability float64  // precipitation at or above this probability
+	Visibility        float64  // visibility below this distance, if known
+	Icons             []string // icons that are hazardous in themselves
+}
+
+// DefaultHazards are the thresholds used when RouteOptions.Hazards is nil.
+var DefaultHazards = HazardThresholds{
+	WindGust:          40,
+	PrecipProbability: 0.6,
+	Visibility:        1,
+	Icons:             []string{"sleet", "snow", "fog", "hail", "thunderstorm", "tornado"},
+}
+
+// RouteOptions configure Client.Route.
+type RouteOptions struct {
+	// Departure is when the journey starts. It defaults to now.
+	Departure time.Time
+
+	// Speed is the average speed in km/h. It must be positive.
+	Speed float64
+
+	// Spacing is the distance in kilometres between waypoints. It defaults
+	// to DefaultWaypointSpacing.
+	Spacing float64
+
+	// TimeMachine requests each waypoint's forecast with a Time Machine
+	// request for its time of arrival rather than a forecast request. This
+	// allows journeys beyond the two days covered by the hourly forecast.
+	TimeMachine bool
+
+	// Hazards are the thresholds used to flag waypoints. They default to
+	// DefaultHazards.
+	Hazards *HazardThresholds
+}
+
+// Waypoint is a point along a route with the conditions expected when it is
+// reached.
+type Waypoint struct {
+	Location
+	Distance   float64 // kilometres from the start of the route
+	ETA        time.Time
+	Conditions HourlyDataPoint
+	Hazards    []Hazard
+}
+
+// RouteSegment is a stretch of consecutive hazardous waypoints, from
+// Waypoints[Start] to Waypoints[End] inclusive.
+type RouteSegment struct {
+	Start   int
+	End     int
+	Hazards []Hazard
+}
+
+// RouteForecast is the weather along a route.
+type RouteForecast struct {
+	Waypoints []Waypoint
+	Segments  []RouteSegment // the hazardous stretches of the route
+}
+
+// Route samples waypoints along path every opts.Spacing kilometres, fetches
+// a forecast for each and returns the conditions expected at each waypoint
+// when it is reached at opts.Speed, interpolated from the hourly forecast.
+// The start and end of the path are always waypoints.
+func (c *Client) Route(ctx context.Context, path []Location, opts RouteOptions) (*RouteForecast, error) {
+	if len(path) == 0 {
+		return nil, ErrEmptyRoute
+	}
+	for _, l := range path {
+		if err := l.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if !(opts.Speed > 0) || math.IsInf(opts.Speed, 0) {
+		return nil, &ValidationError{Field: "Speed", Value: opts.Speed, Reason: "must be positive"}
+	}
+	spacing := opts.Spacing
+	if spacing <= 0 {
+		spacing = DefaultWaypointSpacing
+	}
+	departure := opts.Departure
+	if departure.IsZero() {
+		departure = time.Now()
+	}
+	thresholds := &DefaultHazards
+	if opts.Hazards != nil {
+		thresholds = opts.Hazards
+	}
+
+	route := &RouteForecast{}
+	for _, wp := range sample(path, spacing) {
+		wp.ETA = departure.Add(time.Duration(wp.Distance / opts.Speed * float64(time.Hour)))
+		location, err := c.latlong(wp.Lat, wp.Long)
+		if err != nil {
+			return nil, err
+		}
+		if opts.TimeMachine {
+			location += "," + strconv.FormatInt(wp.ETA.Unix(), 10)
+		}
+		fc, err := c.forecast(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		wp.Conditions, err = fc.At(wp.ETA)
+		if err != nil {
+			return nil, err
+		}
+		wp.Hazards = thresholds.check(wp.Conditions)
+		route.Waypoints = append(route.Waypoints, wp)
+	}
+	route.Segments = segments(route.Waypoints)
+	return route, nil
+}
+
+// sample returns waypoints every spacing kilometres along path, plus its
+// end. Legs take the short way round, across the antimeridian if need be.
+func sample(path []Location, spacing float64) []Waypoint {
+	waypoints := []Waypoint{{Location: path[0]}}
+	travelled, next := 0.0, spacing
+	for i := 1; i < len(path); i++ {
+		a, b := path[i-1], path[i]
+		d := geo.Distance(a.Lat, a.Long, b.Lat, b.Long)
+		dLong := b.Long - a.Long
+		if dLong > 180 {
+			dLong -= 360
+		} else if dLong <= -180 {
+			dLong += 360
+		}
+		for d > 0 && next <= travelled+d {
+			f := (next - travelled) / d
+			waypoints = append(waypoints, Waypoint{
+				Location: Location{Lat: a.Lat + f*(b.Lat-a.Lat), Long: a.Long + f*dLong}.Normalize(),
+				Distance: next,
+			})
+			next += spacing
+		}
+		travelled += d
+	}
+	if last := waypoints[len(waypoints)-1]; travelled-last.Distance > 1e-6 {
+		waypoints = append(waypoints, Waypoint{Location: path[len(path)-1], Distance: travelled})
+	}
+	return waypoints
+}
+
+// check returns the hazards of the conditions.
+func (h *HazardThresholds) check(p HourlyDataPoint) []Hazard {
+	var hazards []Hazard
+	if h.WindGust > 0 && p.WindGust >= h.WindGust {
+		hazards = append(hazards, HazardWind)
+	}
+	if h.PrecipProbability > 0 && p.PrecipProbability >= h.PrecipProbability {
+		hazards = append(hazards, HazardPrecipitation)
+		if p.PrecipType == "snow" || p.PrecipType == "sleet" || p.Temperature <= 32 {
+			hazards = append(hazards, HazardIce)
+		}
+	}
+	// A visibility of zero is what a forecast without one decodes to.
+	if h.Visibility > 0 && p.Visibility > 0 && p.Visibility < h.Visibility {
+		hazards = append(hazards, HazardVisibility)
+	}
+	for _, icon := range h.Icons {
+		if p.Icon == icon {
+			hazards = append(hazards, HazardSevere)
+			break
+		}
+	}
+	return hazards
+}
+
+// segments groups runs of consecutive hazardous waypoints.
+func segments(waypoints []Waypoint) []RouteSegment {
+	var segs []RouteSegment
+	for i, wp := range waypoints {
+		if len(wp.Hazards) == 0 {
+			continue
+		}
+		if n := len(segs); n > 0 && segs[n-1].End == i-1 {
+			segs[n-1].End = i
+			segs[n-1].Hazards = union(segs[n-1].Hazards, wp.Hazards)
+			continue
+		}
+		segs = append(segs, RouteSegment{Start: i, End: i, Hazards: append([]Hazard(nil), wp.Hazards...)})
+	}
+	return segs
+}
+
+func union(a, b []Hazard) []Hazard {
+	for _, h := range b {
+		found := false
+		for _, x := range a {
+			if x == h {
+				found = true
+				break
+			}
+		}
+		if !found {
+			a = append(a, h)
+		}
+	}
+	return a
+}
+
+// DecodePolyline decodes a path in Google's encoded polyline format with
+// five decimal places of precision.
+func DecodePolyline(s string) ([]Location, error) {
+	var path []Location
+	var lat, long int
+	for i := 0; i < len(s); {
+		var deltas [2]int
+		for j := range deltas {
+			result, shift := 0, uint(0)
+			for {
+				if i >= len(s) || shift > 30 {
+					return nil, ErrInvalidPolyline
+				}
+				b := int(s[i]) - 63
+				i++
+				if b < 0 || b > 63 {
+					return nil, ErrInvalidPolyline
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[j] = ^(result >> 1)
+			} else {
+				deltas[j] = result >> 1
+			}
+		}
+		lat += deltas[0]
+		long += deltas[1]
+		path = append(path, Location{Lat: float64(lat) / 1e5, Long: float64(long) / 1e5})
+	}
+	return path, nil
+}
+
+// EncodePolyline encodes a path in Google's encoded polyline format with
+// five decimal places of precision.
+func EncodePolyline(path []Location) string {
+	var b strings.Builder
+	var prevLat, prevLong int
+	for _, l := range path {
+		lat, long := int(math.Round(l.Lat*1e5)), int(math.Round(l.Long*1e5))
+		for _, d := range []int{lat - prevLat, long - prevLong} {
+			v := d << 1
+			if d < 0 {
+				v = ^v
+			}
+			for v >= 0x20 {
+				b.WriteByte(byte((0x20 | (v & 0x1f)) + 63))
+				v >>= 5
+			}
+			b.WriteByte(byte(v + 63))
+		}
+		prevLat, prevLong = lat, long
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package darksky_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/darkskytest"
+)
+
+func TestDecodePolyline(t *testing.T) {
+	// The example from Google's polyline documentation.
+	path, err := darksky.DecodePolyline("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	want := []darksky.Location{{Lat: 38.5, Long: -120.2}, {Lat: 40.7, Long: -120.95}, {Lat: 43.252, Long: -126.453}}
+	if len(path) != len(want) {
+		t.Fatalf("len(path) = %d; want %d", len(path), len(want))
+	}
+	for i := range want {
+		if math.Abs(path[i].Lat-want[i].Lat) > 1e-9 || math.Abs(path[i].Long-want[i].Long) > 1e-9 {
+			t.Errorf("path[%d] = %v; want %v", i, path[i], want[i])
+		}
+	}
+	if got := darksky.EncodePolyline(want); got != "_p~iF~ps|U_ulLnnqC_mqNvxq`@" {
+		t.Errorf("EncodePolyline() = %q; want the original polyline", got)
+	}
+
+	for _, s := range []string{"_p~iF~ps|U_", "_p~iF", "\x01\x02"} {
+		if _, err := darksky.DecodePolyline(s); err != darksky.ErrInvalidPolyline {
+			t.Errorf("DecodePolyline(%q) err = %v; want %v", s, err, darksky.ErrInvalidPolyline)
+		}
+	}
+}
+
+// pctNorth is just over 100 km of the Pacific Crest Trail heading north from
+// the southern terminus.
+var pctNorth = []darksky.Location{
+	{Lat: 32.589720, Long: -116.466988},
+	{Lat: 32.9, Long: -116.5},
+	{Lat: 33.49, Long: -116.6},
+}
+
+func TestClient_Route(t *testing.T) {
+	now := time.Date(2019, 12, 17, 8, 0, 0, 0, time.UTC)
+	fake := darkskytest.NewHandler(1)
+	fake.Now = func() time.Time { return now }
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	c := darksky.Client{
+		Key:     "gibberish-key",
+		BaseURL: server.URL,
+	}
+
+	t.Run("waypoints", func(t *testing.T) {
+		paths = nil
+		route, err := c.Route(context.Background(), pctNorth, darksky.RouteOptions{
+			Departure: now,
+			Speed:     50,
+			Spacing:   25,
+		})
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		wps := route.Waypoints
+		if len(wps) != 6 {
+			t.Fatalf("len(Waypoints) = %d; want 6", len(wps))
+		}
+		if wps[0].Location != pctNorth[0] || wps[5].Location != pctNorth[2] {
+			t.Errorf("route runs from %v to %v; want %v to %v", wps[0].Location, wps[5].Location, pctNorth[0], pctNorth[2])
+		}
+		for i, wp := range wps {
+			if i < 5 && math.Abs(wp.Distance-25*float64(i)) > 1e-9 {
+				t.Errorf("Waypoints[%d].Distance = %f; want %d", i, wp.Distance, 25*i)
+			}
+			wantETA := now.Add(time.Duration(wp.Distance / 50 * float64(time.Hour)))
+			if !wp.ETA.Equal(wantETA) {
+				t.Errorf("Waypoints[%d].ETA = %v; want %v", i, wp.ETA, wantETA)
+			}
+			if int64(wp.Conditions.Time) != wp.ETA.Unix() {
+				t.Errorf("Waypoints[%d].Conditions.Time = %d; want the ETA %d", i, wp.Conditions.Time, wp.ETA.Unix())
+			}
+		}
+		if len(paths) != 6 {
+			t.Errorf("requests = %d; want one per waypoint", len(paths))
+		}
+	})
+
+	t.Run("time machine at the ETA", func(t *testing.T) {
+		paths = nil
+		departure := now.AddDate(0, 0, 5)
+		route, err := c.Route(context.Background(), pctNorth, darksky.RouteOptions{
+			Departure:   departure,
+			Speed:       50,
+			TimeMachine: true,
+		})
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		for i, wp := range route.Waypoints {
+			if !strings.HasSuffix(paths[i], ","+strconv.FormatInt(wp.ETA.Unix(), 10)) {
+				t.Errorf("request %d = %q; want a Time Machine request for %d", i, paths[i], wp.ETA.Unix())
+			}
+		}
+	})
+
+	t.Run("beyond the hourly forecast", func(t *testing.T) {
+		_, err := c.Route(context.Background(), pctNorth, darksky.RouteOptions{
+			Departure: now.AddDate(0, 0, 5),
+			Speed:     50,
+		})
+		if err != darksky.ErrOutOfRange {
+			t.Errorf("err = %v; want %v", err, darksky.ErrOutOfRange)
+		}
+	})
+
+	t.Run("hazardous segments", func(t *testing.T) {
+		route, err := c.Route(context.Background(), pctNorth, darksky.RouteOptions{
+			Departure: now,
+			Speed:     50,
+			Hazards:   &darksky.HazardThresholds{WindGust: 0.01},
+		})
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if len(route.Segments) != 1 {
+			t.Fatalf("len(Segments) = %d; want 1", len(route.Segments))
+		}
+		seg := route.Segments[0]
+		if seg.Start != 0 || seg.End != len(route.Waypoints)-1 {
+			t.Errorf("Segments[0] = %d-%d; want the whole route", seg.Start, seg.End)
+		}
+		if len(seg.Hazards) != 1 || seg.Hazards[0] != darksky.HazardWind {
+			t.Errorf("Segments[0].Hazards = %v; want [%s]", seg.Hazards, darksky.HazardWind)
+		}
+	})
+
+	t.Run("across the antimeridian", func(t *testing.T) {
+		path := []darksky.Location{{Lat: -17, Long: 179}, {Lat: -17, Long: -179}}
+		route, err := c.Route(context.Background(), path, darksky.RouteOptions{Departure: now, Speed: 50, Spacing: 50})
+		if err != nil {
+			t.Fatalf("err = %v; want nil", err)
+		}
+		if len(route.Waypoints) != 6 {
+			t.Fatalf("len(Waypoints) = %d; want 6", len(route.Waypoints))
+		}
+		for i, wp := range route.Waypoints {
+			if math.Abs(wp.Long) < 179 || wp.Long < -180 || wp.Long > 180 {
+				t.Errorf("Waypoints[%d].Long = %f; want within a degree of 180", i, wp.Long)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := c.Route(context.Background(), nil, darksky.RouteOptions{Speed: 50}); err != darksky.ErrEmptyRoute {
+			t.Errorf("err = %v; want %v", err, darksky.ErrEmptyRoute)
+		}
+		if _, err := c.Route(context.Background(), pctNorth, darksky.RouteOptions{}); !isValidationError(err) {
+			t.Errorf("err = %v; want *ValidationError for a zero speed", err)
+		}
+		bad := []darksky.Location{{Lat: 91, Long: 0}}
+		if _, err := c.Route(context.Background(), bad, darksky.RouteOptions{Speed: 50}); !isValidationError(err) {
+			t.Errorf("err = %v; want *ValidationError", err)
+		}
+	})
+}
+
+func TestHazardThresholds_visibility(t *testing.T) {
+	h := &darksky.HazardThresholds{Visibility: 1}
+	tests := map[string]struct {
+		visibility float64
+		want       int
+	}{
+		"fog":     {visibility: 0.2, want: 1},
+		"clear":   {visibility: 10, want: 0},
+		"unknown": {visibility: 0, want: 0},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := h.Check(darksky.HourlyDataPoint{Visibility: tc.visibility}); len(got) != tc.want {
+				t.Errorf("Check() = %v; want %d hazards", got, tc.want)
+			}
+		})
+	}
+}
+
+func isValidationError(err error) bool {
+	_, ok := err.(*darksky.ValidationError)
+	return ok
+}
@@ -0,0 +1,369 @@
+package darksky
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Message IDs of the sentences in a Catalog.
+const (
+	MsgDry              = "dry"               // no precipitation in the hourly data
+	MsgPrecipStarting   = "precip.starting"   // {{.Precip}}, {{.Time}}
+	MsgPrecipStopping   = "precip.stopping"   // {{.Precip}}, {{.Time}}
+	MsgPrecipThroughout = "precip.throughout" // {{.Precip}}
+	MsgHeavy            = "heavy"             // appended to the precipitation clause
+	MsgGusts            = "gusts"             // {{.Speed}}, {{.Unit}}
+	MsgHourDry          = "hour.dry"          // no precipitation in the minutely data
+	MsgHourStarting     = "hour.starting"     // {{.Precip}}, {{.Minutes}}
+	MsgHourStopping     = "hour.stopping"     // {{.Precip}}, {{.Minutes}}
+	MsgHourThroughout   = "hour.throughout"   // {{.Precip}}
+	MsgWeekDry          = "week.dry"          // no precipitation in the daily data
+	MsgWeekPrecip       = "week.precip"       // {{.Precip}}, {{.Days}}
+	MsgWeekTemperatures = "week.temperatures" // {{.Low}}, {{.High}}
+)
+
+// Catalog holds the sentences of a summary in one language. Messages are
+// text/template sources keyed by message ID, and PrecipTypes name each
+// precipitation type at the start of a sentence.
+type Catalog struct {
+	Language    string
+	TimeLayout  string // how times are written, in the time package's layout
+	Weekdays    [7]string
+	And         string // joins the last two items of a list
+	PrecipTypes map[string]string
+	Messages    map[string]string
+}
+
+// English, German and Spanish are the built-in catalogs.
+var (
+	English = &Catalog{
+		Language:    "en",
+		TimeLayout:  "3 PM",
+		Weekdays:    [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		And:         "and",
+		PrecipTypes: map[string]string{"rain": "Rain", "snow": "Snow", "sleet": "Sleet"},
+		Messages: map[string]string{
+			MsgDry:              "No precipitation expected",
+			MsgPrecipStarting:   "{{.Precip}} starting around {{.Time}}",
+			MsgPrecipStopping:   "{{.Precip}} stopping around {{.Time}}",
+			MsgPrecipThroughout: "{{.Precip}} throughout",
+			MsgHeavy:            "heavy at times",
+			MsgGusts:            "gusts up to {{.Speed}} {{.Unit}}",
+			MsgHourDry:          "No precipitation for the hour",
+			MsgHourStarting:     "{{.Precip}} starting in {{.Minutes}} min",
+			MsgHourStopping:     "{{.Precip}} stopping in {{.Minutes}} min",
+			MsgHourThroughout:   "{{.Precip}} for the hour",
+			MsgWeekDry:          "No precipitation this week",
+			MsgWeekPrecip:       "{{.Precip}} on {{.Days}}",
+			MsgWeekTemperatures: "highs between {{.Low}}° and {{.High}}°",
+		},
+	}
+	German = &Catalog{
+		Language:    "de",
+		TimeLayout:  "15 Uhr",
+		Weekdays:    [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		And:         "und",
+		PrecipTypes: map[string]string{"rain": "Regen", "snow": "Schnee", "sleet": "Schneeregen"},
+		Messages: map[string]string{
+			MsgDry:              "Kein Niederschlag erwartet",
+			MsgPrecipStarting:   "{{.Precip}} ab etwa {{.Time}}",
+			MsgPrecipStopping:   "{{.Precip}} bis etwa {{.Time}}",
+			MsgPrecipThroughout: "{{.Precip}} durchgehend",
+			MsgHeavy:            "zeitweise stark",
+			MsgGusts:            "Böen bis zu {{.Speed}} {{.Unit}}",
+			MsgHourDry:          "Kein Niederschlag in der nächsten Stunde",
+			MsgHourStarting:     "{{.Precip}} in {{.Minutes}} Min.",
+			MsgHourStopping:     "{{.Precip}} endet in {{.Minutes}} Min.",
+			MsgHourThroughout:   "{{.Precip}} die ganze Stunde",
+			MsgWeekDry:          "Kein Niederschlag diese Woche",
+			MsgWeekPrecip:       "{{.Precip}} am {{.Days}}",
+			MsgWeekTemperatures: "Höchstwerte zwischen {{.Low}}° und {{.High}}°",
+		},
+	}
+	Spanish = &Catalog{
+		Language:    "es",
+		TimeLayout:  "15:04",
+		Weekdays:    [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		And:         "y",
+		PrecipTypes: map[string]string{"rain": "Lluvia", "snow": "Nieve", "sleet": "Aguanieve"},
+		Messages: map[string]string{
+			MsgDry:              "No se espera precipitación",
+			MsgPrecipStarting:   "{{.Precip}} a partir de las {{.Time}}",
+			MsgPrecipStopping:   "{{.Precip}} hasta las {{.Time}}",
+			MsgPrecipThroughout: "{{.Precip}} todo el tiempo",
+			MsgHeavy:            "fuerte a ratos",
+			MsgGusts:            "ráfagas de hasta {{.Speed}} {{.Unit}}",
+			MsgHourDry:          "Sin precipitación durante la próxima hora",
+			MsgHourStarting:     "{{.Precip}} en {{.Minutes}} min",
+			MsgHourStopping:     "{{.Precip}} termina en {{.Minutes}} min",
+			MsgHourThroughout:   "{{.Precip}} durante toda la hora",
+			MsgWeekDry:          "Sin precipitación esta semana",
+			MsgWeekPrecip:       "{{.Precip}} el {{.Days}}",
+			MsgWeekTemperatures: "máximas entre {{.Low}}° y {{.High}}°",
+		},
+	}
+)
+
+var (
+	catalogsMu sync.RWMutex
+	catalogs   = map[string]*Catalog{"en": English, "de": German, "es": Spanish}
+)
+
+// RegisterCatalog makes a catalog available to NewSummarizer under its
+// language, replacing any catalog already registered for it.
+func RegisterCatalog(c *Catalog) {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	catalogs[strings.ToLower(c.Language)] = c
+}
+
+// Summarizer writes forecast summaries from the data points of a forecast.
+type Summarizer struct {
+	Catalog *Catalog
+
+	// Hours is how many hours of the hourly data Hourly summarises. It
+	// defaults to 24.
+	Hours int
+
+	// PrecipProbability is the probability at which precipitation is
+	// mentioned. It defaults to 0.3.
+	PrecipProbability float64
+
+	// GustThreshold is the gust speed at which gusts are mentioned, and
+	// SpeedUnit the unit they are given in. They default to 25 and mph.
+	GustThreshold float64
+	SpeedUnit     string
+}
+
+// NewSummarizer returns a Summarizer for a language such as "de" or
+// "de-AT", using the catalog registered for it.
+func NewSummarizer(lang string) (*Summarizer, error) {
+	lang = strings.ToLower(lang)
+	catalogsMu.RLock()
+	defer catalogsMu.RUnlock()
+	c, ok := catalogs[lang]
+	if !ok {
+		c, ok = catalogs[strings.SplitN(lang, "-", 2)[0]]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no catalog for language %q", lang)
+	}
+	return &Summarizer{Catalog: c}, nil
+}
+
+// Minutely summarises the next hour of the forecast, for example "Rain
+// starting in 12 min, heavy at times". Minutes are counted from the first
+// minute of the minutely data.
+func (s *Summarizer) Minutely(f *Forecast) (string, error) {
+	data := f.Minutely.Data
+	if len(data) == 0 {
+		return "", ErrOutOfRange
+	}
+	first, last, maxIntensity, precipType := -1, -1, 0.0, ""
+	for i, p := range data {
+		if p.PrecipProbability < s.precipProbability() || p.PrecipIntensity <= 0 {
+			continue
+		}
+		if first < 0 {
+			first, precipType = i, p.PrecipType
+		} else if last < i-1 {
+			// Only the first spell of precipitation is described.
+			break
+		}
+		last = i
+		maxIntensity = math.Max(maxIntensity, p.PrecipIntensity)
+	}
+	minutes := func(i int) int { return (data[i].Time - data[0].Time) / 60 }
+
+	var clause string
+	var err error
+	args := summaryArgs{Precip: s.precip(precipType)}
+	switch {
+	case first < 0:
+		return s.message(MsgHourDry, args)
+	case first == 0 && last == len(data)-1:
+		clause, err = s.message(MsgHourThroughout, args)
+	case first == 0:
+		args.Minutes = minutes(last + 1)
+		clause, err = s.message(MsgHourStopping, args)
+	default:
+		args.Minutes = minutes(first)
+		clause, err = s.message(MsgHourStarting, args)
+	}
+	if err != nil {
+		return "", err
+	}
+	if maxIntensity >= HeavyIntensity {
+		heavy, err := s.message(MsgHeavy, args)
+		if err != nil {
+			return "", err
+		}
+		clause += ", " + heavy
+	}
+	return clause, nil
+}
+
+// Hourly summarises the next hours of the forecast, for example "Rain
+// starting around 3 PM, heavy at times; gusts up to 40 mph".
+func (s *Summarizer) Hourly(f *Forecast) (string, error) {
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return "", ErrUnableToLoadTimezone
+	}
+	data := f.Hourly.Data
+	hours := s.Hours
+	if hours <= 0 {
+		hours = 24
+	}
+	if len(data) > hours {
+		data = data[:hours]
+	}
+
+	first, last, maxIntensity, maxGust, precipType := -1, -1, 0.0, 0.0, ""
+	for i, p := range data {
+		maxGust = math.Max(maxGust, p.WindGust)
+		if p.PrecipProbability < s.precipProbability() || p.PrecipIntensity <= 0 {
+			continue
+		}
+		if first < 0 {
+			first, precipType = i, p.PrecipType
+		} else if last < i-1 {
+			// Only the first spell of precipitation is described.
+			continue
+		}
+		last = i
+		maxIntensity = math.Max(maxIntensity, p.PrecipIntensity)
+	}
+
+	var clause string
+	args := summaryArgs{Precip: s.precip(precipType)}
+	switch {
+	case first < 0:
+		clause, err = s.message(MsgDry, args)
+	case first == 0 && last == len(data)-1:
+		clause, err = s.message(MsgPrecipThroughout, args)
+	case first == 0:
+		args.Time = time.Unix(int64(data[last+1].Time), 0).In(loc).Format(s.Catalog.TimeLayout)
+		clause, err = s.message(MsgPrecipStopping, args)
+	default:
+		args.Time = time.Unix(int64(data[first].Time), 0).In(loc).Format(s.Catalog.TimeLayout)
+		clause, err = s.message(MsgPrecipStarting, args)
+	}
+	if err != nil {
+		return "", err
+	}
+	if maxIntensity >= HeavyIntensity {
+		heavy, err := s.message(MsgHeavy, args)
+		if err != nil {
+			return "", err
+		}
+		clause += ", " + heavy
+	}
+	gust := s.GustThreshold
+	if gust <= 0 {
+		gust = 25
+	}
+	if maxGust >= gust {
+		unit := s.SpeedUnit
+		if unit == "" {
+			unit = "mph"
+		}
+		gusts, err := s.message(MsgGusts, summaryArgs{Speed: int(math.Round(maxGust)), Unit: unit})
+		if err != nil {
+			return "", err
+		}
+		clause += "; " + gusts
+	}
+	return clause, nil
+}
+
+// Daily summarises the week of the forecast, for example "Rain on Monday
+// and Tuesday; highs between 49° and 69°".
+func (s *Summarizer) Daily(f *Forecast) (string, error) {
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return "", ErrUnableToLoadTimezone
+	}
+	if len(f.Daily.Data) == 0 {
+		return "", ErrOutOfRange
+	}
+	var days []string
+	precipType := ""
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, d := range f.Daily.Data {
+		low, high = math.Min(low, d.TemperatureHigh), math.Max(high, d.TemperatureHigh)
+		if d.PrecipProbability < s.precipProbability() {
+			continue
+		}
+		if precipType == "" {
+			precipType = d.PrecipType
+		}
+		days = append(days, s.Catalog.Weekdays[time.Unix(int64(d.Time), 0).In(loc).Weekday()])
+	}
+	var clause string
+	if len(days) == 0 {
+		clause, err = s.message(MsgWeekDry, summaryArgs{})
+	} else {
+		clause, err = s.message(MsgWeekPrecip, summaryArgs{Precip: s.precip(precipType), Days: s.list(days)})
+	}
+	if err != nil {
+		return "", err
+	}
+	temps, err := s.message(MsgWeekTemperatures, summaryArgs{Low: int(math.Round(low)), High: int(math.Round(high))})
+	if err != nil {
+		return "", err
+	}
+	return clause + "; " + temps, nil
+}
+
+// summaryArgs are the values available to catalog messages.
+type summaryArgs struct {
+	Precip    string
+	Time      string
+	Minutes   int
+	Days      string
+	Speed     int
+	Unit      string
+	Low, High int
+}
+
+func (s *Summarizer) precipProbability() float64 {
+	if s.PrecipProbability <= 0 {
+		return 0.3
+	}
+	return s.PrecipProbability
+}
+
+func (s *Summarizer) precip(precipType string) string {
+	if name, ok := s.Catalog.PrecipTypes[precipType]; ok {
+		return name
+	}
+	return s.Catalog.PrecipTypes["rain"]
+}
+
+func (s *Summarizer) list(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " " + s.Catalog.And + " " + items[len(items)-1]
+}
+
+func (s *Summarizer) message(id string, args summaryArgs) (string, error) {
+	src, ok := s.Catalog.Messages[id]
+	if !ok {
+		return "", fmt.Errorf("catalog %q has no message %q", s.Catalog.Language, id)
+	}
+	tmpl, err := template.New(id).Parse(src)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, args); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,197 @@
+package darksky_test
+
+import (
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+)
+
+func TestSummarizer_SouthernTerminus(t *testing.T) {
+	fc := loadForecast(t, "SouthernTerminus.json")
+	tests := map[string]struct {
+		hourly string
+		daily  string
+	}{
+		"en": {
+			hourly: "No precipitation expected; gusts up to 44 mph",
+			daily:  "Rain on Monday and Tuesday; highs between 49° and 69°",
+		},
+		"de": {
+			hourly: "Kein Niederschlag erwartet; Böen bis zu 44 mph",
+			daily:  "Regen am Montag und Dienstag; Höchstwerte zwischen 49° und 69°",
+		},
+		"es-MX": {
+			hourly: "No se espera precipitación; ráfagas de hasta 44 mph",
+			daily:  "Lluvia el lunes y martes; máximas entre 49° y 69°",
+		},
+	}
+	for lang, tc := range tests {
+		t.Run(lang, func(t *testing.T) {
+			s, err := darksky.NewSummarizer(lang)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			hourly, err := s.Hourly(fc)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if hourly != tc.hourly {
+				t.Errorf("Hourly() = %q; want %q", hourly, tc.hourly)
+			}
+			daily, err := s.Daily(fc)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if daily != tc.daily {
+				t.Errorf("Daily() = %q; want %q", daily, tc.daily)
+			}
+		})
+	}
+}
+
+// rainyAfternoon is a forecast for Los Angeles starting at 10 AM in which
+// rain starts at 3 PM, heavy at 5 PM, and stops at 8 PM.
+func rainyAfternoon() *darksky.Forecast {
+	start := time.Date(2019, 12, 17, 18, 0, 0, 0, time.UTC)
+	fc := &darksky.Forecast{Timezone: "America/Los_Angeles"}
+	for h := 0; h < 24; h++ {
+		p := darksky.HourlyDataPoint{Time: int(start.Add(time.Duration(h) * time.Hour).Unix()), WindGust: 20}
+		if h >= 5 && h < 10 {
+			p.PrecipType, p.PrecipProbability, p.PrecipIntensity = "rain", 0.8, 0.1
+		}
+		if h == 7 {
+			p.PrecipIntensity, p.WindGust = 0.5, 40.4
+		}
+		fc.Hourly.Data = append(fc.Hourly.Data, p)
+	}
+	return fc
+}
+
+func TestSummarizer_Hourly(t *testing.T) {
+	tests := map[string]struct {
+		lang    string
+		hours   int
+		from    int
+		showers []int // hours of a later spell of rain
+		want    string
+	}{
+		"starting":            {lang: "en", want: "Rain starting around 3 PM, heavy at times; gusts up to 40 mph"},
+		"starting in German":  {lang: "de", want: "Regen ab etwa 15 Uhr, zeitweise stark; Böen bis zu 40 mph"},
+		"starting in Spanish": {lang: "es", want: "Lluvia a partir de las 15:00, fuerte a ratos; ráfagas de hasta 40 mph"},
+		"stopping":            {lang: "en", from: 8, want: "Rain stopping around 8 PM"},
+		"throughout":          {lang: "en", from: 5, hours: 4, want: "Rain throughout, heavy at times; gusts up to 40 mph"},
+		"two spells":          {lang: "en", from: 8, showers: []int{12}, want: "Rain stopping around 8 PM"},
+		"rain again at last":  {lang: "en", from: 8, showers: []int{15}, want: "Rain stopping around 8 PM"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fc := rainyAfternoon()
+			fc.Hourly.Data = fc.Hourly.Data[tc.from:]
+			for _, h := range tc.showers {
+				p := &fc.Hourly.Data[h]
+				p.PrecipType, p.PrecipProbability, p.PrecipIntensity = "rain", 0.8, 0.6
+			}
+			s, err := darksky.NewSummarizer(tc.lang)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			s.Hours = tc.hours
+			got, err := s.Hourly(fc)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if got != tc.want {
+				t.Errorf("Hourly() = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// showersSoon is an hour of minutely data in which rain starts after 12
+// minutes, is heavy after 20 and stops after 40.
+func showersSoon() *darksky.Forecast {
+	start := time.Date(2019, 12, 17, 18, 0, 0, 0, time.UTC)
+	fc := &darksky.Forecast{Timezone: "America/Los_Angeles"}
+	for m := 0; m <= 60; m++ {
+		p := darksky.MinutelyDataPoint{Time: int(start.Add(time.Duration(m) * time.Minute).Unix())}
+		if m >= 12 && m < 40 {
+			p.PrecipType, p.PrecipProbability, p.PrecipIntensity = "rain", 0.7, 0.05
+		}
+		if m == 20 {
+			p.PrecipIntensity = 0.6
+		}
+		fc.Minutely.Data = append(fc.Minutely.Data, p)
+	}
+	return fc
+}
+
+func TestSummarizer_Minutely(t *testing.T) {
+	tests := map[string]struct {
+		lang     string
+		from, to int
+		showers  []int // minutes of a later spell of rain
+		want     string
+	}{
+		"starting":            {lang: "en", to: 61, want: "Rain starting in 12 min, heavy at times"},
+		"starting in German":  {lang: "de", to: 61, want: "Regen in 12 Min., zeitweise stark"},
+		"starting in Spanish": {lang: "es", to: 61, want: "Lluvia en 12 min, fuerte a ratos"},
+		"stopping":            {lang: "en", from: 25, to: 61, want: "Rain stopping in 15 min"},
+		"throughout":          {lang: "en", from: 12, to: 40, want: "Rain for the hour, heavy at times"},
+		"dry":                 {lang: "de", from: 40, to: 61, want: "Kein Niederschlag in der nächsten Stunde"},
+		"two spells":          {lang: "en", from: 25, to: 61, showers: []int{30, 35}, want: "Rain stopping in 15 min"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fc := showersSoon()
+			fc.Minutely.Data = fc.Minutely.Data[tc.from:tc.to]
+			for _, m := range tc.showers {
+				p := &fc.Minutely.Data[m]
+				p.PrecipType, p.PrecipProbability, p.PrecipIntensity = "rain", 0.7, 0.6
+			}
+			s, err := darksky.NewSummarizer(tc.lang)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			got, err := s.Minutely(fc)
+			if err != nil {
+				t.Fatalf("err = %v; want nil", err)
+			}
+			if got != tc.want {
+				t.Errorf("Minutely() = %q; want %q", got, tc.want)
+			}
+		})
+	}
+
+	s, _ := darksky.NewSummarizer("en")
+	if _, err := s.Minutely(&darksky.Forecast{}); err != darksky.ErrOutOfRange {
+		t.Errorf("err = %v; want %v", err, darksky.ErrOutOfRange)
+	}
+}
+
+func TestRegisterCatalog(t *testing.T) {
+	if _, err := darksky.NewSummarizer("fr"); err == nil {
+		t.Fatalf("err = nil; want an error for a language without a catalog")
+	}
+	fr := *darksky.English
+	fr.Language = "fr"
+	fr.Messages = map[string]string{darksky.MsgDry: "Pas de précipitations prévues"}
+	darksky.RegisterCatalog(&fr)
+	t.Cleanup(func() { darksky.UnregisterCatalog("fr") })
+	s, err := darksky.NewSummarizer("fr")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	fc := loadForecast(t, "SouthernTerminus.json")
+	if _, err := s.Hourly(fc); err == nil {
+		t.Errorf("err = nil; want an error for the missing gusts message")
+	}
+	s.GustThreshold = 50
+	got, err := s.Hourly(fc)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if got != "Pas de précipitations prévues" {
+		t.Errorf("Hourly() = %q; want the registered message", got)
+	}
+}
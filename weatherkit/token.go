@@ -0,0 +1,108 @@
+package weatherkit
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"time"
+)
+
+// ErrInvalidKey is returned when a key is not a PEM encoded PKCS #8 P-256
+// private key, as the .p8 files Apple issues are.
+var ErrInvalidKey = errors.New("Invalid WeatherKit Key")
+
+// DefaultTokenTTL is how long the tokens a Client signs are valid for.
+const DefaultTokenTTL = time.Hour
+
+// LoadKey reads the private key from a .p8 file downloaded from the Apple
+// developer portal.
+func LoadKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKey(data)
+}
+
+// ParseKey parses a PEM encoded PKCS #8 P-256 private key.
+func ParseKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, ErrInvalidKey
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok || ecKey.Curve != elliptic.P256() {
+		return nil, ErrInvalidKey
+	}
+	return ecKey, nil
+}
+
+// Token signs a developer token for the WeatherKit REST API, issued at now
+// and valid for ttl.
+func (c *Client) Token(now time.Time, ttl time.Duration) (string, error) {
+	if c.Key == nil {
+		return "", ErrInvalidKey
+	}
+	header, err := json.Marshal(map[string]string{
+		"alg": "ES256",
+		"typ": "JWT",
+		"kid": c.KeyID,
+		"id":  c.TeamID + "." + c.ServiceID,
+	})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss": c.TeamID,
+		"sub": c.ServiceID,
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, c.Key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// JWS wants the signature as the two 32 byte integers, not ASN.1.
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+// token returns the cached token, signing a new one if it expires within a
+// minute.
+func (c *Client) token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.cached != "" && now.Add(time.Minute).Before(c.expires) {
+		return c.cached, nil
+	}
+	ttl := c.TokenTTL
+	if ttl == 0 {
+		ttl = DefaultTokenTTL
+	}
+	tok, err := c.Token(now, ttl)
+	if err != nil {
+		return "", err
+	}
+	c.cached, c.expires = tok, now.Add(ttl)
+	return tok, nil
+}
@@ -0,0 +1,399 @@
+// Package weatherkit adapts Apple's WeatherKit REST API, the successor to
+// Dark Sky, to darksky.Provider, mapping its responses into the
+// darksky.Forecast model in US units.
+package weatherkit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/astro"
+)
+
+const DefaultBaseURL = "https://weatherkit.apple.com"
+
+// ErrNoTimezone is returned when a Client has no Timezone.
+var ErrNoTimezone = errors.New("WeatherKit Timezone Not Set")
+
+const (
+	mmPerInch     = 25.4
+	metresPerMile = 1609.344
+	kmPerMile     = 1.609344
+)
+
+// Client fetches forecasts from WeatherKit, signing its own developer tokens
+// with the key from the Apple developer portal.
+type Client struct {
+	Key       *ecdsa.PrivateKey
+	KeyID     string
+	TeamID    string
+	ServiceID string
+	TokenTTL  time.Duration // DefaultTokenTTL if zero
+
+	// Language is the language of summaries, "en" if empty. Daily
+	// forecasts are split into days in Timezone, an IANA name such as
+	// "America/Los_Angeles", which is required since WeatherKit does not
+	// report the timezone of a location. Alerts are only requested if
+	// CountryCode, e.g. "US", is set.
+	Language    string
+	Timezone    string
+	CountryCode string
+
+	BaseURL    string
+	HttpClient darksky.Doer
+
+	mu      sync.Mutex
+	cached  string
+	expires time.Time
+}
+
+var _ darksky.Provider = (*Client)(nil)
+
+// Forecast returns the current conditions and the forecast for the next
+// days at the given coordinates.
+func (c *Client) Forecast(lat, long float64) (*darksky.Forecast, error) {
+	return c.ForecastContext(context.Background(), lat, long)
+}
+
+// ForecastContext is like Forecast but makes the request with ctx.
+func (c *Client) ForecastContext(ctx context.Context, lat, long float64) (*darksky.Forecast, error) {
+	if err := (darksky.Location{Lat: lat, Long: long}).Validate(); err != nil {
+		return nil, err
+	}
+	if c.Timezone == "" {
+		return nil, ErrNoTimezone
+	}
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	lang := c.Language
+	if lang == "" {
+		lang = "en"
+	}
+	dataSets := []string{"currentWeather", "forecastHourly", "forecastDaily", "forecastNextHour"}
+	q := url.Values{}
+	q.Set("timezone", c.Timezone)
+	if c.CountryCode != "" {
+		dataSets = append(dataSets, "weatherAlerts")
+		q.Set("countryCode", c.CountryCode)
+	}
+	q.Set("dataSets", strings.Join(dataSets, ","))
+	path := "/api/v1/weather/" + url.PathEscape(lang) + "/" +
+		strconv.FormatFloat(lat, 'f', -1, 64) + "/" + strconv.FormatFloat(long, 'f', -1, 64)
+	req, err := http.NewRequest(http.MethodGet, base+path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		return nil, darksky.ErrBadRequest
+	}
+	f, err := Decode(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.Latitude, f.Longitude, f.Timezone = lat, long, c.Timezone
+	return f, nil
+}
+
+// Conditions are the fields WeatherKit's current and hourly conditions have
+// in common. Units are metric: °C, km/h, mm, metres and millibars.
+type Conditions struct {
+	CloudCover             float64 `json:"cloudCover"`
+	ConditionCode          string  `json:"conditionCode"`
+	Daylight               bool    `json:"daylight"`
+	Humidity               float64 `json:"humidity"`
+	PrecipitationIntensity float64 `json:"precipitationIntensity"`
+	Pressure               float64 `json:"pressure"`
+	Temperature            float64 `json:"temperature"`
+	TemperatureApparent    float64 `json:"temperatureApparent"`
+	TemperatureDewPoint    float64 `json:"temperatureDewPoint"`
+	UVIndex                int     `json:"uvIndex"`
+	Visibility             float64 `json:"visibility"`
+	WindDirection          float64 `json:"windDirection"`
+	WindGust               float64 `json:"windGust"`
+	WindSpeed              float64 `json:"windSpeed"`
+}
+
+// Response is the part of a WeatherKit weather response that maps into
+// darksky.Forecast. Data sets that were not requested are nil.
+type Response struct {
+	CurrentWeather *struct {
+		AsOf time.Time `json:"asOf"`
+		Conditions
+	} `json:"currentWeather"`
+	ForecastHourly *struct {
+		Hours []struct {
+			ForecastStart       time.Time `json:"forecastStart"`
+			PrecipitationChance float64   `json:"precipitationChance"`
+			PrecipitationType   string    `json:"precipitationType"`
+			Conditions
+		} `json:"hours"`
+	} `json:"forecastHourly"`
+	ForecastDaily *struct {
+		Days []struct {
+			ForecastStart       time.Time `json:"forecastStart"`
+			ConditionCode       string    `json:"conditionCode"`
+			MaxUVIndex          int       `json:"maxUvIndex"`
+			PrecipitationChance float64   `json:"precipitationChance"`
+			PrecipitationType   string    `json:"precipitationType"`
+			Sunrise             time.Time `json:"sunrise"`
+			Sunset              time.Time `json:"sunset"`
+			TemperatureMax      float64   `json:"temperatureMax"`
+			TemperatureMin      float64   `json:"temperatureMin"`
+			DaytimeForecast     struct {
+				CloudCover float64 `json:"cloudCover"`
+				Humidity   float64 `json:"humidity"`
+				WindSpeed  float64 `json:"windSpeed"`
+			} `json:"daytimeForecast"`
+		} `json:"days"`
+	} `json:"forecastDaily"`
+	ForecastNextHour *struct {
+		Minutes []struct {
+			StartTime              time.Time `json:"startTime"`
+			PrecipitationChance    float64   `json:"precipitationChance"`
+			PrecipitationIntensity float64   `json:"precipitationIntensity"`
+		} `json:"minutes"`
+	} `json:"forecastNextHour"`
+	WeatherAlerts *struct {
+		Alerts []struct {
+			AreaName      string    `json:"areaName"`
+			Description   string    `json:"description"`
+			EffectiveTime time.Time `json:"effectiveTime"`
+			ExpireTime    time.Time `json:"expireTime"`
+			DetailsURL    string    `json:"detailsUrl"`
+		} `json:"alerts"`
+	} `json:"weatherAlerts"`
+}
+
+// Decode reads a WeatherKit weather response and converts it.
+func Decode(r io.Reader) (*darksky.Forecast, error) {
+	var res Response
+	if err := json.NewDecoder(r).Decode(&res); err != nil {
+		return nil, err
+	}
+	return Convert(&res), nil
+}
+
+// Convert maps a WeatherKit response into a darksky.Forecast, converting
+// metric units to US ones. WeatherKit gives only the name of the moon's
+// phase, so the phase is computed with the astro package. The response
+// carries no coordinates or time zone; ForecastContext fills them in.
+func Convert(r *Response) *darksky.Forecast {
+	f := &darksky.Forecast{}
+
+	if h := r.ForecastHourly; h != nil {
+		for _, h := range h.Hours {
+			f.Hourly.Data = append(f.Hourly.Data, darksky.HourlyDataPoint{
+				Time:                int(h.ForecastStart.Unix()),
+				Summary:             summary(h.ConditionCode),
+				Icon:                icon(h.ConditionCode, h.Daylight),
+				PrecipIntensity:     h.PrecipitationIntensity / mmPerInch,
+				PrecipProbability:   h.PrecipitationChance,
+				PrecipType:          precipType(h.PrecipitationType),
+				Temperature:         fahrenheit(h.Temperature),
+				ApparentTemperature: fahrenheit(h.TemperatureApparent),
+				DewPoint:            fahrenheit(h.TemperatureDewPoint),
+				Humidity:            h.Humidity,
+				Pressure:            h.Pressure,
+				WindSpeed:           h.WindSpeed / kmPerMile,
+				WindGust:            h.WindGust / kmPerMile,
+				WindBearing:         int(math.Round(h.WindDirection)) % 360,
+				CloudCover:          h.CloudCover,
+				UvIndex:             h.UVIndex,
+				Visibility:          h.Visibility / metresPerMile,
+			})
+		}
+	}
+
+	if cur := r.CurrentWeather; cur != nil {
+		f.Currently = darksky.CurrentDataPoint{
+			Time:                int(cur.AsOf.Unix()),
+			Summary:             summary(cur.ConditionCode),
+			Icon:                icon(cur.ConditionCode, cur.Daylight),
+			PrecipIntensity:     cur.PrecipitationIntensity / mmPerInch,
+			Temperature:         fahrenheit(cur.Temperature),
+			ApparentTemperature: fahrenheit(cur.TemperatureApparent),
+			DewPoint:            fahrenheit(cur.TemperatureDewPoint),
+			Humidity:            cur.Humidity,
+			Pressure:            cur.Pressure,
+			WindSpeed:           cur.WindSpeed / kmPerMile,
+			WindGust:            cur.WindGust / kmPerMile,
+			WindBearing:         int(math.Round(cur.WindDirection)) % 360,
+			CloudCover:          cur.CloudCover,
+			UvIndex:             cur.UVIndex,
+			Visibility:          cur.Visibility / metresPerMile,
+		}
+		// The fields the current conditions lack come from the hour they
+		// fall in.
+		for _, p := range f.Hourly.Data {
+			if p.Time > f.Currently.Time {
+				break
+			}
+			f.Currently.PrecipProbability = p.PrecipProbability
+			f.Currently.PrecipType = p.PrecipType
+		}
+	}
+
+	if n := r.ForecastNextHour; n != nil {
+		for _, m := range n.Minutes {
+			p := darksky.MinutelyDataPoint{
+				Time:              int(m.StartTime.Unix()),
+				PrecipIntensity:   m.PrecipitationIntensity / mmPerInch,
+				PrecipProbability: m.PrecipitationChance,
+			}
+			if p.PrecipIntensity > 0 {
+				p.PrecipType = f.Currently.PrecipType
+				if p.PrecipType == "" {
+					p.PrecipType = "rain"
+				}
+			}
+			f.Minutely.Data = append(f.Minutely.Data, p)
+		}
+	}
+
+	if d := r.ForecastDaily; d != nil {
+		for _, d := range d.Days {
+			f.Daily.Data = append(f.Daily.Data, darksky.DailyDataPoint{
+				Time:              int(d.ForecastStart.Unix()),
+				Summary:           summary(d.ConditionCode),
+				Icon:              icon(d.ConditionCode, true),
+				SunriseTime:       unix(d.Sunrise),
+				SunsetTime:        unix(d.Sunset),
+				MoonPhase:         math.Round(astro.MoonPhase(d.ForecastStart.Add(12*time.Hour))*100) / 100,
+				PrecipProbability: d.PrecipitationChance,
+				PrecipType:        precipType(d.PrecipitationType),
+				TemperatureHigh:   fahrenheit(d.TemperatureMax),
+				TemperatureLow:    fahrenheit(d.TemperatureMin),
+				Humidity:          d.DaytimeForecast.Humidity,
+				WindSpeed:         d.DaytimeForecast.WindSpeed / kmPerMile,
+				CloudCover:        d.DaytimeForecast.CloudCover,
+				UvIndex:           d.MaxUVIndex,
+				TemperatureMin:    fahrenheit(d.TemperatureMin),
+				TemperatureMax:    fahrenheit(d.TemperatureMax),
+			})
+		}
+	}
+
+	if a := r.WeatherAlerts; a != nil {
+		for _, a := range a.Alerts {
+			title := a.Description
+			if a.AreaName != "" {
+				title += " for " + a.AreaName
+			}
+			f.Alerts = append(f.Alerts, darksky.Alert{
+				Title:   title,
+				Time:    unix(a.EffectiveTime),
+				Expires: unix(a.ExpireTime),
+				URI:     a.DetailsURL,
+			})
+		}
+	}
+
+	if len(f.Hourly.Data) > 0 {
+		f.Hourly.Summary, f.Hourly.Icon = f.Hourly.Data[0].Summary, f.Hourly.Data[0].Icon
+	}
+	if len(f.Daily.Data) > 0 {
+		f.Daily.Summary, f.Daily.Icon = f.Daily.Data[0].Summary, f.Daily.Data[0].Icon
+	}
+	return f
+}
+
+// icon maps a WeatherKit condition code to a Dark Sky icon.
+func icon(code string, daylight bool) string {
+	switch code {
+	case "Clear", "MostlyClear":
+		return dayNight("clear", daylight)
+	case "PartlyCloudy", "MostlyCloudy":
+		return dayNight("partly-cloudy", daylight)
+	case "Cloudy":
+		return "cloudy"
+	case "Foggy", "Haze", "Smoky", "BlowingDust":
+		return "fog"
+	case "Breezy", "Windy", "TropicalStorm", "Hurricane":
+		return "wind"
+	case "Drizzle", "Rain", "HeavyRain", "SunShowers":
+		return "rain"
+	case "Flurries", "Snow", "HeavySnow", "SunFlurries", "BlowingSnow", "Blizzard":
+		return "snow"
+	case "Sleet", "FreezingDrizzle", "FreezingRain", "WintryMix", "Hail":
+		return "sleet"
+	case "Thunderstorms", "IsolatedThunderstorms", "ScatteredThunderstorms", "StrongStorms":
+		return "thunderstorm"
+	}
+	return ""
+}
+
+func dayNight(icon string, day bool) string {
+	if day {
+		return icon + "-day"
+	}
+	return icon + "-night"
+}
+
+// precipType maps a WeatherKit precipitation type to a Dark Sky one.
+func precipType(t string) string {
+	switch t {
+	case "rain", "precipitation":
+		return "rain"
+	case "snow":
+		return "snow"
+	case "sleet", "hail", "mixed":
+		return "sleet"
+	}
+	return ""
+}
+
+// summary spells out a condition code, e.g. "MostlyCloudy" as "Mostly
+// Cloudy".
+func summary(code string) string {
+	var b strings.Builder
+	for i, r := range code {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func fahrenheit(c float64) float64 {
+	return math.Round((c*9/5+32)*100) / 100
+}
+
+// unix returns t as UNIX time, or 0 for the zero time WeatherKit leaves out
+// on days without a sunrise or sunset.
+func unix(t time.Time) int {
+	if t.IsZero() {
+		return 0
+	}
+	return int(t.Unix())
+}
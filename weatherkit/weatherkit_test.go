@@ -0,0 +1,240 @@
+package weatherkit_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	darksky "github.com/sophiaehlen/darksky-client"
+	"github.com/sophiaehlen/darksky-client/weatherkit"
+)
+
+// testKey generates a key and writes it to a .p8 file the way Apple issues
+// them.
+func testKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	path := filepath.Join(t.TempDir(), "AuthKey_ABC123DEFG.p8")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	return key, path
+}
+
+// verifyToken checks a token's signature and returns its header and claims.
+func verifyToken(pub *ecdsa.PublicKey, token string) (header, claims map[string]interface{}, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sig) != 64 {
+		return nil, nil, false
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(pub, digest[:], r, s) {
+		return nil, nil, false
+	}
+	for i, v := range []*map[string]interface{}{&header, &claims} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil || json.Unmarshal(data, v) != nil {
+			return nil, nil, false
+		}
+	}
+	return header, claims, true
+}
+
+func TestLoadKey(t *testing.T) {
+	key, path := testKey(t)
+	got, err := weatherkit.LoadKey(path)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if !got.Equal(key) {
+		t.Errorf("LoadKey() returned a different key")
+	}
+
+	rsaLike := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("junk")})
+	for name, data := range map[string][]byte{
+		"not pem":   []byte("not a key"),
+		"wrong pem": rsaLike,
+		"garbage":   pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("junk")}),
+	} {
+		if _, err := weatherkit.ParseKey(data); err != weatherkit.ErrInvalidKey {
+			t.Errorf("%s: err = %v; want %v", name, err, weatherkit.ErrInvalidKey)
+		}
+	}
+}
+
+func TestClient_Token(t *testing.T) {
+	key, _ := testKey(t)
+	c := &weatherkit.Client{Key: key, KeyID: "ABC123DEFG", TeamID: "TEAM123456", ServiceID: "com.example.weather"}
+	now := time.Unix(1576606500, 0)
+	token, err := c.Token(now, time.Hour)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	header, claims, ok := verifyToken(&key.PublicKey, token)
+	if !ok {
+		t.Fatalf("Token() = %q; want a valid ES256 JWT", token)
+	}
+	if header["alg"] != "ES256" || header["kid"] != "ABC123DEFG" || header["id"] != "TEAM123456.com.example.weather" {
+		t.Errorf("header = %v", header)
+	}
+	if claims["iss"] != "TEAM123456" || claims["sub"] != "com.example.weather" ||
+		claims["iat"] != float64(1576606500) || claims["exp"] != float64(1576610100) {
+		t.Errorf("claims = %v", claims)
+	}
+
+	if _, err := (&weatherkit.Client{}).Token(now, time.Hour); err != weatherkit.ErrInvalidKey {
+		t.Errorf("err = %v; want %v", err, weatherkit.ErrInvalidKey)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	f, err := os.Open("testdata/weather.json")
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	defer f.Close()
+	fc, err := weatherkit.Decode(f)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	approx := func(got, want float64) bool { return math.Abs(got-want) < 0.01 }
+
+	cur := fc.Currently
+	if cur.Time != 1576606500 || cur.Summary != "Mostly Clear" || cur.Icon != "clear-day" {
+		t.Errorf("Currently time, summary, icon = %d, %q, %q", cur.Time, cur.Summary, cur.Icon)
+	}
+	if !approx(cur.Temperature, 45.32) || !approx(cur.WindSpeed, 24.6) || !approx(cur.Visibility, 10) {
+		t.Errorf("Currently temperature, wind, visibility = %v, %v, %v; want 45.32, 24.6, 10", cur.Temperature, cur.WindSpeed, cur.Visibility)
+	}
+	if cur.PrecipProbability != 0.01 || cur.Humidity != 0.41 {
+		t.Errorf("Currently probability, humidity = %v, %v; want 0.01, 0.41", cur.PrecipProbability, cur.Humidity)
+	}
+
+	if len(fc.Hourly.Data) != 3 {
+		t.Fatalf("len(Hourly.Data) = %d; want 3", len(fc.Hourly.Data))
+	}
+	if h := fc.Hourly.Data[1]; h.Icon != "rain" || h.PrecipType != "rain" || !approx(h.PrecipIntensity, 0.1) || h.WindBearing != 0 {
+		t.Errorf("Hourly.Data[1] = %+v", h)
+	}
+	if h := fc.Hourly.Data[2]; h.Icon != "sleet" || h.PrecipType != "sleet" || h.Summary != "Wintry Mix" || h.Temperature != 32 {
+		t.Errorf("Hourly.Data[2] = %+v", h)
+	}
+
+	if len(fc.Minutely.Data) != 3 {
+		t.Fatalf("len(Minutely.Data) = %d; want 3", len(fc.Minutely.Data))
+	}
+	if m := fc.Minutely.Data[1]; !approx(m.PrecipIntensity, 0.01) || m.PrecipType != "rain" || m.PrecipProbability != 0.3 {
+		t.Errorf("Minutely.Data[1] = %+v", m)
+	}
+
+	if len(fc.Daily.Data) != 2 {
+		t.Fatalf("len(Daily.Data) = %d; want 2", len(fc.Daily.Data))
+	}
+	d := fc.Daily.Data[0]
+	if d.Icon != "wind" || d.SunriseTime != int(time.Date(2019, 12, 17, 14, 43, 0, 0, time.UTC).Unix()) || d.UvIndex != 4 {
+		t.Errorf("Daily.Data[0] = %+v", d)
+	}
+	// Dark Sky reported 0.72 for the same day.
+	if math.Abs(d.MoonPhase-0.72) > 0.03 {
+		t.Errorf("MoonPhase = %v; want about 0.72", d.MoonPhase)
+	}
+	if d := fc.Daily.Data[1]; d.PrecipType != "snow" || !approx(d.TemperatureHigh, 38.3) || !approx(d.TemperatureLow, 29.84) {
+		t.Errorf("Daily.Data[1] = %+v", d)
+	}
+
+	if len(fc.Alerts) != 1 {
+		t.Fatalf("len(Alerts) = %d; want 1", len(fc.Alerts))
+	}
+	if a := fc.Alerts[0]; a.Title != "Wind Advisory for San Diego County Mountains" || a.URI == "" {
+		t.Errorf("Alerts[0] = %+v", a)
+	}
+}
+
+func TestClient_Forecast(t *testing.T) {
+	key, _ := testKey(t)
+	var tokens []string
+	var dataSets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if _, _, ok := verifyToken(&key.PublicKey, token); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		tokens = append(tokens, token)
+		if r.URL.Path != "/api/v1/weather/en/32.58972/-116.466988" || r.URL.Query().Get("timezone") != "America/Los_Angeles" {
+			http.NotFound(w, r)
+			return
+		}
+		dataSets = strings.Split(r.URL.Query().Get("dataSets"), ",")
+		http.ServeFile(w, r, "testdata/weather.json")
+	}))
+	defer server.Close()
+
+	c := &weatherkit.Client{
+		Key:         key,
+		KeyID:       "ABC123DEFG",
+		TeamID:      "TEAM123456",
+		ServiceID:   "com.example.weather",
+		Timezone:    "America/Los_Angeles",
+		CountryCode: "US",
+		BaseURL:     server.URL,
+	}
+	var p darksky.Provider = c
+	fc, err := p.ForecastContext(context.Background(), 32.58972, -116.466988)
+	if err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if fc.Latitude != 32.58972 || fc.Timezone != "America/Los_Angeles" || len(fc.Alerts) != 1 {
+		t.Errorf("Forecast() = %+v", fc)
+	}
+	if len(dataSets) != 5 || dataSets[4] != "weatherAlerts" {
+		t.Errorf("dataSets = %v; want the four forecasts and weatherAlerts", dataSets)
+	}
+
+	if _, err := p.Forecast(32.58972, -116.466988); err != nil {
+		t.Fatalf("err = %v; want nil", err)
+	}
+	if len(tokens) != 2 || tokens[0] != tokens[1] {
+		t.Errorf("tokens = %v; want the first token reused", tokens)
+	}
+
+	other, _ := testKey(t)
+	c = &weatherkit.Client{Key: other, Timezone: "America/Los_Angeles", BaseURL: server.URL}
+	if _, err := c.Forecast(32.58972, -116.466988); err != darksky.ErrBadRequest {
+		t.Errorf("err = %v; want %v", err, darksky.ErrBadRequest)
+	}
+
+	c.Timezone = ""
+	if _, err := c.Forecast(32.58972, -116.466988); err != weatherkit.ErrNoTimezone {
+		t.Errorf("err = %v; want %v", err, weatherkit.ErrNoTimezone)
+	}
+}